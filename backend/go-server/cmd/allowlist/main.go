@@ -0,0 +1,82 @@
+// allowlist はフロントエンドのGraphQL操作から許可リストのマニフェストを生成する
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/s-blog/backend/go-server/interface/graphql/allowlist"
+)
+
+func main() {
+	src := flag.String("src", "../../frontend/src", "directory containing the frontend sources")
+	out := flag.String("out", "interface/graphql/allowlist/operations.json", "manifest file to write")
+	flag.Parse()
+
+	if err := run(*src, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(src, out string) error {
+	var ops []allowlist.Operation
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "node_modules" || d.Name() == ".next" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".ts" && ext != ".tsx" {
+			return nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		found, err := allowlist.Extract(string(b), filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		ops = append(ops, found...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Name < ops[j].Name })
+	for i := 1; i < len(ops); i++ {
+		if ops[i].Name == ops[i-1].Name {
+			return fmt.Errorf("duplicate operation name %q in %s and %s", ops[i].Name, ops[i-1].Source, ops[i].Source)
+		}
+	}
+	// 生成したマニフェストがそのまま読み込めることを確認する
+	if _, err := allowlist.New(allowlist.Manifest{Operations: ops}); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(allowlist.Manifest{Operations: ops}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %d operations to %s\n", len(ops), strings.TrimPrefix(out, "./"))
+	return nil
+}
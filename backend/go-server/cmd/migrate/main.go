@@ -1,27 +1,53 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"os"
 
 	"github.com/joho/godotenv"
+	"github.com/s-blog/backend/go-server/domain/config"
 	"github.com/s-blog/backend/go-server/infrastructure/gorm"
+	infralog "github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/registry"
+	"go.uber.org/zap"
 )
 
 func main() {
+	ctx := context.Background()
+	logger := infralog.New(os.Stdout)
+
 	// .env ファイルを読み込む
 	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .envファイルが見つかりません: %v", err)
+		logger.Warn(ctx, ".envファイルが見つかりません", zap.Error(err))
+	}
+
+	cfg, err := config.New(ctx)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("設定の読み込みに失敗しました: %v", err))
+		os.Exit(1)
+	}
+	db := cfg.Database
+	logger.Info(ctx, "データベース接続情報",
+		zap.String("host", db.Host), zap.Int("port", db.Port), zap.String("user", db.User), zap.String("dbname", db.Name))
+
+	creds, err := registry.NewCredentialProvider(db)
+	if err != nil {
+		logger.Error(ctx, "failed to init database credentials", zap.Error(err))
+		os.Exit(1)
 	}
 
 	// データベース接続を初期化
-	gorm.InitDB()
+	if err := gorm.InitDB(ctx, logger, db.DataSourceName(), creds); err != nil {
+		logger.Error(ctx, "データベースへの接続に失敗しました", zap.Error(err))
+		os.Exit(1)
+	}
 
 	// マイグレーションを実行
-	if err := gorm.MigrateDB(); err != nil {
-		log.Fatalf("マイグレーションに失敗しました: %v", err)
+	if err := gorm.MigrateDB(ctx, logger, cfg.AppEnv == "development"); err != nil {
+		logger.Error(ctx, "マイグレーションに失敗しました", zap.Error(err))
 		os.Exit(1)
 	}
 
-	log.Println("マイグレーションが正常に完了しました")
+	logger.Info(ctx, "マイグレーションが正常に完了しました")
 }
@@ -2,16 +2,21 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
+	"go.uber.org/zap"
 
 	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/infrastructure/clientip"
 	infralog "github.com/s-blog/backend/go-server/infrastructure/log"
 	ihttp "github.com/s-blog/backend/go-server/interface/http"
 	"github.com/s-blog/backend/go-server/registry"
@@ -21,43 +26,125 @@ func main() {
 	godotenv.Load()
 
 	ctx := context.Background()
-	cfg := config.MustNew(ctx)
 	logger := infralog.New(os.Stdout)
-	logger.Info(ctx, "starting server...")
-
-	muxServer, cleanup, err := registry.InitMuxServer(ctx, cfg)
+	cfg, err := config.New(ctx)
 	if err != nil {
-		logger.Error(ctx, fmt.Sprintf("failed to init mux server: %v", err))
+		logger.Error(ctx, fmt.Sprintf("failed to load config: %v", err))
+		os.Exit(1)
+	}
+	logger.Info(ctx, "starting server...", zap.Any("config", cfg.Redacted()))
+
+	if err := run(ctx, cfg, logger); err != nil {
+		logger.Error(ctx, fmt.Sprintf("server error: %v", err))
 		os.Exit(1)
 	}
+	logger.Info(ctx, "server stopped")
+}
+
+// run SIGINT/SIGTERM を受けるまでサーバーを動かし、処理中のリクエストを待ってから依存先を閉じる
+func run(ctx context.Context, cfg *config.Vars, logger *infralog.Logger) error {
+	muxServer, cleanup, err := registry.InitMuxServer(ctx, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("failed to init mux server: %w", err)
+	}
+	// HTTPサーバーを止めた後に、WebSocket・ワーカー・DBの順に閉じる
 	defer cleanup()
 
 	withLoggerHandler := func(next http.Handler) http.HandlerFunc {
 		return ihttp.WithLogger(next.ServeHTTP, logger)
 	}
+	withTracingHandler := func(next http.Handler) http.HandlerFunc {
+		return ihttp.WithTracing(next.ServeHTTP, muxServer.TracerProvider)
+	}
+	withMetricsHandler := func(next http.Handler) http.HandlerFunc {
+		return ihttp.WithMetrics(next.ServeHTTP, muxServer.Metrics.HTTP, func(r *http.Request) string {
+			if _, pattern := muxServer.Mux.Handler(r); pattern != "" {
+				return pattern
+			}
+			return "unmatched"
+		})
+	}
+	withRequestIDHandler := func(next http.Handler) http.HandlerFunc {
+		return ihttp.WithRequestID(next.ServeHTTP)
+	}
+	withDBSessionHandler := func(next http.Handler) http.HandlerFunc {
+		return ihttp.WithDBSession(next.ServeHTTP)
+	}
+	withAuthHandler := func(next http.Handler) http.HandlerFunc {
+		return ihttp.WithAuth(next.ServeHTTP, muxServer.Verifier)
+	}
+
+	ipResolver, err := clientip.NewResolver(cfg.Server.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("failed to init client ip resolver: %w", err)
+	}
+	withClientIPHandler := func(next http.Handler) http.HandlerFunc {
+		return ihttp.WithClientIP(next.ServeHTTP, ipResolver)
+	}
 
 	rootMux := http.NewServeMux()
-	rootMux.Handle("/", withLoggerHandler(muxServer.Mux))
+	rootMux.Handle("/", withLoggerHandler(withTracingHandler(withMetricsHandler(withRequestIDHandler(withDBSessionHandler(withClientIPHandler(withAuthHandler(muxServer.Mux))))))))
 
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:8080"},
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
 		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodOptions},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Request-ID", "traceparent", "tracestate", "baggage"},
+		ExposedHeaders:   []string{"X-Request-ID"},
 		AllowCredentials: true,
 		Debug:            true,
 	})
 
 	httpHandler := c.Handler(rootMux)
 
-	logger.Info(ctx, fmt.Sprintf("Starting a server on port %d with %s", cfg.Port, runtime.Version()))
-	httpServer := &http.Server{
+	servers := []*http.Server{{
 		Addr:              fmt.Sprintf(":%d", cfg.Port),
 		Handler:           httpHandler,
 		ReadHeaderTimeout: 30 * time.Second,
+	}}
+	logger.Info(ctx, fmt.Sprintf("Starting a server on port %d with %s", cfg.Port, runtime.Version()))
+
+	if cfg.Metrics.Port > 0 {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle(cfg.Metrics.Path, muxServer.Metrics.Handler())
+		servers = append(servers, &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.Metrics.Port),
+			Handler:           metricsMux,
+			ReadHeaderTimeout: 30 * time.Second,
+		})
+		logger.Info(ctx, fmt.Sprintf("Serving metrics on port %d at %s", cfg.Metrics.Port, cfg.Metrics.Path))
 	}
-	if err := httpServer.ListenAndServe(); err != nil {
-		cleanup()
-		logger.Error(ctx, fmt.Sprintf("server error: %v", err))
-		os.Exit(1)
+
+	errc := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errc <- fmt.Errorf("server on %s failed: %w", srv.Addr, err)
+			}
+		}()
+	}
+
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// いずれかのリスナーが失敗した場合も、残りのサーバーを止めてから cleanup でリソースを解放する
+	var listenErr error
+	select {
+	case listenErr = <-errc:
+	case <-sigCtx.Done():
+	}
+	// 2回目のシグナルでは待たずに終了する
+	stop()
+
+	logger.Info(ctx, "shutting down server...")
+	muxServer.Health.SetDraining()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, cfg.Server.ShutdownTimeout)
+	defer cancel()
+	shutdownErr := listenErr
+	for _, srv := range servers {
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			shutdownErr = errors.Join(shutdownErr, fmt.Errorf("failed to shutdown server on %s: %w", srv.Addr, err))
+		}
 	}
+	return shutdownErr
 }
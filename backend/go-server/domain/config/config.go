@@ -2,29 +2,236 @@ package config
 
 import (
 	"context"
-	"fmt"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sethvargo/go-envconfig"
 )
 
 type Database struct {
-	User                   string `env:"DB_USER" default:"postgres"`
-	Password               string `env:"DB_PASSWORD,required"`
+	User string `env:"DB_USER,default=postgres"`
+	// CredentialProvider は static / file / command のいずれか
+	// file と command は接続のたびに取得するので、IAM 認証のトークンのように期限のあるパスワードに使う
+	CredentialProvider string `env:"DB_CREDENTIAL_PROVIDER,default=static"`
+	Password           string `env:"DB_PASSWORD" redact:"true"`
+	PasswordFile       string `env:"DB_PASSWORD_FILE"`
+	// PasswordCommand シェルを通さずに実行する。空白で区切った最初の要素がコマンド
+	PasswordCommand string `env:"DB_PASSWORD_COMMAND"`
+	// PasswordCommandTTL コマンドの結果を使い回す時間。トークンの有効期限より短くする
+	PasswordCommandTTL time.Duration `env:"DB_PASSWORD_COMMAND_TTL,default=10m"`
+	// InstanceConnectionName Cloud SQL のインスタンス。指定した場合は SocketDir 以下のUnixソケットで接続する
 	InstanceConnectionName string `env:"INSTANCE_CONNECTION_NAME"`
-	Host                   string `env:"DB_HOST" default:"localhost"`
-	Port                   int64  `env:"DB_PORT" default:"5435"`
-	Name                   string `env:"DB_NAME" default:"sblog_dev"`
-	SocketDir              string `env:"DATABASE_SOCKET_DIR"`
+	SocketDir              string `env:"DATABASE_SOCKET_DIR,default=/cloudsql"`
+	// Host / で始まる場合はそのディレクトリにあるUnixソケットで接続する
+	Host string `env:"DB_HOST,default=localhost"`
+	Port int    `env:"DB_PORT,default=5435"`
+	Name string `env:"DB_NAME,default=sblog_dev"`
+	// SSLMode は disable / allow / prefer / require / verify-ca / verify-full のいずれか
+	// Unixソケットで接続する場合は使わない
+	SSLMode string `env:"DB_SSLMODE,default=disable"`
+	// SSLRootCert サーバー証明書を検証するCA。system の場合はOSの証明書を使う
+	SSLRootCert string `env:"DB_SSLROOTCERT"`
+	// SSLCert と SSLKey はクライアント証明書で認証する場合に両方指定する
+	SSLCert  string `env:"DB_SSLCERT"`
+	SSLKey   string `env:"DB_SSLKEY"`
+	TimeZone string `env:"DB_TIMEZONE,default=UTC"`
+	// ConnectTimeout は秒単位に切り上げて渡す
+	ConnectTimeout time.Duration `env:"DB_CONNECT_TIMEOUT,default=5s"`
+	// StatementTimeout 0の場合は制限しない
+	StatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT,default=0s"`
+	// MaxOpenConns 0の場合は制限しない
+	MaxOpenConns int `env:"DB_MAX_OPEN_CONNS,default=25"`
+	MaxIdleConns int `env:"DB_MAX_IDLE_CONNS,default=10"`
+	// ConnMaxLifetime フェイルオーバーやDNSの切り替えに追従するため、古い接続を作り直す。0の場合は制限しない
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME,default=30m"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME,default=5m"`
+	// ReplicaHosts 読み取り専用のレプリカ。host または host:port で指定し、認証情報などはプライマリと同じものを使う
+	ReplicaHosts []string `env:"DB_REPLICA_HOSTS"`
+	// TxMaxAttempts 直列化の失敗やデッドロックで失敗したトランザクションを、初回を含めて何回まで試すか
+	TxMaxAttempts    int           `env:"DB_TX_MAX_ATTEMPTS,default=3"`
+	TxRetryBaseDelay time.Duration `env:"DB_TX_RETRY_BASE_DELAY,default=50ms"`
+	TxRetryMaxDelay  time.Duration `env:"DB_TX_RETRY_MAX_DELAY,default=1s"`
+}
+
+type Auth struct {
+	// JWTSecret はSupabaseが発行するアクセストークンの署名検証に使う
+	JWTSecret string `env:"SUPABASE_JWT_SECRET" redact:"true"`
+}
+
+type Storage struct {
+	// Backend は local / s3 / memory のいずれか
+	Backend        string `env:"STORAGE_BACKEND,default=local"`
+	LocalDir       string `env:"STORAGE_LOCAL_DIR,default=./uploads"`
+	PublicBaseURL  string `env:"STORAGE_PUBLIC_BASE_URL,default=http://localhost:8080/media"`
+	S3Endpoint     string `env:"S3_ENDPOINT"`
+	S3Region       string `env:"S3_REGION"`
+	S3Bucket       string `env:"S3_BUCKET"`
+	S3AccessKey    string `env:"S3_ACCESS_KEY" redact:"true"`
+	S3SecretKey    string `env:"S3_SECRET_KEY" redact:"true"`
+	S3UseSSL       bool   `env:"S3_USE_SSL,default=true"`
+	MaxUploadBytes int64  `env:"MEDIA_MAX_UPLOAD_BYTES,default=10485760"`
+	// MaxImagePixels デコードを許可する画像の画素数(幅×高さ)の上限
+	// 圧縮率の高い画像はファイルサイズが小さくてもデコード時に大量のメモリを使うため、別に制限する
+	MaxImagePixels int64 `env:"MEDIA_MAX_IMAGE_PIXELS,default=50000000"`
+}
+
+type Mail struct {
+	// Backend は smtp / file / log のいずれか
+	Backend      string `env:"MAIL_BACKEND,default=log"`
+	From         string `env:"MAIL_FROM,default=s-blog <no-reply@localhost>"`
+	SMTPHost     string `env:"SMTP_HOST,default=localhost"`
+	SMTPPort     int    `env:"SMTP_PORT,default=587"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD" redact:"true"`
+	FileDir      string `env:"MAIL_FILE_DIR,default=./mails"`
+}
+
+type Newsletter struct {
+	SiteURL    string        `env:"SITE_URL,default=http://localhost:3000"`
+	APIURL     string        `env:"API_URL,default=http://localhost:8080"`
+	ConfirmTTL time.Duration `env:"NEWSLETTER_CONFIRM_TTL,default=48h"`
+	// ResendInterval 同じアドレスに確認メールを再送するまでの間隔
+	ResendInterval time.Duration `env:"NEWSLETTER_RESEND_INTERVAL,default=10m"`
+	MaxAttempts    int           `env:"NEWSLETTER_MAX_ATTEMPTS,default=5"`
+	BatchSize      int           `env:"NEWSLETTER_BATCH_SIZE,default=100"`
+	RetryDelay     time.Duration `env:"NEWSLETTER_RETRY_DELAY,default=5m"`
+}
+
+type PubSub struct {
+	// Backend は memory / postgres のいずれか。複数台で動かす場合は postgres を使う
+	Backend string `env:"PUBSUB_BACKEND,default=memory"`
+}
+
+type CORS struct {
+	AllowedOrigins []string `env:"CORS_ALLOWED_ORIGINS,default=http://localhost:3000,http://localhost:8080"`
+}
+
+type Spam struct {
+	MaxLinks        int           `env:"SPAM_MAX_LINKS,default=2"`
+	DuplicateWindow time.Duration `env:"SPAM_DUPLICATE_WINDOW,default=24h"`
+	VelocityWindow  time.Duration `env:"SPAM_VELOCITY_WINDOW,default=10m"`
+	MaxPerUser      int           `env:"SPAM_MAX_COMMENTS_PER_USER,default=5"`
+	MaxPerIP        int           `env:"SPAM_MAX_COMMENTS_PER_IP,default=10"`
+}
+
+type Server struct {
+	// TrustedProxies X-Forwarded-For を信頼するロードバランサーのアドレス(CIDR表記可)
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+	// HealthCheckTimeout /readyz の各チェックにかける時間の上限
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT,default=2s"`
+	// DrainDelay 停止の合図を受けてから /readyz を失敗させたまま待つ時間
+	// ロードバランサーが振り分けを止めるまでの猶予で、その間も新しいリクエストは処理する
+	DrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY,default=0s"`
+	// ShutdownTimeout 処理中のリクエストの完了を待つ時間の上限
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT,default=30s"`
+}
+
+type RateLimit struct {
+	// Backend は memory / postgres のいずれか。複数台で動かす場合は postgres を使う
+	Backend            string `env:"RATE_LIMIT_BACKEND,default=memory"`
+	QueriesPerMinute   int    `env:"RATE_LIMIT_QUERIES_PER_MINUTE,default=120"`
+	QueryBurst         int    `env:"RATE_LIMIT_QUERY_BURST,default=60"`
+	MutationsPerMinute int    `env:"RATE_LIMIT_MUTATIONS_PER_MINUTE,default=20"`
+	MutationBurst      int    `env:"RATE_LIMIT_MUTATION_BURST,default=10"`
+}
+
+// GraphQL 1回の操作で許可する深さと複雑度(複雑度はロールごと)と、実行を許可する操作
+type GraphQL struct {
+	MaxDepth               int `env:"GRAPHQL_MAX_DEPTH,default=10"`
+	MaxComplexityAnonymous int `env:"GRAPHQL_MAX_COMPLEXITY_ANONYMOUS,default=5000"`
+	MaxComplexityReader    int `env:"GRAPHQL_MAX_COMPLEXITY_READER,default=10000"`
+	MaxComplexityEditor    int `env:"GRAPHQL_MAX_COMPLEXITY_EDITOR,default=50000"`
+	// QueryCacheSize パース済みのクエリを保持する数
+	QueryCacheSize int `env:"GRAPHQL_QUERY_CACHE_SIZE,default=1000"`
+	// APQCacheSize Automatic Persisted Queries で保持するクエリの数
+	APQCacheSize int `env:"GRAPHQL_APQ_CACHE_SIZE,default=1000"`
+	// StrictAllowlist 許可リストにある操作だけを実行する。イントロスペクションも無効になる
+	StrictAllowlist bool `env:"GRAPHQL_STRICT_ALLOWLIST,default=false"`
+	// AllowlistFile 許可リストのマニフェスト。空の場合はビルド時に埋め込んだものを使う
+	AllowlistFile string `env:"GRAPHQL_ALLOWLIST_FILE"`
+	// PlaygroundEnabled 本番環境では無効にする
+	PlaygroundEnabled bool   `env:"GRAPHQL_PLAYGROUND_ENABLED,default=true"`
+	PlaygroundPath    string `env:"GRAPHQL_PLAYGROUND_PATH,default=/playground"`
+}
+
+// Tracing OpenTelemetryのトレース
+type Tracing struct {
+	// Exporter は none / stdout / otlp のいずれか
+	// otlp の送信先は OTEL_EXPORTER_OTLP_ENDPOINT などの標準の環境変数で指定する
+	Exporter    string  `env:"TRACING_EXPORTER,default=none"`
+	ServiceName string  `env:"TRACING_SERVICE_NAME,default=s-blog-api"`
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO,default=1"`
+}
+
+// Metrics Prometheus のメトリクスはAPIとは別のポートで公開する。Port が0の場合は公開しない
+type Metrics struct {
+	Port int    `env:"METRICS_PORT,default=9090"`
+	Path string `env:"METRICS_PATH,default=/metrics"`
+}
+
+type Worker struct {
+	Concurrency int `env:"WORKER_CONCURRENCY,default=2"`
+	QueueSize   int `env:"WORKER_QUEUE_SIZE,default=256"`
+	// StopTimeout 停止時に実行中のタスクの完了を待つ時間の上限
+	StopTimeout time.Duration `env:"WORKER_STOP_TIMEOUT,default=30s"`
+}
+
+// Related 関連記事の事前計算
+type Related struct {
+	// MaxResults 記事ごとに保存する関連記事の数
+	MaxResults int `env:"RELATED_MAX_RESULTS,default=20"`
+	// Candidates スコアを計算する記事の数の上限。公開日時の新しいものから選ぶ
+	Candidates int `env:"RELATED_CANDIDATES,default=2000"`
+	// TagWeight タグの重なりのスコアの比重。残りを本文の類似度に割り当てる
+	TagWeight float64 `env:"RELATED_TAG_WEIGHT,default=0.6"`
+	// MinScore これ未満のスコアの記事は保存せず、同じタグの新しい記事で補う
+	MinScore float64 `env:"RELATED_MIN_SCORE,default=0.05"`
+	// RefreshInterval 未計算の記事や、計算後に編集された記事を探す間隔
+	RefreshInterval time.Duration `env:"RELATED_REFRESH_INTERVAL,default=10m"`
 }
 
 type Vars struct {
-	Database *Database
-	Port     int `env:"API_PORT,default=8080"`
+	Database   *Database
+	Auth       *Auth
+	Storage    *Storage
+	Worker     *Worker
+	Related    *Related
+	Mail       *Mail
+	Newsletter *Newsletter
+	PubSub     *PubSub
+	CORS       *CORS
+	Spam       *Spam
+	Server     *Server
+	RateLimit  *RateLimit
+	GraphQL    *GraphQL
+	Tracing    *Tracing
+	Metrics    *Metrics
+	Port       int `env:"API_PORT,default=8080"`
+	// AppEnv development の場合、マイグレーション時に既存のテーブルを作り直す
+	AppEnv string `env:"APP_ENV"`
 }
 
+// New 環境変数と CONFIG_FILES で指定したYAMLファイルから設定を読み込み、検証する
+// 同じ項目は環境変数、後に指定したファイル、先に指定したファイルの順に優先する
 func New(ctx context.Context) (*Vars, error) {
+	files, err := loadFiles(os.Getenv(configFilesEnv))
+	if err != nil {
+		return nil, err
+	}
+
 	var vars Vars
-	if err := envconfig.Process(ctx, &vars); err != nil {
+	err = envconfig.ProcessWith(ctx, &envconfig.Config{
+		Target:   &vars,
+		Lookuper: envconfig.MultiLookuper(envconfig.OsLookuper(), envconfig.MapLookuper(files)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := vars.Validate(); err != nil {
 		return nil, err
 	}
 	return &vars, nil
@@ -38,15 +245,79 @@ func MustNew(ctx context.Context) *Vars {
 	return vars
 }
 
+// DataSourceName DBに接続するためのDSN。接続先はすべてここで組み立てる
 func (d *Database) DataSourceName() string {
-	if d.InstanceConnectionName == "" {
-		return fmt.Sprintf(
-			"user=%s password=%s database=%s host=%s port=5435 sslmode=disable",
-			d.User, d.Password, d.Name, d.Host,
-		)
-	}
-	return fmt.Sprintf(
-		"user=%s password=%s database=%s host=%s/%s",
-		d.User, d.Password, d.Name, d.SocketDir, d.InstanceConnectionName,
+	if d.InstanceConnectionName != "" {
+		return d.dataSourceName([2]string{"host", path.Join(d.SocketDir, d.InstanceConnectionName)})
+	}
+	return d.dataSourceName(
+		[2]string{"host", d.Host},
+		[2]string{"port", strconv.Itoa(d.Port)},
+	)
+}
+
+// UnixSocket TCPではなくUnixソケットで接続するか
+func (d *Database) UnixSocket() bool {
+	return d.InstanceConnectionName != "" || strings.HasPrefix(d.Host, "/")
+}
+
+// ReplicaDataSourceNames レプリカごとのDSN。ポートを省略した場合は DB_PORT を使う
+func (d *Database) ReplicaDataSourceNames() []string {
+	dsns := make([]string, 0, len(d.ReplicaHosts))
+	for _, h := range d.ReplicaHosts {
+		host, port := h, strconv.Itoa(d.Port)
+		if hh, pp, err := net.SplitHostPort(h); err == nil {
+			host, port = hh, pp
+		}
+		dsns = append(dsns, d.dataSourceName(
+			[2]string{"host", host},
+			[2]string{"port", port},
+		))
+	}
+	return dsns
+}
+
+func (d *Database) dataSourceName(addr ...[2]string) string {
+	params := [][2]string{
+		{"user", d.User},
+		{"password", d.Password},
+		{"dbname", d.Name},
+	}
+	params = append(params, addr...)
+	params = append(params,
+		[2]string{"sslmode", d.SSLMode},
+		[2]string{"TimeZone", d.TimeZone},
 	)
+	for _, p := range [][2]string{
+		{"sslrootcert", d.SSLRootCert},
+		{"sslcert", d.SSLCert},
+		{"sslkey", d.SSLKey},
+	} {
+		if p[1] != "" {
+			params = append(params, p)
+		}
+	}
+	if d.ConnectTimeout > 0 {
+		seconds := int((d.ConnectTimeout + time.Second - 1) / time.Second)
+		params = append(params, [2]string{"connect_timeout", strconv.Itoa(seconds)})
+	}
+	if d.StatementTimeout > 0 {
+		params = append(params, [2]string{"statement_timeout", strconv.FormatInt(d.StatementTimeout.Milliseconds(), 10)})
+	}
+
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		parts = append(parts, p[0]+"="+quoteDSNValue(p[1]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteDSNValue 空白や引用符を含む値をlibpqの形式で囲む
+func quoteDSNValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
 }
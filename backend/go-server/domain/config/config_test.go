@@ -0,0 +1,323 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sethvargo/go-envconfig"
+)
+
+// load env だけから設定を読み込む。検証はしない
+func load(t *testing.T, env map[string]string) *Vars {
+	t.Helper()
+	var vars Vars
+	err := envconfig.ProcessWith(context.Background(), &envconfig.Config{
+		Target:   &vars,
+		Lookuper: envconfig.MapLookuper(env),
+	})
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	return &vars
+}
+
+func TestValidateDefaults(t *testing.T) {
+	vars := load(t, map[string]string{"DB_PASSWORD": "secret"})
+	if err := vars.Validate(); err != nil {
+		t.Fatalf("Validate() with defaults = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		// wantFields エラーに含まれる項目。空の場合はエラーにならない
+		wantFields []string
+	}{
+		{name: "valid", env: map[string]string{}},
+		{name: "port out of range", env: map[string]string{"API_PORT": "70000"}, wantFields: []string{"API_PORT"}},
+		{name: "static credential without password", env: map[string]string{"DB_PASSWORD": ""}, wantFields: []string{"DB_PASSWORD"}},
+		{name: "missing password file", env: map[string]string{"DB_CREDENTIAL_PROVIDER": "file", "DB_PASSWORD_FILE": "/nonexistent"}, wantFields: []string{"DB_PASSWORD_FILE"}},
+		{name: "command credential", env: map[string]string{"DB_CREDENTIAL_PROVIDER": "command", "DB_PASSWORD_COMMAND": " ", "DB_PASSWORD_COMMAND_TTL": "0s"}, wantFields: []string{"DB_PASSWORD_COMMAND", "DB_PASSWORD_COMMAND_TTL"}},
+		{name: "unknown credential provider", env: map[string]string{"DB_CREDENTIAL_PROVIDER": "vault"}, wantFields: []string{"DB_CREDENTIAL_PROVIDER"}},
+		{name: "verify-full over a unix socket", env: map[string]string{"DB_HOST": "/var/run/postgresql", "DB_SSLMODE": "verify-full"}, wantFields: []string{"DB_SSLMODE"}},
+		{name: "client cert without key", env: map[string]string{"DB_SSLCERT": "/nonexistent"}, wantFields: []string{"DB_SSLCERT"}},
+		{name: "unknown time zone", env: map[string]string{"DB_TIMEZONE": "Mars/Olympus"}, wantFields: []string{"DB_TIMEZONE"}},
+		{name: "idle above open connections", env: map[string]string{"DB_MAX_OPEN_CONNS": "5", "DB_MAX_IDLE_CONNS": "10"}, wantFields: []string{"DB_MAX_IDLE_CONNS"}},
+		{name: "replica with a bad port", env: map[string]string{"DB_REPLICA_HOSTS": "replica:99999"}, wantFields: []string{"DB_REPLICA_HOSTS"}},
+		{name: "s3 without endpoint and bucket", env: map[string]string{"STORAGE_BACKEND": "s3"}, wantFields: []string{"S3_ENDPOINT", "S3_BUCKET"}},
+		{name: "invalid cors origin", env: map[string]string{"CORS_ALLOWED_ORIGINS": "localhost:3000"}, wantFields: []string{"CORS_ALLOWED_ORIGINS"}},
+		{name: "rate limits must be positive", env: map[string]string{"RATE_LIMIT_QUERY_BURST": "0", "RATE_LIMIT_MUTATIONS_PER_MINUTE": "-1"}, wantFields: []string{"RATE_LIMIT_QUERY_BURST", "RATE_LIMIT_MUTATIONS_PER_MINUTE"}},
+		{name: "newsletter", env: map[string]string{"NEWSLETTER_BATCH_SIZE": "0", "NEWSLETTER_MAX_ATTEMPTS": "0", "NEWSLETTER_CONFIRM_TTL": "0s"}, wantFields: []string{"NEWSLETTER_BATCH_SIZE", "NEWSLETTER_MAX_ATTEMPTS", "NEWSLETTER_CONFIRM_TTL"}},
+		{name: "related weights", env: map[string]string{"RELATED_TAG_WEIGHT": "1.5", "RELATED_MIN_SCORE": "-0.1"}, wantFields: []string{"RELATED_TAG_WEIGHT", "RELATED_MIN_SCORE"}},
+		{name: "metrics on the api port", env: map[string]string{"METRICS_PORT": "8080"}, wantFields: []string{"METRICS_PORT"}},
+		{name: "metrics disabled", env: map[string]string{"METRICS_PORT": "0", "METRICS_PATH": "metrics"}},
+		{name: "negative durations", env: map[string]string{"DB_CONNECT_TIMEOUT": "-1s", "SHUTDOWN_DRAIN_DELAY": "-1s"}, wantFields: []string{"DB_CONNECT_TIMEOUT", "SHUTDOWN_DRAIN_DELAY"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{"DB_PASSWORD": "secret"}
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			err := load(t, env).Validate()
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want errors for %v", tt.wantFields)
+			}
+			for _, field := range tt.wantFields {
+				if !strings.Contains(err.Error(), field+":") {
+					t.Errorf("Validate() = %v, want an error for %s", err, field)
+				}
+			}
+		})
+	}
+}
+
+// TestValidateReportsAllErrors 最初の誤りで止めず、すべての項目をまとめて報告する
+func TestValidateReportsAllErrors(t *testing.T) {
+	vars := load(t, map[string]string{
+		"API_PORT":              "0",
+		"DB_NAME":               "",
+		"STORAGE_BACKEND":       "ftp",
+		"MAIL_BACKEND":          "pigeon",
+		"WORKER_CONCURRENCY":    "0",
+		"NEWSLETTER_BATCH_SIZE": "0",
+		"TRACING_SAMPLE_RATIO":  "2",
+		"SHUTDOWN_TIMEOUT":      "0s",
+	})
+	err := vars.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+
+	want := []string{
+		"API_PORT", "DB_PASSWORD", "DB_NAME", "STORAGE_BACKEND", "MAIL_BACKEND",
+		"WORKER_CONCURRENCY", "NEWSLETTER_BATCH_SIZE", "TRACING_SAMPLE_RATIO", "SHUTDOWN_TIMEOUT",
+	}
+	lines := strings.Split(strings.TrimPrefix(err.Error(), "invalid config:\n"), "\n")
+	if len(lines) != len(want) {
+		t.Errorf("got %d errors, want %d:\n%v", len(lines), len(want), err)
+	}
+	for _, field := range want {
+		if !strings.Contains(err.Error(), field+":") {
+			t.Errorf("missing error for %s", field)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	vars := load(t, map[string]string{
+		"DB_PASSWORD":         "db-secret",
+		"SUPABASE_JWT_SECRET": "jwt-secret",
+		"S3_SECRET_KEY":       "s3-secret",
+		"DB_USER":             "app",
+	})
+	out := vars.Redacted()
+
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{key: "DB_PASSWORD", want: redacted},
+		{key: "SUPABASE_JWT_SECRET", want: redacted},
+		{key: "S3_SECRET_KEY", want: redacted},
+		// 設定されていない秘密は空のまま出力し、未設定であることが分かるようにする
+		{key: "S3_ACCESS_KEY", want: ""},
+		{key: "SMTP_PASSWORD", want: ""},
+		{key: "DB_USER", want: "app"},
+		{key: "API_PORT", want: 8080},
+		{key: "DB_CONNECT_TIMEOUT", want: "5s"},
+		{key: "CORS_ALLOWED_ORIGINS", want: []string{"http://localhost:3000", "http://localhost:8080"}},
+	}
+	for _, tt := range tests {
+		got, ok := out[tt.key]
+		if !ok {
+			t.Errorf("%s is missing", tt.key)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.key, got, tt.want)
+		}
+	}
+	for key, value := range out {
+		if s, ok := value.(string); ok && strings.Contains(s, "secret") {
+			t.Errorf("%s leaks a secret: %q", key, s)
+		}
+	}
+}
+
+func TestNewFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	base := writeFile("base.yaml", `
+DB_PASSWORD: from-base
+DB_HOST: base.internal
+DB_NAME: base
+API_PORT: 8081
+CORS_ALLOWED_ORIGINS: [https://example.com, https://www.example.com]
+`)
+	override := writeFile("override.yaml", `
+DB_HOST: override.internal
+DB_NAME: override
+`)
+
+	t.Setenv(configFilesEnv, base+", "+override)
+	t.Setenv("DB_NAME", "from-env")
+
+	vars, err := New(context.Background())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{name: "value only in the first file", got: vars.Database.Password, want: "from-base"},
+		{name: "later file overrides earlier", got: vars.Database.Host, want: "override.internal"},
+		{name: "env overrides files", got: vars.Database.Name, want: "from-env"},
+		{name: "numbers", got: vars.Port, want: 8081},
+		{name: "lists", got: vars.CORS.AllowedOrigins, want: []string{"https://example.com", "https://www.example.com"}},
+		{name: "defaults still apply", got: vars.Database.ConnectTimeout, want: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s: got %#v, want %#v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestNewFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "nested.yaml")
+	if err := os.WriteFile(nested, []byte("DB:\n  HOST: x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("DB_HOST: [unclosed\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		files   string
+		wantErr string
+	}{
+		{name: "missing file", files: filepath.Join(dir, "missing.yaml"), wantErr: "failed to read config file"},
+		{name: "invalid yaml", files: invalid, wantErr: "failed to parse config file"},
+		{name: "nested values", files: nested, wantErr: "nested values are not supported"},
+		{name: "validation errors", files: "", wantErr: "DB_PASSWORD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(configFilesEnv, tt.files)
+			t.Setenv("DB_PASSWORD", "")
+			_, err := New(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDataSourceName(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{"DB_PASSWORD": "secret"},
+			want: "user=postgres password=secret dbname=sblog_dev host=localhost port=5435 sslmode=disable TimeZone=UTC connect_timeout=5",
+		},
+		{
+			name: "cloud sql socket",
+			env:  map[string]string{"DB_PASSWORD": "secret", "INSTANCE_CONNECTION_NAME": "project:region:instance"},
+			want: "user=postgres password=secret dbname=sblog_dev host=/cloudsql/project:region:instance sslmode=disable TimeZone=UTC connect_timeout=5",
+		},
+		{
+			name: "values are quoted",
+			env:  map[string]string{"DB_PASSWORD": `it's a \secret`, "DB_CONNECT_TIMEOUT": "0s"},
+			want: `user=postgres password='it\'s a \\secret' dbname=sblog_dev host=localhost port=5435 sslmode=disable TimeZone=UTC`,
+		},
+		{
+			name: "empty password is quoted",
+			env:  map[string]string{"DB_CONNECT_TIMEOUT": "0s"},
+			want: "user=postgres password='' dbname=sblog_dev host=localhost port=5435 sslmode=disable TimeZone=UTC",
+		},
+		{
+			name: "tls files and timeouts",
+			env: map[string]string{
+				"DB_PASSWORD":          "secret",
+				"DB_SSLMODE":           "verify-full",
+				"DB_SSLROOTCERT":       "/certs/ca.pem",
+				"DB_SSLCERT":           "/certs/client.pem",
+				"DB_SSLKEY":            "/certs/client.key",
+				"DB_CONNECT_TIMEOUT":   "1500ms",
+				"DB_STATEMENT_TIMEOUT": "2s",
+			},
+			want: "user=postgres password=secret dbname=sblog_dev host=localhost port=5435 sslmode=verify-full TimeZone=UTC " +
+				"sslrootcert=/certs/ca.pem sslcert=/certs/client.pem sslkey=/certs/client.key connect_timeout=2 statement_timeout=2000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := load(t, tt.env).Database.DataSourceName(); got != tt.want {
+				t.Errorf("DataSourceName() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplicaDataSourceNames(t *testing.T) {
+	db := load(t, map[string]string{
+		"DB_PASSWORD":        "secret",
+		"DB_CONNECT_TIMEOUT": "0s",
+		"DB_REPLICA_HOSTS":   "replica1,replica2:6543,[::1]:5000",
+	}).Database
+
+	want := []string{
+		"user=postgres password=secret dbname=sblog_dev host=replica1 port=5435 sslmode=disable TimeZone=UTC",
+		"user=postgres password=secret dbname=sblog_dev host=replica2 port=6543 sslmode=disable TimeZone=UTC",
+		"user=postgres password=secret dbname=sblog_dev host=::1 port=5000 sslmode=disable TimeZone=UTC",
+	}
+	if got := db.ReplicaDataSourceNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReplicaDataSourceNames() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want bool
+	}{
+		{env: map[string]string{}, want: false},
+		{env: map[string]string{"DB_HOST": "/var/run/postgresql"}, want: true},
+		{env: map[string]string{"INSTANCE_CONNECTION_NAME": "p:r:i"}, want: true},
+	}
+	for _, tt := range tests {
+		if got := load(t, tt.env).Database.UnixSocket(); got != tt.want {
+			t.Errorf("UnixSocket() with %v = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
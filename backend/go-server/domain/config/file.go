@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// configFilesEnv 読み込むYAMLファイルをカンマ区切りで指定する環境変数
+const configFilesEnv = "CONFIG_FILES"
+
+// loadFiles 環境変数と同じ名前をキーにしたYAMLファイルを読み込む
+//
+//	DB_HOST: db.internal
+//	CORS_ALLOWED_ORIGINS: [https://example.com, https://www.example.com]
+//
+// 後のファイルの値で先のファイルの値を上書きする
+func loadFiles(paths string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, p := range strings.Split(paths, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		var raw map[string]any
+		if err := yaml.Unmarshal(b, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", p, err)
+		}
+		for k, v := range raw {
+			s, err := fileValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", p, k, err)
+			}
+			values[k] = s
+		}
+	}
+	return values, nil
+}
+
+// fileValue 環境変数と同じ文字列の形式にする。リストはカンマで区切る
+func fileValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := fileValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]any:
+		return "", fmt.Errorf("nested values are not supported")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// Redacted ログに出力するための設定。キーは環境変数の名前で、
+// redact タグの付いた秘密の値は伏せる
+func (vars *Vars) Redacted() map[string]any {
+	out := map[string]any{}
+	redact(reflect.ValueOf(vars).Elem(), out)
+	return out
+}
+
+func redact(v reflect.Value, out map[string]any) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field, value := t.Field(i), v.Field(i)
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Struct && field.Tag.Get("env") == "" {
+			redact(value, out)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("env"), ",")
+		if name == "" {
+			continue
+		}
+		if field.Tag.Get("redact") == "true" && !value.IsZero() {
+			out[name] = redacted
+			continue
+		}
+		if s, ok := value.Interface().(fmt.Stringer); ok {
+			// time.Duration などを読める形で出力する
+			out[name] = s.String()
+			continue
+		}
+		out[name] = value.Interface()
+	}
+}
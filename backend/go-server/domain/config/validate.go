@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validator 見つかった問題をすべてまとめて返す
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, field, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%s: "+format, append([]any{field}, args...)...))
+	}
+}
+
+func (v *validator) port(field string, port int) {
+	v.check(port > 0 && port <= 65535, field, "must be between 1 and 65535 (got %d)", port)
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	v.check(slices.Contains(allowed, value), field, "must be one of %s (got %q)", strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) nonNegative(field string, n int) {
+	v.check(n >= 0, field, "must not be negative (got %d)", n)
+}
+
+func (v *validator) positive(field string, n int) {
+	v.check(n > 0, field, "must be positive (got %d)", n)
+}
+
+func (v *validator) duration(field string, d time.Duration) {
+	v.check(d >= 0, field, "must not be negative (got %s)", d)
+}
+
+// file 起動時に読めることを確認する
+func (v *validator) file(field, path string) {
+	if path == "" {
+		v.check(false, field, "must be set")
+		return
+	}
+	_, err := os.Stat(path)
+	v.check(err == nil, field, "%v", err)
+}
+
+// Validate 起動前に設定の誤りをすべて報告する
+func (vars *Vars) Validate() error {
+	v := &validator{}
+	v.port("API_PORT", vars.Port)
+
+	db := vars.Database
+	if db.InstanceConnectionName == "" {
+		v.check(db.Host != "", "DB_HOST", "must be set unless INSTANCE_CONNECTION_NAME is set")
+		v.port("DB_PORT", db.Port)
+	}
+	v.check(db.Name != "", "DB_NAME", "must be set")
+	v.oneOf("DB_CREDENTIAL_PROVIDER", db.CredentialProvider, "static", "file", "command")
+	switch db.CredentialProvider {
+	case "static":
+		v.check(db.Password != "", "DB_PASSWORD", "must be set when DB_CREDENTIAL_PROVIDER is static")
+	case "file":
+		v.file("DB_PASSWORD_FILE", db.PasswordFile)
+	case "command":
+		v.check(strings.TrimSpace(db.PasswordCommand) != "", "DB_PASSWORD_COMMAND", "must be set when DB_CREDENTIAL_PROVIDER is command")
+		v.check(db.PasswordCommandTTL > 0, "DB_PASSWORD_COMMAND_TTL", "must be positive (got %s)", db.PasswordCommandTTL)
+	}
+	v.oneOf("DB_SSLMODE", db.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	if db.UnixSocket() {
+		v.check(db.SSLMode != "verify-ca" && db.SSLMode != "verify-full", "DB_SSLMODE", "%s can not be used with unix socket connections", db.SSLMode)
+	}
+	if db.SSLRootCert != "" && db.SSLRootCert != "system" {
+		v.file("DB_SSLROOTCERT", db.SSLRootCert)
+	}
+	v.check((db.SSLCert == "") == (db.SSLKey == ""), "DB_SSLCERT", "must be set together with DB_SSLKEY")
+	if db.SSLCert != "" {
+		v.file("DB_SSLCERT", db.SSLCert)
+	}
+	if db.SSLKey != "" {
+		v.file("DB_SSLKEY", db.SSLKey)
+	}
+	_, err := time.LoadLocation(db.TimeZone)
+	v.check(err == nil, "DB_TIMEZONE", "unknown time zone %q", db.TimeZone)
+	v.duration("DB_CONNECT_TIMEOUT", db.ConnectTimeout)
+	v.duration("DB_STATEMENT_TIMEOUT", db.StatementTimeout)
+	v.nonNegative("DB_MAX_OPEN_CONNS", db.MaxOpenConns)
+	v.nonNegative("DB_MAX_IDLE_CONNS", db.MaxIdleConns)
+	if db.MaxOpenConns > 0 {
+		v.check(db.MaxIdleConns <= db.MaxOpenConns, "DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS (%d > %d)", db.MaxIdleConns, db.MaxOpenConns)
+	}
+	v.duration("DB_CONN_MAX_LIFETIME", db.ConnMaxLifetime)
+	v.duration("DB_CONN_MAX_IDLE_TIME", db.ConnMaxIdleTime)
+	v.positive("DB_TX_MAX_ATTEMPTS", db.TxMaxAttempts)
+	v.duration("DB_TX_RETRY_BASE_DELAY", db.TxRetryBaseDelay)
+	v.duration("DB_TX_RETRY_MAX_DELAY", db.TxRetryMaxDelay)
+	for _, h := range db.ReplicaHosts {
+		host, port := h, ""
+		if hh, pp, err := net.SplitHostPort(h); err == nil {
+			host, port = hh, pp
+		}
+		v.check(host != "", "DB_REPLICA_HOSTS", "host must be set (got %q)", h)
+		if port != "" {
+			n, err := strconv.Atoi(port)
+			v.check(err == nil && n > 0 && n <= 65535, "DB_REPLICA_HOSTS", "invalid port in %q", h)
+		}
+	}
+
+	st := vars.Storage
+	v.oneOf("STORAGE_BACKEND", st.Backend, "local", "s3", "memory")
+	if st.Backend == "s3" {
+		v.check(st.S3Endpoint != "", "S3_ENDPOINT", "must be set when STORAGE_BACKEND is s3")
+		v.check(st.S3Bucket != "", "S3_BUCKET", "must be set when STORAGE_BACKEND is s3")
+	}
+	v.check(st.MaxUploadBytes > 0, "MEDIA_MAX_UPLOAD_BYTES", "must be positive (got %d)", st.MaxUploadBytes)
+	v.check(st.MaxImagePixels > 0, "MEDIA_MAX_IMAGE_PIXELS", "must be positive (got %d)", st.MaxImagePixels)
+
+	v.oneOf("MAIL_BACKEND", vars.Mail.Backend, "smtp", "file", "log")
+	if vars.Mail.Backend == "smtp" {
+		v.port("SMTP_PORT", vars.Mail.SMTPPort)
+	}
+
+	v.oneOf("PUBSUB_BACKEND", vars.PubSub.Backend, "memory", "postgres")
+	rl := vars.RateLimit
+	v.oneOf("RATE_LIMIT_BACKEND", rl.Backend, "memory", "postgres")
+	v.positive("RATE_LIMIT_QUERIES_PER_MINUTE", rl.QueriesPerMinute)
+	v.positive("RATE_LIMIT_QUERY_BURST", rl.QueryBurst)
+	v.positive("RATE_LIMIT_MUTATIONS_PER_MINUTE", rl.MutationsPerMinute)
+	v.positive("RATE_LIMIT_MUTATION_BURST", rl.MutationBurst)
+
+	v.check(len(vars.CORS.AllowedOrigins) > 0, "CORS_ALLOWED_ORIGINS", "must not be empty")
+	for _, origin := range vars.CORS.AllowedOrigins {
+		u, err := url.Parse(origin)
+		v.check(origin == "*" || (err == nil && u.Scheme != "" && u.Host != ""), "CORS_ALLOWED_ORIGINS", "invalid origin %q", origin)
+	}
+
+	gql := vars.GraphQL
+	v.nonNegative("GRAPHQL_MAX_DEPTH", gql.MaxDepth)
+	v.positive("GRAPHQL_QUERY_CACHE_SIZE", gql.QueryCacheSize)
+	v.positive("GRAPHQL_APQ_CACHE_SIZE", gql.APQCacheSize)
+	if gql.PlaygroundEnabled {
+		v.check(strings.HasPrefix(gql.PlaygroundPath, "/"), "GRAPHQL_PLAYGROUND_PATH", "must start with / (got %q)", gql.PlaygroundPath)
+	}
+
+	v.positive("WORKER_CONCURRENCY", vars.Worker.Concurrency)
+	v.nonNegative("WORKER_QUEUE_SIZE", vars.Worker.QueueSize)
+
+	nl := vars.Newsletter
+	v.positive("NEWSLETTER_BATCH_SIZE", nl.BatchSize)
+	v.positive("NEWSLETTER_MAX_ATTEMPTS", nl.MaxAttempts)
+	v.check(nl.ConfirmTTL > 0, "NEWSLETTER_CONFIRM_TTL", "must be positive (got %s)", nl.ConfirmTTL)
+	v.duration("NEWSLETTER_RESEND_INTERVAL", nl.ResendInterval)
+	v.duration("NEWSLETTER_RETRY_DELAY", nl.RetryDelay)
+
+	rel := vars.Related
+	v.positive("RELATED_MAX_RESULTS", rel.MaxResults)
+	v.positive("RELATED_CANDIDATES", rel.Candidates)
+	v.check(rel.TagWeight >= 0 && rel.TagWeight <= 1, "RELATED_TAG_WEIGHT", "must be between 0 and 1 (got %g)", rel.TagWeight)
+	v.check(rel.MinScore >= 0 && rel.MinScore <= 1, "RELATED_MIN_SCORE", "must be between 0 and 1 (got %g)", rel.MinScore)
+	v.check(rel.RefreshInterval > 0, "RELATED_REFRESH_INTERVAL", "must be positive (got %s)", rel.RefreshInterval)
+
+	v.oneOf("TRACING_EXPORTER", vars.Tracing.Exporter, "none", "stdout", "otlp")
+	v.check(vars.Tracing.SampleRatio >= 0 && vars.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO", "must be between 0 and 1 (got %g)", vars.Tracing.SampleRatio)
+
+	if vars.Metrics.Port != 0 {
+		v.port("METRICS_PORT", vars.Metrics.Port)
+		v.check(vars.Metrics.Port != vars.Port, "METRICS_PORT", "must differ from API_PORT (%d)", vars.Port)
+		v.check(strings.HasPrefix(vars.Metrics.Path, "/"), "METRICS_PATH", "must start with / (got %q)", vars.Metrics.Path)
+	}
+
+	v.duration("SHUTDOWN_DRAIN_DELAY", vars.Server.DrainDelay)
+	v.check(vars.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT", "must be positive (got %s)", vars.Server.ShutdownTimeout)
+	v.check(vars.Server.HealthCheckTimeout > 0, "HEALTH_CHECK_TIMEOUT", "must be positive (got %s)", vars.Server.HealthCheckTimeout)
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config:\n%w", errors.Join(v.errs...))
+}
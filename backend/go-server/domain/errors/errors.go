@@ -3,15 +3,73 @@ package errors
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/morikuni/failure"
 	"gorm.io/gorm"
 )
 
 const (
-	CodeNotFound failure.StringCode = "not-found"
+	CodeNotFound        failure.StringCode = "not-found"
+	CodeInvalidArgument failure.StringCode = "invalid-argument"
+	CodeUnauthenticated failure.StringCode = "unauthenticated"
+	CodeForbidden       failure.StringCode = "forbidden"
+	CodeConflict        failure.StringCode = "conflict"
+	CodeRateLimited     failure.StringCode = "rate-limited"
+	// CodeInternal 原因はログに出力済みで、クライアントには詳細を返さない
+	CodeInternal failure.StringCode = "internal"
 )
 
+// codedError メッセージはそのままクライアントに返す
+// failure.CodeOf でコードを取り出せる
+type codedError struct {
+	code failure.StringCode
+	msg  string
+}
+
+func (e *codedError) Error() string {
+	return e.msg
+}
+
+func (e *codedError) As(x any) bool {
+	if c, ok := x.(*failure.Code); ok {
+		*c = e.code
+		return true
+	}
+	return false
+}
+
+func newError(code failure.StringCode, format string, args ...any) error {
+	return &codedError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+func NotFound(format string, args ...any) error {
+	return newError(CodeNotFound, format, args...)
+}
+
+func InvalidArgument(format string, args ...any) error {
+	return newError(CodeInvalidArgument, format, args...)
+}
+
+func Unauthenticated(format string, args ...any) error {
+	return newError(CodeUnauthenticated, format, args...)
+}
+
+func Forbidden(format string, args ...any) error {
+	return newError(CodeForbidden, format, args...)
+}
+
+func Conflict(format string, args ...any) error {
+	return newError(CodeConflict, format, args...)
+}
+
+func RateLimited(format string, args ...any) error {
+	return newError(CodeRateLimited, format, args...)
+}
+
+// ErrInternal 原因をログに出力した後にクライアントに返す
+var ErrInternal = newError(CodeInternal, "internal system error")
+
 func IsNotFound(err error) bool {
 	if failure.Is(err, CodeNotFound) {
 		return true
@@ -0,0 +1,35 @@
+package event
+
+import "github.com/google/uuid"
+
+// Event 書き込みに伴って発生するドメインイベント
+// 書き込みと同じトランザクションで通知などの副作用に変換される
+type Event interface {
+	isEvent()
+}
+
+// CommentAdded 記事にコメントが付いた
+type CommentAdded struct {
+	CommentID       uuid.UUID
+	ArticleID       uuid.UUID
+	ArticleAuthorID uuid.UUID
+	CommenterID     uuid.UUID
+	// ParentAuthorID 返信の場合は返信先コメントの投稿者
+	ParentAuthorID *uuid.UUID
+}
+
+// UserFollowed ユーザーが著者をフォローした
+type UserFollowed struct {
+	FollowerID uuid.UUID
+	AuthorID   uuid.UUID
+}
+
+// ArticlePublished 記事が公開された
+type ArticlePublished struct {
+	ArticleID uuid.UUID
+	AuthorID  uuid.UUID
+}
+
+func (CommentAdded) isEvent()     {}
+func (UserFollowed) isEvent()     {}
+func (ArticlePublished) isEvent() {}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthorFollow ユーザーによる著者のフォロー
+type AuthorFollow struct {
+	FollowerID uuid.UUID `gorm:"type:uuid;primary_key" json:"follower_id"`
+	AuthorID   uuid.UUID `gorm:"type:uuid;primary_key;index" json:"author_id"`
+	Follower   User      `gorm:"foreignKey:FollowerID" json:"-"`
+	Author     User      `gorm:"foreignKey:AuthorID" json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TagFollow ユーザーによるタグのフォロー
+type TagFollow struct {
+	FollowerID uuid.UUID `gorm:"type:uuid;primary_key" json:"follower_id"`
+	TagID      uuid.UUID `gorm:"type:uuid;primary_key;index" json:"tag_id"`
+	Follower   User      `gorm:"foreignKey:FollowerID" json:"-"`
+	Tag        Tag       `gorm:"foreignKey:TagID" json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewAuthorFollow(followerID, authorID uuid.UUID) *AuthorFollow {
+	return &AuthorFollow{
+		FollowerID: followerID,
+		AuthorID:   authorID,
+	}
+}
+
+func NewTagFollow(followerID, tagID uuid.UUID) *TagFollow {
+	return &TagFollow{
+		FollowerID: followerID,
+		TagID:      tagID,
+	}
+}
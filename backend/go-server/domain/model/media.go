@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MediaStatusPending    = "pending"
+	MediaStatusProcessing = "processing"
+	MediaStatusReady      = "ready"
+	MediaStatusFailed     = "failed"
+)
+
+// Media アップロードされたメディアファイル
+// 同じ所有者が同じ内容のファイルをアップロードした場合はChecksumで重複を排除する
+type Media struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	OwnerID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_media_owner_checksum" json:"owner_id"`
+	Owner      User      `gorm:"foreignKey:OwnerID" json:"owner,omitempty"`
+	FileName   string    `gorm:"size:255" json:"file_name"`
+	MimeType   string    `gorm:"size:100;not null" json:"mime_type"`
+	Size       int64     `gorm:"not null" json:"size"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	Checksum   string    `gorm:"size:64;not null;uniqueIndex:idx_media_owner_checksum;index" json:"checksum"`
+	StorageKey string    `gorm:"size:255;not null" json:"storage_key"`
+	// Status バックグラウンドでのバリアント生成の状態
+	Status    string         `gorm:"size:20;not null;default:pending;index" json:"status"`
+	Variants  []MediaVariant `gorm:"foreignKey:MediaID" json:"variants,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// MediaVariant 画像をリサイズ・再エンコードしたバリアント
+type MediaVariant struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	MediaID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_media_variant" json:"media_id"`
+	Format     string    `gorm:"size:10;not null;uniqueIndex:idx_media_variant" json:"format"`
+	Width      int       `gorm:"not null;uniqueIndex:idx_media_variant" json:"width"`
+	Height     int       `gorm:"not null" json:"height"`
+	Size       int64     `gorm:"not null" json:"size"`
+	StorageKey string    `gorm:"size:255;not null" json:"storage_key"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func NewMedia(id, ownerID uuid.UUID, fileName, mimeType string, size int64, checksum, storageKey string) *Media {
+	return &Media{
+		ID:         id,
+		OwnerID:    ownerID,
+		FileName:   fileName,
+		MimeType:   mimeType,
+		Size:       size,
+		Checksum:   checksum,
+		StorageKey: storageKey,
+		Status:     MediaStatusPending,
+	}
+}
+
+func NewMediaVariant(id, mediaID uuid.UUID, format string, width, height int, size int64, storageKey string) *MediaVariant {
+	return &MediaVariant{
+		ID:         id,
+		MediaID:    mediaID,
+		Format:     format,
+		Width:      width,
+		Height:     height,
+		Size:       size,
+		StorageKey: storageKey,
+	}
+}
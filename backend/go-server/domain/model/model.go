@@ -9,11 +9,17 @@ import (
 
 // User ユーザーモデル
 type User struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	ID uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	// Username プロフィールURLに使う。未設定のユーザーがいるため空文字は一意制約から除外する
+	Username  string    `gorm:"size:30;uniqueIndex:idx_users_username,where:username <> ''" json:"username"`
 	Name      string    `gorm:"size:100;not null" json:"name"`
 	Email     string    `gorm:"size:100;not null;unique" json:"email"`
 	Password  string    `gorm:"size:100;not null" json:"-"`
 	Avatar    string    `gorm:"size:255" json:"avatar"`
+	Bio       string    `gorm:"size:500" json:"bio"`
+	Website   string    `gorm:"size:255" json:"website"`
+	Twitter   string    `gorm:"size:50" json:"twitter"`
+	GitHub    string    `gorm:"column:github;size:50" json:"github"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Articles  []Article `gorm:"foreignKey:AuthorID" json:"articles,omitempty"`
@@ -40,6 +46,8 @@ type Article struct {
 	PublishedAt *time.Time     `json:"published_at"`
 	AuthorID    uuid.UUID      `gorm:"type:uuid;not null" json:"author_id"`
 	Author      User           `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	CoverID     *uuid.UUID     `gorm:"type:uuid" json:"cover_id"`
+	Cover       *Media         `gorm:"foreignKey:CoverID" json:"cover,omitempty"`
 	Tags        []*Tag         `gorm:"many2many:article_tags;" json:"tags,omitempty"`
 	Comments    []Comment      `gorm:"foreignKey:ArticleID" json:"comments,omitempty"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -48,15 +56,23 @@ type Article struct {
 }
 
 // Comment コメントモデル
+// ParentID は返信先のコメントで、Depth はトップレベルを0とした入れ子の深さ
+// Status が PUBLISHED 以外のコメントは投稿者と編集者以外には見せない。ModerationReason はその理由
 type Comment struct {
-	ID        uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
-	Content   string         `gorm:"type:text;not null" json:"content"`
-	ArticleID uuid.UUID      `gorm:"type:uuid;not null" json:"article_id"`
-	UserID    uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
-	User      User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID               uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Content          string         `gorm:"type:text;not null" json:"content"`
+	ArticleID        uuid.UUID      `gorm:"type:uuid;not null" json:"article_id"`
+	UserID           uuid.UUID      `gorm:"type:uuid;not null;index:idx_comments_user_created,priority:1" json:"user_id"`
+	User             User           `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ParentID         *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id,omitempty"`
+	Depth            int            `gorm:"not null;default:0" json:"depth"`
+	Replies          []Comment      `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
+	Status           string         `gorm:"size:20;not null;default:PUBLISHED;index" json:"status"`
+	ModerationReason string         `gorm:"size:100" json:"moderation_reason,omitempty"`
+	IPAddress        string         `gorm:"size:45;index:idx_comments_ip_created,priority:1" json:"-"`
+	CreatedAt        time.Time      `gorm:"index:idx_comments_user_created,priority:2;index:idx_comments_ip_created,priority:2" json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // ArticleTag 記事とタグの中間テーブル
@@ -65,6 +81,14 @@ type ArticleTag struct {
 	TagID     uuid.UUID `gorm:"type:uuid;primary_key" json:"tag_id"`
 }
 
+// ArticleLike 記事へのいいね
+type ArticleLike struct {
+	ArticleID uuid.UUID `gorm:"type:uuid;primary_key" json:"article_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primary_key;index" json:"user_id"`
+	User      User      `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // ファクトリー関数
 func NewUser(id uuid.UUID, name, email, password, avatar string) *User {
 	return &User{
@@ -100,5 +124,26 @@ func NewComment(id uuid.UUID, content string, articleID, userID uuid.UUID) *Comm
 		Content:   content,
 		ArticleID: articleID,
 		UserID:    userID,
+		Status:    CommentStatusPublished,
+	}
+}
+
+// NewReply parent への返信を作る
+func NewReply(id uuid.UUID, content string, parent *Comment, userID uuid.UUID) *Comment {
+	return &Comment{
+		ID:        id,
+		Content:   content,
+		ArticleID: parent.ArticleID,
+		UserID:    userID,
+		ParentID:  &parent.ID,
+		Depth:     parent.Depth + 1,
+		Status:    CommentStatusPublished,
+	}
+}
+
+func NewArticleLike(articleID, userID uuid.UUID) *ArticleLike {
+	return &ArticleLike{
+		ArticleID: articleID,
+		UserID:    userID,
 	}
 }
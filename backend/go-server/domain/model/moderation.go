@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CommentStatusPublished = "PUBLISHED"
+	// CommentStatusPending スパムの疑いがあり、編集者の確認を待っている
+	CommentStatusPending  = "PENDING"
+	CommentStatusRejected = "REJECTED"
+)
+
+// BannedWord コメントに含まれているとモデレーション待ちにする語句
+// Word は小文字で保存する
+type BannedWord struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Word      string    `gorm:"size:100;not null;unique" json:"word"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewBannedWord(id uuid.UUID, word string) *BannedWord {
+	return &BannedWord{
+		ID:   id,
+		Word: word,
+	}
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SubscriberStatusPending      = "pending"
+	SubscriberStatusConfirmed    = "confirmed"
+	SubscriberStatusUnsubscribed = "unsubscribed"
+)
+
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+)
+
+// Subscriber ニュースレターの購読者
+// 確認メールのリンクを踏むまではpendingのまま配信しない(ダブルオプトイン)
+type Subscriber struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Email  string    `gorm:"size:255;not null;unique" json:"email"`
+	Status string    `gorm:"size:20;not null;default:pending;index" json:"status"`
+	// ConfirmTokenHash 確認用トークンのSHA-256。トークン自体はメールにのみ含める
+	ConfirmTokenHash string     `gorm:"size:64;index" json:"-"`
+	ConfirmExpiresAt *time.Time `json:"-"`
+	// ConfirmSentAt 最後に確認メールを送った日時。再送の間隔を空けるために使う
+	ConfirmSentAt *time.Time `json:"-"`
+	// UnsubscribeToken 配信停止リンク用。すべてのメールに含めるため平文で持つ
+	UnsubscribeToken string     `gorm:"size:64;not null;unique" json:"-"`
+	ConfirmedAt      *time.Time `json:"confirmed_at"`
+	UnsubscribedAt   *time.Time `json:"unsubscribed_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// NewsletterDelivery 記事公開時の購読者ごとの配信状態
+type NewsletterDelivery struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	ArticleID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_delivery_article_subscriber" json:"article_id"`
+	SubscriberID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_delivery_article_subscriber" json:"subscriber_id"`
+	Subscriber   Subscriber `gorm:"foreignKey:SubscriberID" json:"-"`
+	Status       string     `gorm:"size:20;not null;default:pending;index" json:"status"`
+	Attempts     int        `gorm:"not null;default:0" json:"attempts"`
+	LastError    string     `gorm:"type:text" json:"last_error"`
+	SentAt       *time.Time `json:"sent_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func NewSubscriber(id uuid.UUID, email, unsubscribeToken string) *Subscriber {
+	return &Subscriber{
+		ID:               id,
+		Email:            email,
+		Status:           SubscriberStatusPending,
+		UnsubscribeToken: unsubscribeToken,
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationTypeCommentOnArticle = "comment_on_article"
+	NotificationTypeCommentReply     = "comment_reply"
+	NotificationTypeNewFollower      = "new_follower"
+	NotificationTypeArticlePublished = "article_published"
+)
+
+// Notification ユーザーへのアプリ内通知
+type Notification struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	RecipientID uuid.UUID  `gorm:"type:uuid;not null;index:idx_notifications_recipient,priority:1" json:"recipient_id"`
+	Type        string     `gorm:"size:30;not null" json:"type"`
+	ActorID     *uuid.UUID `gorm:"type:uuid" json:"actor_id"`
+	Actor       *User      `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	ArticleID   *uuid.UUID `gorm:"type:uuid" json:"article_id"`
+	Article     *Article   `gorm:"foreignKey:ArticleID" json:"article,omitempty"`
+	CommentID   *uuid.UUID `gorm:"type:uuid" json:"comment_id"`
+	Comment     *Comment   `gorm:"foreignKey:CommentID" json:"comment,omitempty"`
+	ReadAt      *time.Time `json:"read_at"`
+	CreatedAt   time.Time  `gorm:"index:idx_notifications_recipient,priority:2" json:"created_at"`
+}
+
+func NewNotification(id, recipientID uuid.UUID, typ string, actorID, articleID, commentID *uuid.UUID) *Notification {
+	return &Notification{
+		ID:          id,
+		RecipientID: recipientID,
+		Type:        typ,
+		ActorID:     actorID,
+		ArticleID:   articleID,
+		CommentID:   commentID,
+	}
+}
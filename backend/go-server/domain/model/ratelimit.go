@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// RateLimitBucket 複数台で共有するレート制限のトークンバケット
+type RateLimitBucket struct {
+	Key    string  `gorm:"size:200;primary_key" json:"key"`
+	Tokens float64 `gorm:"not null" json:"tokens"`
+	// Allowed 直前の取得が許可されたか
+	Allowed   bool      `gorm:"not null" json:"allowed"`
+	UpdatedAt time.Time `gorm:"not null;index" json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RelatedArticle 記事ごとに事前計算した関連記事。Rank は0から始まり、スコアの高い順
+type RelatedArticle struct {
+	ArticleID uuid.UUID `gorm:"type:uuid;primary_key;uniqueIndex:idx_related_articles_rank,priority:1" json:"article_id"`
+	RelatedID uuid.UUID `gorm:"type:uuid;primary_key;index" json:"related_id"`
+	Related   Article   `gorm:"foreignKey:RelatedID" json:"-"`
+	Rank      int       `gorm:"not null;uniqueIndex:idx_related_articles_rank,priority:2" json:"rank"`
+	Score     float64   `gorm:"not null" json:"score"`
+}
+
+// RelatedArticleRun 関連記事を最後に計算した時点の記事の更新日時
+// 関連記事が1件もない場合も記録し、記事が編集されたかどうかの判定に使う
+type RelatedArticleRun struct {
+	ArticleID        uuid.UUID `gorm:"type:uuid;primary_key" json:"article_id"`
+	ArticleUpdatedAt time.Time `gorm:"not null" json:"article_updated_at"`
+	ComputedAt       time.Time `gorm:"not null" json:"computed_at"`
+}
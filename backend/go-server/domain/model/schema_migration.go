@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+// SchemaMigration 適用済みのスキーマのバージョン。マイグレーションが成功するたびに記録する
+type SchemaMigration struct {
+	Version   int       `gorm:"primary_key;autoIncrement:false" json:"version"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Series 複数回に分けた記事をまとめる連載
+type Series struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Title       string         `gorm:"size:200;not null" json:"title"`
+	Slug        string         `gorm:"size:200;not null;unique" json:"slug"`
+	Description string         `gorm:"size:1000" json:"description"`
+	AuthorID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"author_id"`
+	Author      User           `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// SeriesArticle 連載に含まれる記事と、その中での順番
+// 記事は1つの連載にのみ含められる。Position は0から始まる
+type SeriesArticle struct {
+	SeriesID  uuid.UUID `gorm:"type:uuid;primary_key;uniqueIndex:idx_series_articles_position,priority:1" json:"series_id"`
+	Series    Series    `gorm:"foreignKey:SeriesID" json:"-"`
+	ArticleID uuid.UUID `gorm:"type:uuid;primary_key;uniqueIndex" json:"article_id"`
+	Article   Article   `gorm:"foreignKey:ArticleID" json:"-"`
+	Position  int       `gorm:"not null;uniqueIndex:idx_series_articles_position,priority:2" json:"position"`
+}
+
+func NewSeries(id uuid.UUID, title, slug, description string, authorID uuid.UUID) *Series {
+	return &Series{
+		ID:          id,
+		Title:       title,
+		Slug:        slug,
+		Description: description,
+		AuthorID:    authorID,
+	}
+}
+
+// NewSeriesArticles articleIDs の順番で連載のパートを作る
+func NewSeriesArticles(seriesID uuid.UUID, articleIDs []uuid.UUID) []*SeriesArticle {
+	parts := make([]*SeriesArticle, 0, len(articleIDs))
+	for i, id := range articleIDs {
+		parts = append(parts, &SeriesArticle{
+			SeriesID:  seriesID,
+			ArticleID: id,
+			Position:  i,
+		})
+	}
+	return parts
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Transactor fn を1つのトランザクションで実行する
+// 一時的なエラーで失敗した場合は fn ごとやり直すので、fn はトランザクションの外に副作用を残してはいけない
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RoleReader = "reader"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Principal 認証済みのリクエスト主体
+type Principal struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+type contextKey struct{}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	v := ctx.Value(contextKey{})
+	if v == nil {
+		return nil, false
+	}
+	p, ok := v.(*Principal)
+	if !ok {
+		return nil, false
+	}
+	return p, true
+}
+
+func WithContext(parent context.Context, p *Principal) context.Context {
+	return context.WithValue(parent, contextKey{}, p)
+}
@@ -0,0 +1,141 @@
+package clientip
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewResolver(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		wantErr bool
+	}{
+		{name: "none", proxies: nil},
+		{name: "cidr and single addresses", proxies: []string{"10.0.0.0/8", " 192.168.1.1 ", "::1", ""}},
+		{name: "invalid address", proxies: []string{"10.0.0.256"}, wantErr: true},
+		{name: "invalid prefix", proxies: []string{"10.0.0.0/33"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewResolver(tt.proxies)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewResolver(%q) error = %v, wantErr %v", tt.proxies, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolverFromRequest(t *testing.T) {
+	r, err := NewResolver([]string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		xForwardedFor string
+		want          string
+	}{
+		{
+			name:       "direct connection",
+			remoteAddr: "203.0.113.5:1234",
+			want:       "203.0.113.5",
+		},
+		{
+			name:          "untrusted peer cannot spoof the header",
+			remoteAddr:    "203.0.113.5:1234",
+			xForwardedFor: "198.51.100.1",
+			want:          "203.0.113.5",
+		},
+		{
+			name:          "trusted proxy",
+			remoteAddr:    "10.0.0.1:1234",
+			xForwardedFor: "198.51.100.1",
+			want:          "198.51.100.1",
+		},
+		{
+			name:       "trusted proxy without header",
+			remoteAddr: "10.0.0.1:1234",
+			want:       "10.0.0.1",
+		},
+		{
+			name:          "walks right to left over trusted hops",
+			remoteAddr:    "10.0.0.1:1234",
+			xForwardedFor: "198.51.100.1, 192.168.1.1, 10.1.2.3",
+			want:          "198.51.100.1",
+		},
+		{
+			name:          "client supplied entries left of the first untrusted hop are ignored",
+			remoteAddr:    "10.0.0.1:1234",
+			xForwardedFor: "1.1.1.1, 198.51.100.1, 10.1.2.3",
+			want:          "198.51.100.1",
+		},
+		{
+			name:          "all hops trusted uses the leftmost",
+			remoteAddr:    "10.0.0.1:1234",
+			xForwardedFor: "10.2.0.1, 192.168.1.1",
+			want:          "10.2.0.1",
+		},
+		{
+			name:          "empty entries are skipped",
+			remoteAddr:    "10.0.0.1:1234",
+			xForwardedFor: "198.51.100.1,, ",
+			want:          "198.51.100.1",
+		},
+		{
+			name:          "invalid hop stops the walk",
+			remoteAddr:    "10.0.0.1:1234",
+			xForwardedFor: "198.51.100.1, not-an-ip, 10.1.2.3",
+			want:          "10.1.2.3",
+		},
+		{
+			name:          "invalid hop right after the proxy",
+			remoteAddr:    "10.0.0.1:1234",
+			xForwardedFor: "198.51.100.1, not-an-ip",
+			want:          "10.0.0.1",
+		},
+		{
+			name:          "ipv6 proxy",
+			remoteAddr:    "[fd00::1]:1234",
+			xForwardedFor: "2001:db8::1",
+			want:          "2001:db8::1",
+		},
+		{
+			name:          "ipv4-mapped ipv6 proxy",
+			remoteAddr:    "[::ffff:10.0.0.1]:1234",
+			xForwardedFor: "198.51.100.1",
+			want:          "198.51.100.1",
+		},
+		{
+			name:       "remote address without port",
+			remoteAddr: "203.0.113.5",
+			want:       "203.0.113.5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xForwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.xForwardedFor)
+			}
+			if got := r.FromRequest(req); got != tt.want {
+				t.Errorf("FromRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); got != "" {
+		t.Errorf("FromContext() = %q, want empty", got)
+	}
+	ctx = WithContext(ctx, "198.51.100.1")
+	if got := FromContext(ctx); got != "198.51.100.1" {
+		t.Errorf("FromContext() = %q, want %q", got, "198.51.100.1")
+	}
+}
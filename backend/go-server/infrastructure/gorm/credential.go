@@ -0,0 +1,97 @@
+package gorm
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// CredentialProvider 接続のたびにパスワードを返す
+// IAM 認証のように有効期限のあるトークンをパスワードに使う場合に差し替える
+type CredentialProvider interface {
+	Password(ctx context.Context) (string, error)
+}
+
+// StaticCredential 設定したパスワードをそのまま使う
+type StaticCredential string
+
+func (c StaticCredential) Password(context.Context) (string, error) {
+	return string(c), nil
+}
+
+// FileCredential 接続のたびにファイルを読み直す
+// サイドカーなどが定期的に書き換えるトークンを使う場合に指定する
+type FileCredential struct {
+	Path string
+}
+
+func (c *FileCredential) Password(context.Context) (string, error) {
+	b, err := os.ReadFile(c.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read database password file: %w", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// CommandCredential コマンドの標準出力をパスワードにする
+// 例えば gcloud sql generate-login-token のようにトークンを発行するコマンドを指定する
+// 結果は TTL の間使い回し、接続のたびにコマンドを実行しないようにする
+type CommandCredential struct {
+	Command []string
+	TTL     time.Duration
+
+	mu       sync.Mutex
+	password string
+	expires  time.Time
+}
+
+func (c *CommandCredential) Password(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.password != "" && time.Now().Before(c.expires) {
+		return c.password, nil
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command[0], c.Command[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run database password command: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	password := strings.TrimSpace(string(out))
+	if password == "" {
+		return "", fmt.Errorf("database password command printed nothing")
+	}
+	c.password, c.expires = password, time.Now().Add(c.TTL)
+	return password, nil
+}
+
+// OpenSQL dsn で接続する sql.DB を作る。creds がある場合は DSN のパスワードの代わりに使う
+// 接続は最初の問い合わせまで行わない
+func OpenSQL(dsn string, creds CredentialProvider) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database DSN: %w", err)
+	}
+	if creds == nil {
+		return stdlib.OpenDB(*connConfig), nil
+	}
+	return stdlib.OpenDB(*connConfig, stdlib.OptionBeforeConnect(func(ctx context.Context, cc *pgx.ConnConfig) error {
+		password, err := creds.Password(ctx)
+		if err != nil {
+			return err
+		}
+		cc.Password = password
+		return nil
+	})), nil
+}
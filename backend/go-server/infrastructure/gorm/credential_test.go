@@ -0,0 +1,177 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileCredential(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content *string
+		want    string
+		wantErr bool
+	}{
+		{name: "trims the trailing newline", content: ptr("secret\n"), want: "secret"},
+		{name: "trims surrounding spaces", content: ptr("  token \r\n"), want: "token"},
+		{name: "missing file", wantErr: true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i)))
+			if tt.content != nil {
+				if err := os.WriteFile(path, []byte(*tt.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := (&FileCredential{Path: path}).Password(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Password() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Password() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileCredentialRereads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	c := &FileCredential{Path: path}
+	for _, want := range []string{"first", "rotated"} {
+		if err := os.WriteFile(path, []byte(want), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := c.Password(context.Background()); err != nil || got != want {
+			t.Errorf("Password() = %q, %v, want %q", got, err, want)
+		}
+	}
+}
+
+func TestCommandCredential(t *testing.T) {
+	tests := []struct {
+		name    string
+		command []string
+		want    string
+		wantErr string
+	}{
+		{name: "stdout is the password", command: []string{"echo", "token"}, want: "token"},
+		{name: "arguments are passed", command: []string{"sh", "-c", `printf '%s-%s\n' "$0" "$1"`, "a", "b"}, want: "a-b"},
+		{name: "failure includes stderr", command: []string{"sh", "-c", "echo denied >&2; exit 1"}, wantErr: "denied"},
+		{name: "empty output", command: []string{"sh", "-c", "echo"}, wantErr: "printed nothing"},
+		{name: "missing command", command: []string{"/nonexistent/command"}, wantErr: "failed to run"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CommandCredential{Command: tt.command, TTL: time.Minute}
+			got, err := c.Password(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Password() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Password() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Password() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCommandCredentialTTL コマンドを実行するたびに数が増えるパスワードで、使い回されたかを確認する
+func TestCommandCredentialTTL(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "runs")
+	command := []string{"sh", "-c", `echo run >> "$0"; wc -l < "$0"`, counter}
+
+	t.Run("cached within the TTL", func(t *testing.T) {
+		c := &CommandCredential{Command: command, TTL: 50 * time.Millisecond}
+		first := mustPassword(t, c)
+		if second := mustPassword(t, c); second != first {
+			t.Errorf("Password() within TTL = %q, want cached %q", second, first)
+		}
+		time.Sleep(60 * time.Millisecond)
+		if third := mustPassword(t, c); third == first {
+			t.Errorf("Password() after TTL = %q, want a new password", third)
+		}
+	})
+
+	t.Run("zero TTL runs every time", func(t *testing.T) {
+		c := &CommandCredential{Command: command}
+		if first, second := mustPassword(t, c), mustPassword(t, c); first == second {
+			t.Errorf("Password() returned %q twice, want the command to run again", first)
+		}
+	})
+
+	t.Run("failures are not cached", func(t *testing.T) {
+		flag := filepath.Join(t.TempDir(), "ok")
+		c := &CommandCredential{
+			Command: []string{"sh", "-c", `test -f "$0" && echo token`, flag},
+			TTL:     time.Minute,
+		}
+		if _, err := c.Password(context.Background()); err == nil {
+			t.Fatal("Password() succeeded before the command could")
+		}
+		if err := os.WriteFile(flag, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got := mustPassword(t, c); got != "token" {
+			t.Errorf("Password() = %q, want %q", got, "token")
+		}
+	})
+}
+
+func TestOpenSQL(t *testing.T) {
+	errNoToken := errors.New("no token")
+	tests := []struct {
+		name    string
+		dsn     string
+		creds   CredentialProvider
+		wantErr string
+	}{
+		{name: "invalid dsn", dsn: "postgres://%zz", wantErr: "invalid database DSN"},
+		// 接続の前にパスワードを取得するので、取得に失敗すると接続しない
+		{name: "credential error stops the connection", dsn: "host=127.0.0.1 port=1 user=test dbname=test connect_timeout=1", creds: failingCredential{errNoToken}, wantErr: errNoToken.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := OpenSQL(tt.dsn, tt.creds)
+			if err == nil {
+				defer db.Close()
+				err = db.PingContext(context.Background())
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type failingCredential struct {
+	err error
+}
+
+func (c failingCredential) Password(context.Context) (string, error) {
+	return "", c.err
+}
+
+func mustPassword(t *testing.T, c CredentialProvider) string {
+	t.Helper()
+	p, err := c.Password(context.Background())
+	if err != nil {
+		t.Fatalf("Password() error = %v", err)
+	}
+	return p
+}
+
+func ptr(s string) *string {
+	return &s
+}
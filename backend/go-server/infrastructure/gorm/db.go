@@ -1,11 +1,11 @@
 package gorm
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"os"
 
 	"github.com/s-blog/backend/go-server/domain/model"
+	infralog "github.com/s-blog/backend/go-server/infrastructure/log"
 	// postgres
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -15,53 +15,70 @@ import (
 // DB コネクション
 var DB *gorm.DB
 
-// InitDB データベース接続の初期化
-func InitDB() {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Tokyo",
-		host, user, password, dbname, port,
-	)
-
-	log.Printf("データベース接続情報: host=%s, port=%s, user=%s, dbname=%s", host, port, user, dbname)
-
+// InitDB データベース接続の初期化。dsn は config.Database.DataSourceName で組み立てる
+// creds がある場合は接続のたびにパスワードを取得する。実行したSQLも l に出力する
+func InitDB(ctx context.Context, l *infralog.Logger, dsn string, creds CredentialProvider) error {
 	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags),
+		gormLogWriter{ctx: ctx, logger: l},
 		logger.Config{
 			LogLevel: logger.Info,
 		},
 	)
 
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	sqlDB, err := OpenSQL(dsn, creds)
+	if err != nil {
+		return err
+	}
+	DB, err = gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
 		Logger: newLogger,
 	})
-
 	if err != nil {
-		log.Fatalf("データベースへの接続に失敗しました: %v", err)
+		return fmt.Errorf("データベースへの接続に失敗しました: %w", err)
 	}
 
-	log.Println("データベース接続に成功しました")
+	l.Info(ctx, "データベース接続に成功しました")
+	return nil
+}
+
+// gormLogWriter gorm のログを zap に出力する
+type gormLogWriter struct {
+	ctx    context.Context
+	logger *infralog.Logger
+}
+
+func (w gormLogWriter) Printf(format string, args ...any) {
+	w.logger.Info(w.ctx, fmt.Sprintf(format, args...))
 }
 
 // MigrateDB データベースマイグレーションを実行
-func MigrateDB() error {
-	log.Println("データベースマイグレーションを開始します...")
+// dropTables が true の場合は既存のテーブルを削除してから作り直す(開発環境のみ)
+func MigrateDB(ctx context.Context, l *infralog.Logger, dropTables bool) error {
+	l.Info(ctx, "データベースマイグレーションを開始します...")
 
 	// テーブルが存在する場合は削除（開発環境のみ）
-	if os.Getenv("APP_ENV") == "development" {
-		log.Println("開発環境: 既存のテーブルをドロップします")
+	if dropTables {
+		l.Info(ctx, "開発環境: 既存のテーブルをドロップします")
 		err := DB.Migrator().DropTable(
 			&model.User{},
 			&model.Article{},
 			&model.Tag{},
 			&model.Comment{},
 			&model.ArticleTag{},
+			&model.Media{},
+			&model.MediaVariant{},
+			&model.AuthorFollow{},
+			&model.TagFollow{},
+			&model.Subscriber{},
+			&model.NewsletterDelivery{},
+			&model.Notification{},
+			&model.ArticleLike{},
+			&model.SeriesArticle{},
+			&model.Series{},
+			&model.RelatedArticle{},
+			&model.RelatedArticleRun{},
+			&model.BannedWord{},
+			&model.RateLimitBucket{},
+			&model.SchemaMigration{},
 		)
 		if err != nil {
 			return fmt.Errorf("テーブルのドロップに失敗しました: %w", err)
@@ -71,17 +88,35 @@ func MigrateDB() error {
 	// マイグレーション実行
 	err := DB.AutoMigrate(
 		&model.User{},
+		&model.Media{},
+		&model.MediaVariant{},
 		&model.Article{},
 		&model.Tag{},
 		&model.Comment{},
 		&model.ArticleTag{},
+		&model.AuthorFollow{},
+		&model.TagFollow{},
+		&model.Subscriber{},
+		&model.NewsletterDelivery{},
+		&model.Notification{},
+		&model.ArticleLike{},
+		&model.Series{},
+		&model.SeriesArticle{},
+		&model.RelatedArticle{},
+		&model.RelatedArticleRun{},
+		&model.BannedWord{},
+		&model.RateLimitBucket{},
+		&model.SchemaMigration{},
 	)
 
 	if err != nil {
 		return fmt.Errorf("マイグレーションに失敗しました: %w", err)
 	}
+	if err := recordSchemaVersion(DB); err != nil {
+		return fmt.Errorf("スキーマのバージョンの記録に失敗しました: %w", err)
+	}
 
-	log.Println("データベースマイグレーションが完了しました")
+	l.Info(ctx, "データベースマイグレーションが完了しました")
 	return nil
 }
 
@@ -0,0 +1,30 @@
+package gorm
+
+import (
+	"context"
+	"time"
+
+	"github.com/s-blog/backend/go-server/domain/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SchemaVersion このビルドが前提とするスキーマのバージョン
+// モデルを追加・変更したら1つ上げる
+const SchemaVersion = 4
+
+func recordSchemaVersion(db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&model.SchemaMigration{
+		Version:   SchemaVersion,
+		AppliedAt: time.Now(),
+	}).Error
+}
+
+// AppliedSchemaVersion 適用済みの最新のバージョンを返す。一度も記録されていない場合は0
+func AppliedSchemaVersion(ctx context.Context, db *gorm.DB) (int, error) {
+	var version int
+	err := db.WithContext(ctx).Model(&model.SchemaMigration{}).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
@@ -0,0 +1,85 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// Router 読み取り専用の問い合わせをレプリカに振り分ける
+// 同じセッションで書き込みがあった後は、レプリカの遅延で古いデータが見えないようにプライマリを使う
+type Router struct {
+	primary  *gorm.DB
+	replicas []*gorm.DB
+	next     atomic.Uint64
+}
+
+// NewRouter primary への書き込みをセッションに記録するコールバックを登録する
+// replicas が空の場合は読み取りもプライマリで行う
+func NewRouter(primary *gorm.DB, replicas ...*gorm.DB) (*Router, error) {
+	if err := registerWriteCallbacks(primary); err != nil {
+		return nil, err
+	}
+	return &Router{primary: primary, replicas: replicas}, nil
+}
+
+// Primary 書き込みと、最新のデータが必要な読み取りに使う
+func (r *Router) Primary() *gorm.DB {
+	return r.primary
+}
+
+// Replicas 接続プールのメトリクスなどに使う
+func (r *Router) Replicas() []*gorm.DB {
+	return r.replicas
+}
+
+// Reader 読み取り用のDBをラウンドロビンで選ぶ。ctx は設定済みで返す
+func (r *Router) Reader(ctx context.Context) *gorm.DB {
+	if len(r.replicas) == 0 || wrote(ctx) {
+		return r.primary.WithContext(ctx)
+	}
+	i := r.next.Add(1) % uint64(len(r.replicas))
+	return r.replicas[i].WithContext(ctx)
+}
+
+type sessionKey struct{}
+
+// WithSession リクエストの間、書き込みの有無を記録する
+// WebSocket ではコネクションの間記録するので、書き込み後の購読や問い合わせもプライマリを使う
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, new(atomic.Bool))
+}
+
+// MarkWritten 以降の Reader をプライマリに固定する
+// gorm を経由しない書き込みの後に呼ぶ。gorm の書き込みはコールバックで記録する
+func MarkWritten(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*atomic.Bool); ok {
+		s.Store(true)
+	}
+}
+
+func wrote(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*atomic.Bool)
+	return ok && s.Load()
+}
+
+const writeCallbackName = "sblog:mark_written"
+
+func registerWriteCallbacks(db *gorm.DB) error {
+	cb := db.Callback()
+	// Exec は raw コールバックで実行される。失敗した書き込みも反映済みの可能性があるので記録する
+	return errors.Join(
+		cb.Create().After("gorm:create").Register(writeCallbackName, markWritten),
+		cb.Update().After("gorm:update").Register(writeCallbackName, markWritten),
+		cb.Delete().After("gorm:delete").Register(writeCallbackName, markWritten),
+		cb.Raw().After("gorm:raw").Register(writeCallbackName, markWritten),
+	)
+}
+
+func markWritten(db *gorm.DB) {
+	if db.Statement.Context != nil {
+		MarkWritten(db.Statement.Context)
+	}
+}
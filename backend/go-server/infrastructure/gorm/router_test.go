@@ -0,0 +1,173 @@
+package gorm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// routed Reader が返したDBがどれかを名前で返す
+// WithContext は新しいセッションを返すので、接続プールで元のDBを見分ける
+func routed(t *testing.T, got *gorm.DB, dbs map[string]*gorm.DB) string {
+	t.Helper()
+	for name, db := range dbs {
+		if got.Statement.ConnPool == db.Statement.ConnPool {
+			return name
+		}
+	}
+	t.Fatal("Reader() returned an unknown DB")
+	return ""
+}
+
+func TestRouterReader(t *testing.T) {
+	primary := openFakeDB(t, nil)
+	replica := openFakeDB(t, nil)
+	dbs := map[string]*gorm.DB{"primary": primary, "replica": replica}
+
+	tests := []struct {
+		name     string
+		replicas []*gorm.DB
+		ctx      func() context.Context
+		want     string
+	}{
+		{
+			name: "no replicas",
+			ctx:  func() context.Context { return WithSession(context.Background()) },
+			want: "primary",
+		},
+		{
+			name:     "no session",
+			replicas: []*gorm.DB{replica},
+			ctx: func() context.Context {
+				ctx := context.Background()
+				MarkWritten(ctx)
+				return ctx
+			},
+			want: "replica",
+		},
+		{
+			name:     "session without writes",
+			replicas: []*gorm.DB{replica},
+			ctx:      func() context.Context { return WithSession(context.Background()) },
+			want:     "replica",
+		},
+		{
+			name:     "session after a write",
+			replicas: []*gorm.DB{replica},
+			ctx: func() context.Context {
+				ctx := WithSession(context.Background())
+				MarkWritten(ctx)
+				return ctx
+			},
+			want: "primary",
+		},
+		{
+			name:     "write in another session",
+			replicas: []*gorm.DB{replica},
+			ctx: func() context.Context {
+				MarkWritten(WithSession(context.Background()))
+				return WithSession(context.Background())
+			},
+			want: "replica",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Router{primary: primary, replicas: tt.replicas}
+			ctx := tt.ctx()
+			got := r.Reader(ctx)
+			if name := routed(t, got, dbs); name != tt.want {
+				t.Errorf("Reader() = %s, want %s", name, tt.want)
+			}
+			if got.Statement.Context != ctx {
+				t.Error("Reader() did not set the context")
+			}
+		})
+	}
+}
+
+func TestRouterRoundRobin(t *testing.T) {
+	dbs := map[string]*gorm.DB{}
+	var replicas []*gorm.DB
+	for _, name := range []string{"a", "b", "c"} {
+		db := openFakeDB(t, nil)
+		dbs[name] = db
+		replicas = append(replicas, db)
+	}
+	r := &Router{primary: openFakeDB(t, nil), replicas: replicas}
+
+	counts := map[string]int{}
+	prev := ""
+	for range 3 * len(replicas) {
+		name := routed(t, r.Reader(context.Background()), dbs)
+		if name == prev {
+			t.Errorf("read from %s twice in a row", name)
+		}
+		counts[name]++
+		prev = name
+	}
+	for name := range dbs {
+		if counts[name] != 3 {
+			t.Errorf("read from %s %d times, want 3", name, counts[name])
+		}
+	}
+}
+
+type routedRow struct {
+	ID   uuid.UUID `gorm:"type:uuid;primary_key"`
+	Name string
+}
+
+// TestRouterWriteCallbacks プライマリでの書き込みがセッションに記録されるか
+func TestRouterWriteCallbacks(t *testing.T) {
+	tests := []struct {
+		name    string
+		execErr error
+		run     func(db *gorm.DB) error
+		want    bool
+	}{
+		{name: "create", run: func(db *gorm.DB) error { return db.Create(&routedRow{ID: uuid.New()}).Error }, want: true},
+		{name: "update", run: func(db *gorm.DB) error {
+			return db.Model(&routedRow{ID: uuid.New()}).Update("name", "x").Error
+		}, want: true},
+		{name: "delete", run: func(db *gorm.DB) error { return db.Delete(&routedRow{ID: uuid.New()}).Error }, want: true},
+		{name: "raw exec", run: func(db *gorm.DB) error { return db.Exec("UPDATE routed_rows SET name = ?", "x").Error }, want: true},
+		// 失敗した書き込みも反映済みの可能性がある
+		{name: "failed exec", execErr: errors.New("connection reset"), run: func(db *gorm.DB) error {
+			return db.Exec("UPDATE routed_rows SET name = ?", "x").Error
+		}, want: true},
+		{name: "find", run: func(db *gorm.DB) error { return db.Find(&[]routedRow{}).Error }},
+		{name: "raw select", run: func(db *gorm.DB) error {
+			var rows []routedRow
+			return db.Raw("SELECT * FROM routed_rows").Scan(&rows).Error
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := openFakeDBWith(t, fakeConnector{execErr: tt.execErr})
+			replica := openFakeDB(t, nil)
+			r, err := NewRouter(primary, replica)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := WithSession(context.Background())
+			if err := tt.run(r.Primary().WithContext(ctx)); (err != nil) != (tt.execErr != nil) {
+				t.Fatalf("error = %v, want %v", err, tt.execErr)
+			}
+			if got := wrote(ctx); got != tt.want {
+				t.Errorf("session written = %v, want %v", got, tt.want)
+			}
+			want := "replica"
+			if tt.want {
+				want = "primary"
+			}
+			if name := routed(t, r.Reader(ctx), map[string]*gorm.DB{"primary": primary, "replica": replica}); name != want {
+				t.Errorf("Reader() after %s = %s, want %s", tt.name, name, want)
+			}
+		})
+	}
+}
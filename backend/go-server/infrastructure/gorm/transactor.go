@@ -0,0 +1,139 @@
+package gorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/domain/repository"
+	infralog "github.com/s-blog/backend/go-server/infrastructure/log"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgAdminShutdown        = "57P01"
+	// pgConnectionException 08 で始まるコードは接続の異常
+	pgConnectionException = "08"
+)
+
+// TransactorOptions MaxAttempts は初回を含めた試行回数
+type TransactorOptions struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Transactor 直列化の失敗・デッドロック・接続の切断で失敗したトランザクションをやり直す
+type Transactor struct {
+	db     *gorm.DB
+	logger *infralog.Logger
+	opts   TransactorOptions
+}
+
+var _ repository.Transactor = (*Transactor)(nil)
+
+func NewTransactor(db *gorm.DB, logger *infralog.Logger, opts TransactorOptions) *Transactor {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+	return &Transactor{db: db, logger: logger, opts: opts}
+}
+
+// Transaction やり直しても失敗した場合は最後のエラーを RetryableError のまま返す
+func (t *Transactor) Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	for attempt := 1; ; attempt++ {
+		err := t.try(ctx, fn)
+		if err == nil || !domainerrors.IsRetryable(err) || attempt >= t.opts.MaxAttempts {
+			return err
+		}
+
+		delay := t.backoff(attempt)
+		t.logger.Warn(ctx, "retrying transaction",
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", t.opts.MaxAttempts),
+			zap.Duration("delay", delay),
+			zap.Error(errors.Unwrap(err)))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *Transactor) try(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	var committing bool
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		committing = true
+		return nil
+	})
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	// コミット中に接続が切れた場合は反映されたかどうか分からないので、やり直さない
+	if committing && isConnectionError(err) {
+		return err
+	}
+	return classify(err)
+}
+
+// backoff 上限を指数的に伸ばし、その範囲でランダムに待つ(full jitter)
+func (t *Transactor) backoff(attempt int) time.Duration {
+	limit := t.opts.MaxDelay
+	if shift := attempt - 1; shift < 32 && t.opts.BaseDelay<<shift < limit {
+		limit = t.opts.BaseDelay << shift
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit) + 1
+}
+
+// classify やり直せば成功しうるエラーを RetryableError で包む
+func classify(err error) error {
+	if domainerrors.IsRetryable(err) {
+		return err
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgSerializationFailure, pgErr.Code == pgDeadlockDetected:
+			return domainerrors.NewRetryableError(err)
+		case pgErr.Code == pgAdminShutdown, strings.HasPrefix(pgErr.Code, pgConnectionException):
+			return domainerrors.NewRetryableError(err)
+		}
+		return err
+	}
+	if isConnectionError(err) {
+		return domainerrors.NewRetryableError(err)
+	}
+	return err
+}
+
+func isConnectionError(err error) bool {
+	return pgconn.SafeToRetry(err) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,261 @@
+package gorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	infralog "github.com/s-blog/backend/go-server/infrastructure/log"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestClassify(t *testing.T) {
+	pgErr := func(code string) error {
+		return fmt.Errorf("query failed: %w", &pgconn.PgError{Code: code})
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "serialization failure", err: pgErr(pgSerializationFailure), want: true},
+		{name: "deadlock", err: pgErr(pgDeadlockDetected), want: true},
+		{name: "admin shutdown", err: pgErr(pgAdminShutdown), want: true},
+		{name: "connection exception", err: pgErr("08000"), want: true},
+		{name: "connection failure", err: pgErr("08006"), want: true},
+		{name: "unique violation", err: pgErr("23505"), want: false},
+		{name: "syntax error", err: pgErr("42601"), want: false},
+		{name: "bad connection", err: driver.ErrBadConn, want: true},
+		{name: "unexpected eof", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "closed connection", err: net.ErrClosed, want: true},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: true},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "broken pipe", err: syscall.EPIPE, want: true},
+		{name: "already retryable", err: domainerrors.NewRetryableError(errors.New("x")), want: true},
+		{name: "record not found", err: gorm.ErrRecordNotFound, want: false},
+		{name: "domain error", err: domainerrors.NotFound("article"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classify(tt.err)
+			if domainerrors.IsRetryable(got) != tt.want {
+				t.Errorf("classify(%v) retryable = %v, want %v", tt.err, !tt.want, tt.want)
+			}
+			if !errors.Is(got, tt.err) {
+				t.Errorf("classify(%v) = %v, lost the original error", tt.err, got)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tr := &Transactor{opts: TransactorOptions{BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond}}
+	tests := []struct {
+		attempt int
+		limit   time.Duration
+	}{
+		{attempt: 1, limit: 10 * time.Millisecond},
+		{attempt: 2, limit: 20 * time.Millisecond},
+		{attempt: 3, limit: 40 * time.Millisecond},
+		{attempt: 4, limit: 80 * time.Millisecond},
+		{attempt: 5, limit: 100 * time.Millisecond},
+		{attempt: 40, limit: 100 * time.Millisecond},
+		{attempt: 100, limit: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			for range 1000 {
+				if d := tr.backoff(tt.attempt); d <= 0 || d > tt.limit {
+					t.Fatalf("backoff(%d) = %s, want within (0, %s]", tt.attempt, d, tt.limit)
+				}
+			}
+		})
+	}
+
+	t.Run("zero delay", func(t *testing.T) {
+		tr := &Transactor{}
+		if d := tr.backoff(3); d != 0 {
+			t.Errorf("backoff() = %s, want 0", d)
+		}
+	})
+}
+
+func TestTransaction(t *testing.T) {
+	serialization := &pgconn.PgError{Code: pgSerializationFailure}
+	tests := []struct {
+		name        string
+		maxAttempts int
+		failures    int
+		err         error
+		commitErr   error
+		wantCalls   int
+		wantErr     bool
+		wantRetry   bool
+	}{
+		{name: "succeeds first time", maxAttempts: 3, wantCalls: 1},
+		{name: "succeeds after retries", maxAttempts: 3, failures: 2, err: serialization, wantCalls: 3},
+		{name: "gives up after max attempts", maxAttempts: 3, failures: 5, err: serialization, wantCalls: 3, wantErr: true, wantRetry: true},
+		{name: "non-retryable error", maxAttempts: 3, failures: 1, err: errors.New("boom"), wantCalls: 1, wantErr: true},
+		{name: "domain error", maxAttempts: 3, failures: 1, err: domainerrors.NotFound("article"), wantCalls: 1, wantErr: true},
+		{name: "max attempts below one runs once", maxAttempts: 0, failures: 1, err: serialization, wantCalls: 1, wantErr: true, wantRetry: true},
+		{name: "connection lost during commit is not retried", maxAttempts: 3, commitErr: driver.ErrBadConn, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openFakeDB(t, tt.commitErr)
+			tr := NewTransactor(db, infralog.New(io.Discard), TransactorOptions{
+				MaxAttempts: tt.maxAttempts,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    time.Millisecond,
+			})
+
+			var calls int
+			err := tr.Transaction(context.Background(), func(tx *gorm.DB) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transaction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if domainerrors.IsRetryable(err) != tt.wantRetry {
+				t.Errorf("Transaction() error = %v, retryable %v, want %v", err, !tt.wantRetry, tt.wantRetry)
+			}
+			if tt.err != nil && tt.wantErr && !errors.Is(err, tt.err) {
+				t.Errorf("Transaction() error = %v, want it to wrap %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestTransactionStopsWhenContextIsDone(t *testing.T) {
+	db := openFakeDB(t, nil)
+	tr := NewTransactor(db, infralog.New(io.Discard), TransactorOptions{
+		MaxAttempts: 5,
+		BaseDelay:   time.Hour,
+		MaxDelay:    time.Hour,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var calls int
+	err := tr.Transaction(ctx, func(tx *gorm.DB) error {
+		calls++
+		return &pgconn.PgError{Code: pgDeadlockDetected}
+	})
+	if calls != 1 || !domainerrors.IsRetryable(err) {
+		t.Errorf("calls = %d, err = %v, want 1 call returning the retryable error", calls, err)
+	}
+}
+
+// openFakeDB BEGIN と COMMIT を受け付けるドライバーで gorm を開く
+// 問い合わせは0行を返し、更新は execErr を返す
+// commitErr を返すとコミット中に接続が切れた状態になる
+func openFakeDB(t *testing.T, commitErr error) *gorm.DB {
+	t.Helper()
+	return openFakeDBWith(t, fakeConnector{commitErr: commitErr})
+}
+
+func openFakeDBWith(t *testing.T, c fakeConnector) *gorm.DB {
+	t.Helper()
+	sqlDB := sql.OpenDB(c)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+	return db
+}
+
+type fakeConnector struct {
+	commitErr error
+	execErr   error
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{commitErr: c.commitErr, execErr: c.execErr}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("use the connector")
+}
+
+type fakeConn struct {
+	commitErr error
+	execErr   error
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{commitErr: c.commitErr}, nil
+}
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return fakeRows{}, nil
+}
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	if c.execErr != nil {
+		return nil, c.execErr
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (fakeRows) Close() error {
+	return nil
+}
+
+func (fakeRows) Next([]driver.Value) error {
+	return io.EOF
+}
+
+type fakeTx struct {
+	commitErr error
+}
+
+func (tx fakeTx) Commit() error {
+	return tx.commitErr
+}
+
+func (tx fakeTx) Rollback() error {
+	return nil
+}
@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+)
+
+// Check 依存先の状態を確認する。使えない場合はエラーを返す
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Registry readiness を判定する名前付きのチェックの一覧
+type Registry struct {
+	timeout  time.Duration
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// NewRegistry timeout は各チェックにかける時間の上限
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// Register 起動時に呼ぶ。Run と並行して呼んではいけない
+func (r *Registry) Register(name string, check Check) {
+	r.checks = append(r.checks, namedCheck{name: name, check: check})
+}
+
+// SetDraining 以降の Run は失敗を返し、ロードバランサーから外れるようにする
+func (r *Registry) SetDraining() {
+	r.draining.Store(true)
+}
+
+// Result 1つのチェックの結果
+// Error は内部のホスト名などを含むことがあるため、JSONには出力しない
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"-"`
+}
+
+// Report 全体の状態と各チェックの結果
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Run すべてのチェックを並行に実行する
+func (r *Registry) Run(ctx context.Context) *Report {
+	report := &Report{Status: StatusOK, Checks: make([]Result, len(r.checks))}
+
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+	if r.draining.Load() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c namedCheck) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	// ctx を見ないチェックでも timeout で打ち切る
+	done := make(chan error, 1)
+	go func() {
+		done <- c.check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", r.timeout)
+	}
+	res := Result{
+		Name:      c.name,
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusFailing
+		res.Error = err.Error()
+	}
+	return res
+}
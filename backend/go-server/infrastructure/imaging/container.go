@@ -0,0 +1,98 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// webpFlagExif VP8X チャンクの EXIF を含むことを示すフラグ
+const webpFlagExif = 0x08
+
+// stripGPSFromPNG eXIf チャンクの位置情報を取り除き、CRCを計算し直す
+// ImageMagick などがテキストチャンクに16進で埋め込むEXIFは解析せずにチャンクごと取り除く
+// 構造が壊れている部分と IEND より後ろは、解析できないデータが残らないよう取り除く
+func stripGPSFromPNG(b []byte) []byte {
+	out := bytes.Clone(b[:len(pngSignature)])
+	i := len(pngSignature)
+	for i+12 <= len(b) {
+		length := int(binary.BigEndian.Uint32(b[i:]))
+		end := i + 12 + length
+		if end > len(b) {
+			break
+		}
+		typ := string(b[i+4 : i+8])
+		chunk := bytes.Clone(b[i:end])
+		data := chunk[8 : 8+length]
+		i = end
+
+		switch {
+		case typ == "eXIf":
+			if err := stripGPSFromSegment(data); err != nil {
+				continue
+			}
+			binary.BigEndian.PutUint32(chunk[8+length:], crc32.ChecksumIEEE(chunk[4:8+length]))
+		case (typ == "tEXt" || typ == "zTXt" || typ == "iTXt") && rawExifProfile(data):
+			continue
+		}
+		out = append(out, chunk...)
+		if typ == "IEND" {
+			break
+		}
+	}
+	return out
+}
+
+// rawExifProfile テキストチャンクのキーワードが EXIF のプロファイルか
+func rawExifProfile(data []byte) bool {
+	keyword, _, _ := bytes.Cut(data, []byte{0})
+	k := string(bytes.ToLower(keyword))
+	return k == "raw profile type exif" || k == "raw profile type app1"
+}
+
+func isWebP(b []byte) bool {
+	return len(b) >= 12 && string(b[:4]) == "RIFF" && string(b[8:12]) == "WEBP"
+}
+
+// stripGPSFromWebP EXIF チャンクの位置情報を取り除く
+// 解析できない EXIF チャンクと、構造が壊れている部分は取り除き、RIFF のサイズと VP8X のフラグを直す
+func stripGPSFromWebP(b []byte) []byte {
+	limit := len(b)
+	if n := 8 + int(binary.LittleEndian.Uint32(b[4:])); n < limit {
+		limit = n
+	}
+
+	out := bytes.Clone(b[:12])
+	vp8x, exif := -1, false
+	i := 12
+	for i+8 <= limit {
+		size := int(binary.LittleEndian.Uint32(b[i+4:]))
+		end := i + 8 + size + size%2
+		if end > limit {
+			break
+		}
+		fourCC := string(b[i : i+4])
+		chunk := bytes.Clone(b[i:end])
+		i = end
+
+		switch fourCC {
+		case "VP8X":
+			vp8x = len(out)
+		case "EXIF":
+			data := bytes.TrimPrefix(chunk[8:8+size], exifHeader)
+			if err := stripGPSFromSegment(data); err != nil {
+				continue
+			}
+			exif = true
+		}
+		out = append(out, chunk...)
+	}
+
+	if vp8x >= 0 && vp8x+9 <= len(out) && !exif {
+		out[vp8x+8] &^= webpFlagExif
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out
+}
@@ -0,0 +1,252 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+)
+
+const (
+	markerSOS  = 0xDA
+	markerAPP1 = 0xE1
+
+	tagOrientation = 0x0112
+	tagGPSInfo     = 0x8825
+)
+
+var (
+	exifHeader     = []byte("Exif\x00\x00")
+	errInvalidExif = errors.New("invalid exif")
+)
+
+// tiff APP1セグメント内のTIFF構造(EXIF本体)
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// exifSegment JPEG内のEXIFセグメントの位置
+type exifSegment struct {
+	start, end int // マーカーを含むセグメント全体
+	tiffStart  int
+}
+
+// findExifSegments SOSまでのセグメントを走査してEXIFを含むAPP1を探す
+func findExifSegments(b []byte) []exifSegment {
+	if len(b) < 4 || b[0] != 0xFF || b[1] != 0xD8 {
+		return nil
+	}
+	var segs []exifSegment
+	i := 2
+	for i+4 <= len(b) {
+		if b[i] != 0xFF {
+			return segs
+		}
+		marker := b[i+1]
+		if marker == markerSOS {
+			return segs
+		}
+		// 長さを持たないマーカー(RSTn, TEM)
+		if (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 || marker == 0xFF {
+			i += 2
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(b[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(b) {
+			return segs
+		}
+		payload := b[i+4 : end]
+		if marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader) {
+			segs = append(segs, exifSegment{start: i, end: end, tiffStart: i + 4 + len(exifHeader)})
+		}
+		i = end
+	}
+	return segs
+}
+
+func parseTIFF(data []byte) (*tiff, uint32, error) {
+	if len(data) < 8 {
+		return nil, 0, errInvalidExif
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0, errInvalidExif
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return nil, 0, errInvalidExif
+	}
+	return &tiff{data: data, order: order}, order.Uint32(data[4:]), nil
+}
+
+// entries IFDのエントリ数とエントリ領域の開始位置
+func (t *tiff) entries(offset uint32) (int, int, error) {
+	off := int(offset)
+	if off < 8 || off+2 > len(t.data) {
+		return 0, 0, errInvalidExif
+	}
+	n := int(t.order.Uint16(t.data[off:]))
+	if off+2+n*12 > len(t.data) {
+		return 0, 0, errInvalidExif
+	}
+	return n, off + 2, nil
+}
+
+// find IFD内のタグを探し、エントリの位置を返す
+func (t *tiff) find(ifd uint32, tag uint16) (int, bool, error) {
+	n, start, err := t.entries(ifd)
+	if err != nil {
+		return 0, false, err
+	}
+	for i := 0; i < n; i++ {
+		e := start + i*12
+		if t.order.Uint16(t.data[e:]) == tag {
+			return e, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func typeSize(typ uint16) int {
+	switch typ {
+	case 1, 2, 6, 7:
+		return 1
+	case 3, 8:
+		return 2
+	case 4, 9, 11:
+		return 4
+	case 5, 10, 12:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// clearIFD IFDのエントリと、エントリが参照する値をすべてゼロで埋めて空のIFDにする
+func (t *tiff) clearIFD(offset uint32) error {
+	n, start, err := t.entries(offset)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		e := start + i*12
+		size := typeSize(t.order.Uint16(t.data[e+2:])) * int(t.order.Uint32(t.data[e+4:]))
+		if size > 4 {
+			valueOff := int(t.order.Uint32(t.data[e+8:]))
+			if valueOff >= 0 && valueOff+size <= len(t.data) {
+				clear(t.data[valueOff : valueOff+size])
+			}
+		}
+	}
+	clear(t.data[start : start+n*12])
+	t.order.PutUint16(t.data[int(offset):], 0)
+	return nil
+}
+
+// StripGPS EXIFから位置情報(GPS IFD)を取り除いたコピーを返す
+// JPEG の APP1、PNG の eXIf チャンク、WebP の EXIF チャンクを対象にする
+// EXIFが壊れていて解析できない場合はEXIFごと取り除く。それ以外の形式はそのまま返す
+func StripGPS(b []byte) []byte {
+	switch {
+	case bytes.HasPrefix(b, pngSignature):
+		return stripGPSFromPNG(b)
+	case isWebP(b):
+		return stripGPSFromWebP(b)
+	default:
+		return stripGPSFromJPEG(b)
+	}
+}
+
+func stripGPSFromJPEG(b []byte) []byte {
+	segs := findExifSegments(b)
+	if len(segs) == 0 {
+		return b
+	}
+
+	out := bytes.Clone(b)
+	var broken []exifSegment
+	for _, seg := range segs {
+		if err := stripGPSFromSegment(out[seg.tiffStart:seg.end]); err != nil {
+			broken = append(broken, seg)
+		}
+	}
+	// 後ろから取り除いて位置がずれないようにする
+	for i := len(broken) - 1; i >= 0; i-- {
+		out = append(out[:broken[i].start], out[broken[i].end:]...)
+	}
+	return out
+}
+
+func stripGPSFromSegment(data []byte) error {
+	t, ifd0, err := parseTIFF(data)
+	if err != nil {
+		return err
+	}
+	e, ok, err := t.find(ifd0, tagGPSInfo)
+	if err != nil || !ok {
+		return err
+	}
+	return t.clearIFD(t.order.Uint32(t.data[e+8:]))
+}
+
+// Orientation JPEGのEXIFに記録された向き(1-8)を返す。記録がない場合は1
+func Orientation(b []byte) int {
+	for _, seg := range findExifSegments(b) {
+		t, ifd0, err := parseTIFF(b[seg.tiffStart:seg.end])
+		if err != nil {
+			continue
+		}
+		e, ok, err := t.find(ifd0, tagOrientation)
+		if err != nil || !ok {
+			continue
+		}
+		if o := int(t.order.Uint16(t.data[e+8:])); o >= 1 && o <= 8 {
+			return o
+		}
+	}
+	return 1
+}
+
+// applyOrientation EXIFの向きに従って画像を回転・反転する
+func applyOrientation(src image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// 5-8は縦横が入れ替わる
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,379 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/gen2brain/webp"
+)
+
+// gpsMarker 緯度の値に埋め込み、取り除かれたかを確かめる
+var gpsMarker = []byte{0xCA, 0xFE, 0xBA, 0xBE}
+
+const (
+	tagGPSLatitudeRef = 0x0001
+	tagGPSLatitude    = 0x0002
+)
+
+// buildTIFF IFD0 に Orientation を、withGPS の場合は GPS IFD への参照を持つTIFFを作る
+// GPS IFD の GPSLatitude は4バイトに収まらないため、IFDの後ろに値を置く
+func buildTIFF(order binary.ByteOrder, orientation int, withGPS bool) []byte {
+	var buf bytes.Buffer
+	if order == binary.LittleEndian {
+		buf.WriteString("II")
+	} else {
+		buf.WriteString("MM")
+	}
+	put16 := func(v uint16) { _ = binary.Write(&buf, order, v) }
+	put32 := func(v uint32) { _ = binary.Write(&buf, order, v) }
+	put16(42)
+	put32(8)
+
+	n := 1
+	if withGPS {
+		n = 2
+	}
+	gpsIFD := uint32(8 + 2 + n*12 + 4)
+	put16(uint16(n))
+	put16(tagOrientation)
+	put16(3)
+	put32(1)
+	put16(uint16(orientation))
+	put16(0)
+	if withGPS {
+		put16(tagGPSInfo)
+		put16(4)
+		put32(1)
+		put32(gpsIFD)
+	}
+	put32(0)
+
+	if withGPS {
+		latitude := gpsIFD + 2 + 2*12 + 4
+		put16(2)
+		put16(tagGPSLatitudeRef)
+		put16(2)
+		put32(2)
+		buf.WriteString("N\x00\x00\x00")
+		put16(tagGPSLatitude)
+		put16(5)
+		put32(3)
+		put32(latitude)
+		put32(0)
+		for range 3 {
+			buf.Write(gpsMarker)
+			put32(1)
+		}
+	}
+	return buf.Bytes()
+}
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 40), G: uint8(y * 40), B: 200, A: 255})
+		}
+	}
+	return img
+}
+
+// jpegWithExif エンコードしたJPEGのSOIの直後にEXIFのAPP1を挿入する
+func jpegWithExif(t *testing.T, tiffData []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(4, 2), nil); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	payload := append(bytes.Clone(exifHeader), tiffData...)
+	app1 := []byte{0xFF, markerAPP1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(payload)+2))
+	out := append(bytes.Clone(b[:2]), app1...)
+	out = append(out, payload...)
+	return append(out, b[2:]...)
+}
+
+func pngChunk(typ string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// pngWithExif IHDRの直後に eXIf チャンクを挿入する
+func pngWithExif(t *testing.T, tiffData []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(4, 2)); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	ihdrEnd := len(pngSignature) + 12 + 13
+	out := bytes.Clone(b[:ihdrEnd])
+	out = append(out, pngChunk("eXIf", tiffData)...)
+	return append(out, b[ihdrEnd:]...)
+}
+
+func riffChunk(fourCC string, data []byte) []byte {
+	chunk := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// webpWithExif 拡張形式(VP8X)のWebPにEXIFチャンクを加える
+func webpWithExif(t *testing.T, exifData []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, testImage(4, 2)); err != nil {
+		t.Fatal(err)
+	}
+	simple := buf.Bytes()
+	if !isWebP(simple) {
+		t.Fatal("encoder did not produce a webp")
+	}
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagExif
+	vp8x[4] = 4 - 1 // canvas width - 1 (24 bit)
+	vp8x[7] = 2 - 1 // canvas height - 1 (24 bit)
+	body := []byte("WEBP")
+	body = append(body, riffChunk("VP8X", vp8x)...)
+	body = append(body, simple[12:]...)
+	body = append(body, riffChunk("EXIF", exifData)...)
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func decodes(t *testing.T, b []byte) {
+	t.Helper()
+	if _, _, err := image.Decode(bytes.NewReader(b)); err != nil {
+		t.Fatalf("stripped image does not decode: %v", err)
+	}
+}
+
+func TestStripGPS(t *testing.T) {
+	orders := map[string]binary.ByteOrder{"little endian": binary.LittleEndian, "big endian": binary.BigEndian}
+	containers := map[string]func(*testing.T, []byte) []byte{
+		"jpeg":             jpegWithExif,
+		"png":              pngWithExif,
+		"webp":             webpWithExif,
+		"webp exif header": func(t *testing.T, d []byte) []byte { return webpWithExif(t, append(bytes.Clone(exifHeader), d...)) },
+	}
+
+	for orderName, order := range orders {
+		for containerName, build := range containers {
+			t.Run(containerName+"/"+orderName, func(t *testing.T) {
+				in := build(t, buildTIFF(order, 6, true))
+				if !bytes.Contains(in, gpsMarker) {
+					t.Fatal("fixture does not contain GPS values")
+				}
+
+				out := StripGPS(in)
+				if bytes.Contains(out, gpsMarker) {
+					t.Error("GPS values remain after StripGPS")
+				}
+				if !bytes.Contains(in, gpsMarker) {
+					t.Error("StripGPS modified its input")
+				}
+				if len(out) != len(in) {
+					t.Errorf("size changed from %d to %d; only the GPS IFD should be cleared", len(in), len(out))
+				}
+				decodes(t, out)
+				if containerName == "jpeg" {
+					if got := Orientation(out); got != 6 {
+						t.Errorf("Orientation() = %d after strip, want 6", got)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestStripGPSWithoutExif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(2, 2)); err != nil {
+		t.Fatal(err)
+	}
+	for name, b := range map[string][]byte{
+		"jpeg without gps": jpegWithExif(t, buildTIFF(binary.BigEndian, 1, false)),
+		"plain png":        buf.Bytes(),
+		"gif":              []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if out := StripGPS(b); !bytes.Equal(out, b) {
+				t.Error("StripGPS changed an image without GPS")
+			}
+		})
+	}
+}
+
+func TestStripGPSCorrupt(t *testing.T) {
+	valid := buildTIFF(binary.LittleEndian, 1, true)
+
+	badOrder := bytes.Clone(valid)
+	copy(badOrder, "XX")
+	badMagic := bytes.Clone(valid)
+	binary.LittleEndian.PutUint16(badMagic[2:], 43)
+	ifdOutOfRange := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(ifdOutOfRange[4:], 1<<20)
+	tooManyEntries := bytes.Clone(valid)
+	binary.LittleEndian.PutUint16(tooManyEntries[8:], 0xFFFF)
+	gpsOutOfRange := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(gpsOutOfRange[8+2+12+8:], 0xFFFFFFF0)
+	gpsEntriesTruncated := bytes.Clone(valid[:len(valid)-30])
+
+	tests := []struct {
+		name string
+		tiff []byte
+	}{
+		{"unknown byte order", badOrder},
+		{"bad magic", badMagic},
+		{"ifd0 out of range", ifdOutOfRange},
+		{"too many ifd0 entries", tooManyEntries},
+		{"gps ifd out of range", gpsOutOfRange},
+		{"gps entries truncated", gpsEntriesTruncated},
+		{"shorter than header", valid[:6]},
+	}
+	for _, tt := range tests {
+		t.Run("jpeg/"+tt.name, func(t *testing.T) {
+			in := jpegWithExif(t, tt.tiff)
+			out := StripGPS(in)
+			if len(findExifSegments(out)) != 0 {
+				t.Error("broken EXIF segment was not removed")
+			}
+			if bytes.Contains(out, gpsMarker) {
+				t.Error("GPS values remain")
+			}
+			decodes(t, out)
+			if got := Orientation(in); got != 1 {
+				t.Errorf("Orientation() = %d for broken EXIF, want 1", got)
+			}
+		})
+		t.Run("png/"+tt.name, func(t *testing.T) {
+			out := StripGPS(pngWithExif(t, tt.tiff))
+			if bytes.Contains(out, []byte("eXIf")) {
+				t.Error("broken eXIf chunk was not removed")
+			}
+			decodes(t, out)
+		})
+		t.Run("webp/"+tt.name, func(t *testing.T) {
+			out := StripGPS(webpWithExif(t, tt.tiff))
+			if bytes.Contains(out, []byte("EXIF")) {
+				t.Error("broken EXIF chunk was not removed")
+			}
+			if out[12+8]&webpFlagExif != 0 {
+				t.Error("VP8X still has the EXIF flag")
+			}
+			if size := int(binary.LittleEndian.Uint32(out[4:])); size != len(out)-8 {
+				t.Errorf("RIFF size = %d, want %d", size, len(out)-8)
+			}
+			decodes(t, out)
+		})
+	}
+}
+
+func TestStripGPSTruncated(t *testing.T) {
+	jpegIn := jpegWithExif(t, buildTIFF(binary.BigEndian, 1, true))
+	pngIn := pngWithExif(t, buildTIFF(binary.BigEndian, 1, true))
+	webpIn := webpWithExif(t, buildTIFF(binary.BigEndian, 1, true))
+
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		// APP1 の長さがファイルの終わりを超える
+		{"jpeg segment", jpegIn[:2+4+len(exifHeader)+20]},
+		{"jpeg marker only", jpegIn[:3]},
+		{"png chunk", pngIn[:len(pngSignature)+12+13+20]},
+		{"webp chunk", webpIn[:len(webpIn)-10]},
+		{"webp header only", webpIn[:12]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := StripGPS(tt.in)
+			if bytes.Contains(out, gpsMarker) {
+				t.Error("GPS values remain")
+			}
+			_ = Orientation(tt.in)
+		})
+	}
+}
+
+func TestOrientation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for o := 1; o <= 8; o++ {
+			if got := Orientation(jpegWithExif(t, buildTIFF(order, o, true))); got != o {
+				t.Errorf("%s: Orientation() = %d, want %d", order, got, o)
+			}
+		}
+		if got := Orientation(jpegWithExif(t, buildTIFF(order, 9, false))); got != 1 {
+			t.Errorf("%s: Orientation() = %d for out of range value, want 1", order, got)
+		}
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 3x2 の画像の左上の画素が、向きを適用した後にどこへ移るか
+	const w, h = 3, 2
+	tests := []struct {
+		orientation int
+		width       int
+		height      int
+		x, y        int
+	}{
+		{1, w, h, 0, 0},
+		{2, w, h, w - 1, 0},
+		{3, w, h, w - 1, h - 1},
+		{4, w, h, 0, h - 1},
+		{5, h, w, 0, 0},
+		{6, h, w, h - 1, 0},
+		{7, h, w, h - 1, w - 1},
+		{8, h, w, 0, w - 1},
+	}
+	src := testImage(w, h)
+	src.Set(0, 0, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+	for _, tt := range tests {
+		dst := applyOrientation(src, tt.orientation)
+		b := dst.Bounds()
+		if b.Dx() != tt.width || b.Dy() != tt.height {
+			t.Errorf("orientation %d: size = %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.width, tt.height)
+			continue
+		}
+		if got := color.NRGBAModel.Convert(dst.At(tt.x, tt.y)).(color.NRGBA); got.R != 255 || got.G != 255 {
+			t.Errorf("orientation %d: top-left pixel not at (%d, %d)", tt.orientation, tt.x, tt.y)
+		}
+	}
+}
+
+func TestDecodeAppliesOrientation(t *testing.T) {
+	img, err := Decode(jpegWithExif(t, buildTIFF(binary.LittleEndian, 6, false)), 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 2 || b.Dy() != 4 {
+		t.Errorf("size = %dx%d, want 2x4", b.Dx(), b.Dy())
+	}
+}
+
+func TestDecodeRejectsTooManyPixels(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(pngSignature)
+	ihdr := binary.BigEndian.AppendUint32(nil, 60000)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 60000)
+	ihdr = append(ihdr, 8, 6, 0, 0, 0)
+	buf.Write(pngChunk("IHDR", ihdr))
+
+	if _, err := Decode(buf.Bytes(), 50_000_000); !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("Decode() error = %v, want ErrTooManyPixels", err)
+	}
+}
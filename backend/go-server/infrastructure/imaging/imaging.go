@@ -0,0 +1,138 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+
+	// デコード可能な形式を登録する
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/gen2brain/webp"
+	"golang.org/x/image/draw"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatWebP = "webp"
+
+	jpegQuality = 80
+	webpQuality = 75
+)
+
+// DefaultWidths レスポンシブ画像として生成する横幅
+var DefaultWidths = []int{320, 640, 1280}
+
+// Formats バリアントとして生成する形式
+var Formats = []string{FormatWebP, FormatJPEG}
+
+// Variant リサイズ・再エンコードした画像
+type Variant struct {
+	Width  int
+	Height int
+	Format string
+	Data   []byte
+}
+
+// ErrTooManyPixels 画素数が上限を超えている
+var ErrTooManyPixels = errors.New("image has too many pixels")
+
+// CheckPixels ヘッダーだけを読んで画像のサイズを返す。画素数が maxPixels を超える場合は ErrTooManyPixels
+func CheckPixels(b []byte, maxPixels int64) (width, height int, err error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode image config: %w", err)
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return cfg.Width, cfg.Height, fmt.Errorf("%w: %dx%d exceeds %d", ErrTooManyPixels, cfg.Width, cfg.Height, maxPixels)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// Decode 画像をデコードし、JPEGの場合はEXIFのOrientationを適用する
+// ヘッダーのサイズが大きすぎる画像はデコード前に拒否し、メモリを使い切らないようにする
+func Decode(b []byte, maxPixels int64) (image.Image, error) {
+	if _, _, err := CheckPixels(b, maxPixels); err != nil {
+		return nil, err
+	}
+	img, format, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if format == "jpeg" {
+		img = applyOrientation(img, Orientation(b))
+	}
+	return img, nil
+}
+
+// VariantWidths 元画像より大きいサイズへの拡大はしない
+// 元画像が最小幅より小さい場合は元のサイズのみを返す
+func VariantWidths(srcWidth int, widths []int) []int {
+	var out []int
+	for _, w := range widths {
+		if w < srcWidth {
+			out = append(out, w)
+		}
+	}
+	if len(out) < len(widths) {
+		out = append(out, srcWidth)
+	}
+	return out
+}
+
+// Resize アスペクト比を保ったまま横幅をwidthに縮小する
+func Resize(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	if b.Dx() == width {
+		return src
+	}
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// Encode 指定した形式でエンコードする。メタデータは書き出されない
+func Encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	case FormatWebP:
+		err = webp.Encode(&buf, img, webp.Options{Quality: webpQuality})
+	default:
+		return nil, fmt.Errorf("unsupported image format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateVariants 横幅と形式の組み合わせごとにバリアントを生成する
+func GenerateVariants(src image.Image, widths []int) ([]Variant, error) {
+	var variants []Variant
+	for _, w := range VariantWidths(src.Bounds().Dx(), widths) {
+		resized := Resize(src, w)
+		for _, format := range Formats {
+			data, err := Encode(resized, format)
+			if err != nil {
+				return nil, err
+			}
+			variants = append(variants, Variant{
+				Width:  resized.Bounds().Dx(),
+				Height: resized.Bounds().Dy(),
+				Format: format,
+				Data:   data,
+			})
+		}
+	}
+	return variants, nil
+}
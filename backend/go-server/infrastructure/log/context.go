@@ -2,10 +2,14 @@ package log
 
 import (
 	"context"
+
+	"go.uber.org/zap"
 )
 
 type contextKey struct{}
 
+type fieldsKey struct{}
+
 func FromContext(ctx context.Context) (*Logger, bool) {
 	v := ctx.Value(contextKey{})
 	if v == nil {
@@ -29,3 +33,24 @@ func MustFromContext(ctx context.Context) *Logger {
 func WithContext(parent context.Context, logger *Logger) context.Context {
 	return context.WithValue(parent, contextKey{}, logger)
 }
+
+// WithFields ctx を渡したログに出力するフィールドを追加する
+// リクエストID・ユーザーID・GraphQLの操作名など、処理の途中で分かる値に使う
+func WithFields(parent context.Context, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return parent
+	}
+	current := FieldsFromContext(parent)
+	merged := make([]zap.Field, 0, len(current)+len(fields))
+	merged = append(merged, current...)
+	merged = append(merged, fields...)
+	return context.WithValue(parent, fieldsKey{}, merged)
+}
+
+func FieldsFromContext(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+	return fields
+}
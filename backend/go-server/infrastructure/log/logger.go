@@ -9,7 +9,7 @@ import (
 )
 
 type Logger struct {
-	logger        *zap.Logger
+	logger *zap.Logger
 }
 
 func New(w io.Writer) *Logger {
@@ -20,20 +20,20 @@ func New(w io.Writer) *Logger {
 	}
 }
 
-func (l *Logger) Debug(_ context.Context, msg string, fields ...zap.Field) {
-	l.logger.Debug(msg, fields...)
+func (l *Logger) Debug(ctx context.Context, msg string, fields ...zap.Field) {
+	l.logger.Debug(msg, withContextFields(ctx, fields)...)
 }
 
-func (l *Logger) Info(_ context.Context, msg string, fields ...zap.Field) {
-	l.logger.Info(msg, fields...)
+func (l *Logger) Info(ctx context.Context, msg string, fields ...zap.Field) {
+	l.logger.Info(msg, withContextFields(ctx, fields)...)
 }
 
-func (l *Logger) Warn(_ context.Context, msg string, fields ...zap.Field) {
-	l.logger.Warn(msg, fields...)
+func (l *Logger) Warn(ctx context.Context, msg string, fields ...zap.Field) {
+	l.logger.Warn(msg, withContextFields(ctx, fields)...)
 }
 
-func (l *Logger) Error(_ context.Context, msg string, fields ...zap.Field) {
-	l.logger.Error(msg, fields...)
+func (l *Logger) Error(ctx context.Context, msg string, fields ...zap.Field) {
+	l.logger.Error(msg, withContextFields(ctx, fields)...)
 
 	// エラーフィールドを抽出
 	for _, f := range fields {
@@ -51,6 +51,15 @@ func (l *Logger) With(fields ...zap.Field) *Logger {
 	}
 }
 
+// withContextFields WithFields で ctx に追加したフィールドを先頭に付ける
+func withContextFields(ctx context.Context, fields []zap.Field) []zap.Field {
+	ctxFields := FieldsFromContext(ctx)
+	if len(ctxFields) == 0 {
+		return fields
+	}
+	return append(append(make([]zap.Field, 0, len(ctxFields)+len(fields)), ctxFields...), fields...)
+}
+
 func (l *Logger) notifyError(err error) {
 	// エラー通知の代替でログ出力
 	l.logger.Error("error notification", zap.Error(err))
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File 送信する代わりに.emlファイルとして書き出すMailer。ローカル開発用
+type File struct {
+	dir  string
+	from string
+}
+
+var (
+	_ Mailer = (*File)(nil)
+	_ Pinger = (*File)(nil)
+)
+
+func NewFile(dir, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail dir: %w", err)
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+func (f *File) Send(_ context.Context, msg *Message) error {
+	if msg.From == "" {
+		msg.From = f.from
+	}
+	b, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), randomID()[:8])
+	return os.WriteFile(filepath.Join(f.dir, name), b, 0o644)
+}
+
+// Ping 書き出し先のディレクトリがあるかを確認する
+func (f *File) Ping(_ context.Context) error {
+	info, err := os.Stat(f.dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", f.dir)
+	}
+	return nil
+}
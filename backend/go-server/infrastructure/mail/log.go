@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+
+	"go.uber.org/zap"
+)
+
+// Log 送信する代わりにログに出力するMailer。ローカル開発用
+type Log struct {
+	logger *log.Logger
+	from   string
+}
+
+var _ Mailer = (*Log)(nil)
+
+func NewLog(logger *log.Logger, from string) *Log {
+	return &Log{logger: logger, from: from}
+}
+
+func (l *Log) Send(ctx context.Context, msg *Message) error {
+	if msg.From == "" {
+		msg.From = l.from
+	}
+	l.logger.Info(ctx, "mail sent",
+		zap.String("from", msg.From),
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("text", msg.Text),
+	)
+	return nil
+}
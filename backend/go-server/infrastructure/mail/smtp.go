@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTP SMTPサーバー経由で送信するMailer
+type SMTP struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+var (
+	_ Mailer = (*SMTP)(nil)
+	_ Pinger = (*SMTP)(nil)
+)
+
+func NewSMTP(host string, port int, username, password, from string) *SMTP {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTP{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		host: host,
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s *SMTP) Send(ctx context.Context, msg *Message) error {
+	if msg.From == "" {
+		msg.From = s.from
+	}
+	b, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	// net/smtpはcontextに対応していないため、キャンセル時は結果を待たずに返す
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, b)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send mail via smtp: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ping SMTPサーバーに接続できるかだけを確認する
+func (s *SMTP) Ping(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,162 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/imaging"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/storage"
+	"github.com/s-blog/backend/go-server/infrastructure/worker"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// processableTypes バリアントを生成する画像形式。アニメーションGIFは対象外
+var processableTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// Processor アップロードされた画像からレスポンシブ用のバリアントを生成する
+type Processor struct {
+	db      *gorm.DB
+	storage storage.Storage
+	pool    *worker.Pool
+	widths  []int
+	// maxPixels アップロード時にも確認するが、上限を下げた場合に備えてデコード前に再度確認する
+	maxPixels int64
+}
+
+func NewProcessor(db *gorm.DB, st storage.Storage, pool *worker.Pool, maxPixels int64) *Processor {
+	return &Processor{
+		db:        db,
+		storage:   st,
+		pool:      pool,
+		widths:    imaging.DefaultWidths,
+		maxPixels: maxPixels,
+	}
+}
+
+// Processable バリアント生成の対象かどうか
+func Processable(mimeType string) bool {
+	return processableTypes[mimeType]
+}
+
+// Enqueue バリアント生成をバックグラウンドワーカーに積む
+func (p *Processor) Enqueue(mediaID uuid.UUID) error {
+	return p.pool.Enqueue("media.process:"+mediaID.String(), func(ctx context.Context) error {
+		return p.Process(ctx, mediaID)
+	})
+}
+
+// ResumePending 再起動などで処理されずに残ったメディアを再度キューに積む
+func (p *Processor) ResumePending(ctx context.Context) error {
+	var ids []uuid.UUID
+	err := p.db.WithContext(ctx).Model(&model.Media{}).
+		Where("status IN ?", []string{model.MediaStatusPending, model.MediaStatusProcessing}).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch pending media: %w", err)
+	}
+	for _, id := range ids {
+		if err := p.Enqueue(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Process 画像をデコードし、横幅・形式ごとのバリアントを保存する
+func (p *Processor) Process(ctx context.Context, mediaID uuid.UUID) error {
+	db := p.db.WithContext(ctx)
+
+	var m model.Media
+	if err := db.First(&m, "id = ?", mediaID).Error; err != nil {
+		return fmt.Errorf("failed to fetch media %s: %w", mediaID, err)
+	}
+	if m.Status == model.MediaStatusReady {
+		return nil
+	}
+	if err := p.setStatus(db, &m, model.MediaStatusProcessing); err != nil {
+		return err
+	}
+
+	if err := p.process(ctx, db, &m); err != nil {
+		if serr := p.setStatus(db, &m, model.MediaStatusFailed); serr != nil {
+			log.MustFromContext(ctx).Error(ctx, "failed to mark media as failed", zap.Error(serr))
+		}
+		return fmt.Errorf("failed to process media %s: %w", mediaID, err)
+	}
+	return nil
+}
+
+func (p *Processor) process(ctx context.Context, db *gorm.DB, m *model.Media) error {
+	rc, err := p.storage.Get(ctx, m.StorageKey)
+	if err != nil {
+		return err
+	}
+	b, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+
+	img, err := imaging.Decode(b, p.maxPixels)
+	if err != nil {
+		return err
+	}
+	variants, err := imaging.GenerateVariants(img, p.widths)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]*model.MediaVariant, 0, len(variants))
+	for _, v := range variants {
+		key := variantKey(m.StorageKey, v.Width, v.Format)
+		if err := p.storage.Put(ctx, key, bytes.NewReader(v.Data), int64(len(v.Data)), "image/"+v.Format); err != nil {
+			return fmt.Errorf("failed to store variant %s: %w", key, err)
+		}
+		rows = append(rows, model.NewMediaVariant(uuid.New(), m.ID, v.Format, v.Width, v.Height, int64(len(v.Data)), key))
+	}
+
+	bounds := img.Bounds()
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "media_id"}, {Name: "format"}, {Name: "width"}},
+			DoUpdates: clause.AssignmentColumns([]string{"height", "size", "storage_key"}),
+		}).Create(&rows).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(m).Updates(map[string]interface{}{
+			"width":  bounds.Dx(),
+			"height": bounds.Dy(),
+			"status": model.MediaStatusReady,
+		}).Error
+	})
+}
+
+func (p *Processor) setStatus(db *gorm.DB, m *model.Media, status string) error {
+	return db.Model(m).Update("status", status).Error
+}
+
+// variantKey 元ファイルと同じ場所に幅と形式ごとのファイルを置く
+// 例: media/ab/abcd.jpg -> media/ab/abcd/w640.webp
+func variantKey(originalKey string, width int, format string) string {
+	base := strings.TrimSuffix(originalKey, path.Ext(originalKey))
+	ext := format
+	if format == imaging.FormatJPEG {
+		ext = "jpg"
+	}
+	return fmt.Sprintf("%s/w%d.%s", base, width, ext)
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/s-blog/backend/go-server/domain/model"
+	"gorm.io/gorm"
+)
+
+// collectTimeout スクレイプが遅いDBに引きずられないようにする
+const collectTimeout = 5 * time.Second
+
+// ContentCollector 公開記事数などの業務指標をスクレイプのたびにDBから集計する
+type ContentCollector struct {
+	db                    *gorm.DB
+	publishedArticles     *prometheus.Desc
+	pendingComments       *prometheus.Desc
+	newsletterSubscribers *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*ContentCollector)(nil)
+
+func NewContentCollector(db *gorm.DB) *ContentCollector {
+	return &ContentCollector{
+		db: db,
+		publishedArticles: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "content", "published_articles"),
+			"Number of published articles.", nil, nil),
+		pendingComments: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "content", "pending_comments"),
+			"Number of comments waiting for moderation.", nil, nil),
+		newsletterSubscribers: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "content", "newsletter_subscribers"),
+			"Number of confirmed newsletter subscribers.", nil, nil),
+	}
+}
+
+func (c *ContentCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.publishedArticles
+	ch <- c.pendingComments
+	ch <- c.newsletterSubscribers
+}
+
+func (c *ContentCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	db := c.db.WithContext(ctx)
+
+	c.count(ch, c.publishedArticles, db.Model(&model.Article{}).
+		Where("published_at IS NOT NULL AND published_at <= ?", time.Now()))
+	c.count(ch, c.pendingComments, db.Model(&model.Comment{}).
+		Where("status = ?", model.CommentStatusPending))
+	c.count(ch, c.newsletterSubscribers, db.Model(&model.Subscriber{}).
+		Where("status = ?", model.SubscriberStatusConfirmed))
+}
+
+func (c *ContentCollector) count(ch chan<- prometheus.Metric, desc *prometheus.Desc, q *gorm.DB) {
+	var n int64
+	if err := q.Count(&n).Error; err != nil {
+		ch <- prometheus.NewInvalidMetric(desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(n))
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sblog"
+
+// Metrics Prometheus に公開するメトリクス
+type Metrics struct {
+	Registry *prometheus.Registry
+	HTTP     *HTTP
+	GraphQL  *GraphQL
+}
+
+// HTTP リクエストの件数と処理時間。handler はルーティングのパターン
+type HTTP struct {
+	Requests *prometheus.CounterVec
+	Duration *prometheus.HistogramVec
+}
+
+// GraphQL 操作ごとの処理時間とエラーの件数
+type GraphQL struct {
+	Duration *prometheus.HistogramVec
+	Errors   *prometheus.CounterVec
+}
+
+// New Goランタイムとプロセスのメトリクスを登録したレジストリを作る
+// DBなど起動後に分かるものは Registry.MustRegister で追加する
+func New() *Metrics {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	m := &Metrics{
+		Registry: reg,
+		HTTP: &HTTP{
+			Requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "http",
+				Name:      "requests_total",
+				Help:      "Number of HTTP requests by handler, method and status code.",
+			}, []string{"handler", "method", "code"}),
+			Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "http",
+				Name:      "request_duration_seconds",
+				Help:      "HTTP request latency by handler, method and status code.",
+				Buckets:   prometheus.DefBuckets,
+			}, []string{"handler", "method", "code"}),
+		},
+		GraphQL: &GraphQL{
+			Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "graphql",
+				Name:      "operation_duration_seconds",
+				Help:      "GraphQL operation latency by operation name and type.",
+				Buckets:   prometheus.DefBuckets,
+			}, []string{"operation", "type"}),
+			Errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "graphql",
+				Name:      "operation_errors_total",
+				Help:      "Number of GraphQL operations that returned errors, by operation name and type.",
+			}, []string{"operation", "type"}),
+		},
+	}
+	reg.MustRegister(m.HTTP.Requests, m.HTTP.Duration, m.GraphQL.Duration, m.GraphQL.Errors)
+	return m
+}
+
+// Handler /metrics のハンドラー
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
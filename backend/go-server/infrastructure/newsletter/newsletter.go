@@ -0,0 +1,326 @@
+package newsletter
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/mail"
+	"github.com/s-blog/backend/go-server/infrastructure/worker"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidEmail = domainerrors.InvalidArgument("invalid email address")
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+type Options struct {
+	// SiteURL 記事へのリンクに使うフロントエンドのURL
+	SiteURL string
+	// APIURL 確認・配信停止リンクに使うこのサーバーのURL
+	APIURL     string
+	ConfirmTTL time.Duration
+	// ResendInterval 未確認のアドレスに確認メールを再送するまでの最短の間隔
+	ResendInterval time.Duration
+	MaxAttempts    int
+	BatchSize      int
+	RetryDelay     time.Duration
+}
+
+// Service 購読の受付と記事公開時のメール配信
+type Service struct {
+	db     *gorm.DB
+	mailer mail.Mailer
+	pool   *worker.Pool
+	opts   Options
+}
+
+func NewService(db *gorm.DB, mailer mail.Mailer, pool *worker.Pool, opts Options) *Service {
+	return &Service{db: db, mailer: mailer, pool: pool, opts: opts}
+}
+
+// Subscribe 購読を受け付けて確認メールを送る
+// 登録済みかどうかを外部から判別できないよう、確認済みのアドレスでもエラーにしない
+func (s *Service) Subscribe(ctx context.Context, email string) error {
+	addr, err := netmail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return ErrInvalidEmail
+	}
+	email = strings.ToLower(addr.Address)
+
+	token := randomToken()
+	now := time.Now()
+	expiresAt := now.Add(s.opts.ConfirmTTL)
+
+	var sendConfirm bool
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sub model.Subscriber
+		err := tx.Where("email = ?", email).First(&sub).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			sub = *model.NewSubscriber(uuid.New(), email, randomToken())
+			sub.ConfirmTokenHash = hashToken(token)
+			sub.ConfirmExpiresAt = &expiresAt
+			sub.ConfirmSentAt = &now
+			sendConfirm = true
+			return tx.Create(&sub).Error
+		}
+		if err != nil {
+			return err
+		}
+		if sub.Status == model.SubscriberStatusConfirmed {
+			return nil
+		}
+		// 第三者に同じアドレスで繰り返し登録されてもメールを送り続けないよう、間隔を空ける
+		// 前回のトークンはそのまま有効なので、届いたメールから確認できる
+		if sub.Status == model.SubscriberStatusPending && sub.ConfirmSentAt != nil &&
+			now.Sub(*sub.ConfirmSentAt) < s.opts.ResendInterval {
+			return nil
+		}
+		sendConfirm = true
+		return tx.Model(&sub).Updates(map[string]interface{}{
+			"status":             model.SubscriberStatusPending,
+			"confirm_token_hash": hashToken(token),
+			"confirm_expires_at": expiresAt,
+			"confirm_sent_at":    now,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save subscriber: %w", err)
+	}
+	if !sendConfirm {
+		return nil
+	}
+
+	html, text, err := confirmTemplate.render(confirmData{
+		ConfirmURL: s.apiURL("/newsletter/confirm", token),
+		ExpiresIn:  s.opts.ConfirmTTL.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render confirmation mail: %w", err)
+	}
+	msg := &mail.Message{
+		To:      email,
+		Subject: "【s-blog】ニュースレター登録の確認",
+		HTML:    html,
+		Text:    text,
+	}
+	return s.pool.Enqueue("newsletter.confirm", func(ctx context.Context) error {
+		return s.mailer.Send(ctx, msg)
+	})
+}
+
+// Confirm 確認メールのトークンを検証して購読を有効にする
+func (s *Service) Confirm(ctx context.Context, token string) error {
+	now := time.Now()
+	res := s.db.WithContext(ctx).Model(&model.Subscriber{}).
+		Where("confirm_token_hash = ? AND status = ? AND confirm_expires_at > ?", hashToken(token), model.SubscriberStatusPending, now).
+		Updates(map[string]interface{}{
+			"status":             model.SubscriberStatusConfirmed,
+			"confirmed_at":       now,
+			"confirm_token_hash": "",
+			"confirm_expires_at": nil,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// Unsubscribe 配信停止リンクのトークンで購読を解除する。解除済みの場合も成功とする
+func (s *Service) Unsubscribe(ctx context.Context, token string) error {
+	if token == "" {
+		return ErrInvalidToken
+	}
+	res := s.db.WithContext(ctx).Model(&model.Subscriber{}).
+		Where("unsubscribe_token = ?", token).
+		Updates(map[string]interface{}{
+			"status":          model.SubscriberStatusUnsubscribed,
+			"unsubscribed_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// QueueArticle 確認済みの購読者全員分の配信レコードを作る
+// 記事の公開と同じトランザクションで呼び、コミット後にDispatchする
+func (s *Service) QueueArticle(tx *gorm.DB, articleID uuid.UUID) error {
+	return tx.Exec(`
+		INSERT INTO newsletter_deliveries (id, article_id, subscriber_id, status, attempts, created_at, updated_at)
+		SELECT gen_random_uuid(), ?, id, ?, 0, now(), now()
+		FROM subscribers
+		WHERE status = ?
+		ON CONFLICT DO NOTHING`,
+		articleID, model.DeliveryStatusPending, model.SubscriberStatusConfirmed,
+	).Error
+}
+
+// Dispatch 記事の未送信分の配信をバックグラウンドワーカーに積む
+func (s *Service) Dispatch(articleID uuid.UUID) error {
+	return s.pool.Enqueue("newsletter.deliver:"+articleID.String(), func(ctx context.Context) error {
+		return s.deliver(ctx, articleID)
+	})
+}
+
+// ResumePending 再起動などで送信されずに残った配信を再度キューに積む
+func (s *Service) ResumePending(ctx context.Context) error {
+	var articleIDs []uuid.UUID
+	err := s.retryable(s.db.WithContext(ctx).Model(&model.NewsletterDelivery{})).
+		Distinct("article_id").
+		Pluck("article_id", &articleIDs).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch pending deliveries: %w", err)
+	}
+	for _, id := range articleIDs {
+		if err := s.Dispatch(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryable 未送信、または試行回数が上限に達していない失敗した配信
+func (s *Service) retryable(q *gorm.DB) *gorm.DB {
+	return q.Where("status = ? OR (status = ? AND attempts < ?)",
+		model.DeliveryStatusPending, model.DeliveryStatusFailed, s.opts.MaxAttempts)
+}
+
+func (s *Service) deliver(ctx context.Context, articleID uuid.UUID) error {
+	db := s.db.WithContext(ctx)
+	logger := log.MustFromContext(ctx)
+
+	var article model.Article
+	if err := db.Preload("Author").First(&article, "id = ?", articleID).Error; err != nil {
+		return fmt.Errorf("failed to fetch article %s: %w", articleID, err)
+	}
+
+	var lastID uuid.UUID
+	var sent, failed int
+	for {
+		var deliveries []*model.NewsletterDelivery
+		// 1回の実行で同じ配信を2度処理しないようにIDの昇順で進める
+		err := s.retryable(db.Preload("Subscriber")).
+			Where("article_id = ? AND id > ?", articleID, lastID).
+			Order("id asc").
+			Limit(s.opts.BatchSize).
+			Find(&deliveries).Error
+		if err != nil {
+			return fmt.Errorf("failed to fetch deliveries: %w", err)
+		}
+		if len(deliveries) == 0 {
+			break
+		}
+		lastID = deliveries[len(deliveries)-1].ID
+
+		for _, d := range deliveries {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := s.deliverOne(ctx, db, &article, d); err != nil {
+				failed++
+				logger.Warn(ctx, "failed to deliver newsletter",
+					zap.String("delivery_id", d.ID.String()), zap.Int("attempts", d.Attempts+1), zap.Error(err))
+				continue
+			}
+			sent++
+		}
+	}
+	logger.Info(ctx, "newsletter delivered",
+		zap.String("article_id", articleID.String()), zap.Int("sent", sent), zap.Int("failed", failed))
+
+	if failed > 0 {
+		// 上限に達していない失敗分は時間を置いて再送する
+		time.AfterFunc(s.opts.RetryDelay, func() {
+			if err := s.Dispatch(articleID); err != nil && !errors.Is(err, worker.ErrStopped) {
+				logger.Error(context.Background(), "failed to schedule newsletter retry", zap.Error(err))
+			}
+		})
+	}
+	return nil
+}
+
+func (s *Service) deliverOne(ctx context.Context, db *gorm.DB, article *model.Article, d *model.NewsletterDelivery) error {
+	// 公開後に配信停止した購読者には送らない
+	if d.Subscriber.Status != model.SubscriberStatusConfirmed {
+		return db.Model(d).Updates(map[string]interface{}{
+			"status":     model.DeliveryStatusFailed,
+			"attempts":   s.opts.MaxAttempts,
+			"last_error": "subscriber is not confirmed",
+		}).Error
+	}
+
+	unsubscribeURL := s.apiURL("/newsletter/unsubscribe", d.Subscriber.UnsubscribeToken)
+	html, text, err := articleTemplate.render(articleData{
+		Title:          article.Title,
+		Excerpt:        article.Excerpt,
+		AuthorName:     article.Author.Name,
+		ArticleURL:     strings.TrimSuffix(s.opts.SiteURL, "/") + "/articles/" + article.ID.String(),
+		UnsubscribeURL: unsubscribeURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	sendErr := s.mailer.Send(ctx, &mail.Message{
+		To:      d.Subscriber.Email,
+		Subject: article.Title,
+		HTML:    html,
+		Text:    text,
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+	if sendErr != nil {
+		if err := db.Model(d).Updates(map[string]interface{}{
+			"status":     model.DeliveryStatusFailed,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": sendErr.Error(),
+		}).Error; err != nil {
+			return errors.Join(sendErr, err)
+		}
+		return sendErr
+	}
+	return db.Model(d).Updates(map[string]interface{}{
+		"status":     model.DeliveryStatusSent,
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": "",
+		"sent_at":    time.Now(),
+	}).Error
+}
+
+func (s *Service) apiURL(path, token string) string {
+	return strings.TrimSuffix(s.opts.APIURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,53 @@
+package newsletter
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// mailTemplate HTML版とテキスト版の組
+type mailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+func mustLoadTemplate(name string) *mailTemplate {
+	return &mailTemplate{
+		html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/"+name+".html.tmpl")),
+		text: texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/"+name+".txt.tmpl")),
+	}
+}
+
+func (t *mailTemplate) render(data any) (html, text string, err error) {
+	var hb, tb bytes.Buffer
+	if err := t.html.Execute(&hb, data); err != nil {
+		return "", "", err
+	}
+	if err := t.text.Execute(&tb, data); err != nil {
+		return "", "", err
+	}
+	return hb.String(), tb.String(), nil
+}
+
+var (
+	confirmTemplate = mustLoadTemplate("confirm")
+	articleTemplate = mustLoadTemplate("article")
+)
+
+type confirmData struct {
+	ConfirmURL string
+	ExpiresIn  string
+}
+
+type articleData struct {
+	Title          string
+	Excerpt        string
+	AuthorName     string
+	ArticleURL     string
+	UnsubscribeURL string
+}
@@ -0,0 +1,82 @@
+package notification
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/s-blog/backend/go-server/domain/event"
+	"github.com/s-blog/backend/go-server/domain/model"
+
+	"gorm.io/gorm"
+)
+
+// Record ドメインイベントを受信者ごとの通知に変換して保存する
+// txはイベントを発生させた書き込みと同じトランザクションを渡す
+func Record(tx *gorm.DB, events ...event.Event) error {
+	for _, e := range events {
+		if err := record(tx, e); err != nil {
+			return fmt.Errorf("failed to record notification for %T: %w", e, err)
+		}
+	}
+	return nil
+}
+
+func record(tx *gorm.DB, e event.Event) error {
+	switch e := e.(type) {
+	case event.CommentAdded:
+		var notifications []*model.Notification
+		if e.ParentAuthorID != nil && *e.ParentAuthorID != e.CommenterID {
+			notifications = append(notifications, model.NewNotification(
+				uuid.New(), *e.ParentAuthorID, model.NotificationTypeCommentReply,
+				&e.CommenterID, &e.ArticleID, &e.CommentID,
+			))
+		}
+		// 返信先の投稿者が記事の著者の場合は返信の通知だけにする
+		repliedToAuthor := e.ParentAuthorID != nil && *e.ParentAuthorID == e.ArticleAuthorID
+		if e.ArticleAuthorID != e.CommenterID && !repliedToAuthor {
+			notifications = append(notifications, model.NewNotification(
+				uuid.New(), e.ArticleAuthorID, model.NotificationTypeCommentOnArticle,
+				&e.CommenterID, &e.ArticleID, &e.CommentID,
+			))
+		}
+		if len(notifications) == 0 {
+			return nil
+		}
+		return tx.Create(&notifications).Error
+
+	case event.UserFollowed:
+		// フォローと解除を繰り返されても通知が積み重ならないよう、同じユーザーからの未読の通知があれば作らない
+		return tx.Exec(`
+			INSERT INTO notifications (id, recipient_id, type, actor_id, created_at)
+			SELECT ?, ?, ?, ?, ?
+			WHERE NOT EXISTS (
+				SELECT 1 FROM notifications
+				WHERE recipient_id = ? AND type = ? AND actor_id = ? AND read_at IS NULL
+			)`,
+			uuid.New(), e.AuthorID, model.NotificationTypeNewFollower, e.FollowerID, time.Now(),
+			e.AuthorID, model.NotificationTypeNewFollower, e.FollowerID,
+		).Error
+
+	case event.ArticlePublished:
+		// 著者のフォロワーと、記事のタグのフォロワーに通知する(重複は除く)
+		return tx.Exec(`
+			INSERT INTO notifications (id, recipient_id, type, actor_id, article_id, created_at)
+			SELECT gen_random_uuid(), recipients.follower_id, ?, ?, ?, ?
+			FROM (
+				SELECT follower_id FROM author_follows WHERE author_id = ?
+				UNION
+				SELECT tag_follows.follower_id
+				FROM tag_follows
+				JOIN article_tags ON article_tags.tag_id = tag_follows.tag_id
+				WHERE article_tags.article_id = ?
+			) AS recipients
+			WHERE recipients.follower_id <> ?`,
+			model.NotificationTypeArticlePublished, e.AuthorID, e.ArticleID, time.Now(),
+			e.AuthorID, e.ArticleID, e.AuthorID,
+		).Error
+
+	default:
+		return fmt.Errorf("unknown event: %T", e)
+	}
+}
@@ -0,0 +1,281 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/s-blog/backend/go-server/domain/event"
+	"github.com/s-blog/backend/go-server/domain/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRecordCommentAdded(t *testing.T) {
+	articleAuthor := uuid.New()
+	commenter := uuid.New()
+	parentAuthor := uuid.New()
+
+	tests := []struct {
+		name         string
+		commenter    uuid.UUID
+		parentAuthor *uuid.UUID
+		// want "受信者 種類"
+		want []string
+	}{
+		{
+			name:      "comment on the article",
+			commenter: commenter,
+			want:      []string{articleAuthor.String() + " " + model.NotificationTypeCommentOnArticle},
+		},
+		{
+			name:      "the author comments on their own article",
+			commenter: articleAuthor,
+		},
+		{
+			name:         "reply to another reader",
+			commenter:    commenter,
+			parentAuthor: &parentAuthor,
+			want: []string{
+				articleAuthor.String() + " " + model.NotificationTypeCommentOnArticle,
+				parentAuthor.String() + " " + model.NotificationTypeCommentReply,
+			},
+		},
+		{
+			// 記事の著者には返信の通知だけを送る
+			name:         "reply to the article author",
+			commenter:    commenter,
+			parentAuthor: &articleAuthor,
+			want:         []string{articleAuthor.String() + " " + model.NotificationTypeCommentReply},
+		},
+		{
+			name:         "the author replies to a reader",
+			commenter:    articleAuthor,
+			parentAuthor: &parentAuthor,
+			want:         []string{parentAuthor.String() + " " + model.NotificationTypeCommentReply},
+		},
+		{
+			name:         "reply to their own comment",
+			commenter:    parentAuthor,
+			parentAuthor: &parentAuthor,
+			want:         []string{articleAuthor.String() + " " + model.NotificationTypeCommentOnArticle},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, rec := openRecordingDB(t, nil)
+			e := event.CommentAdded{
+				CommentID:       uuid.New(),
+				ArticleID:       uuid.New(),
+				ArticleAuthorID: articleAuthor,
+				CommenterID:     tt.commenter,
+				ParentAuthorID:  tt.parentAuthor,
+			}
+			if err := Record(db, e); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+
+			var got []string
+			for _, row := range rec.inserted() {
+				got = append(got, row["recipient_id"]+" "+row["type"])
+				if row["actor_id"] != tt.commenter.String() || row["article_id"] != e.ArticleID.String() || row["comment_id"] != e.CommentID.String() {
+					t.Errorf("notification = %v, want the commenter, article and comment", row)
+				}
+			}
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("notified %q, want %q", got, tt.want)
+			}
+			if len(tt.want) == 0 && len(rec.statements) > 0 {
+				t.Errorf("executed %v, want nothing", rec.statements)
+			}
+		})
+	}
+}
+
+// TestRecordUserFollowed フォローと解除を繰り返しても、未読の通知があれば新しく作らない
+func TestRecordUserFollowed(t *testing.T) {
+	db, rec := openRecordingDB(t, nil)
+	e := event.UserFollowed{FollowerID: uuid.New(), AuthorID: uuid.New()}
+	if err := Record(db, e); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if len(rec.statements) != 1 {
+		t.Fatalf("executed %v, want one statement", rec.statements)
+	}
+	s := rec.statements[0]
+
+	insert := regexp.MustCompile(`(?s)INSERT INTO notifications \(id, recipient_id, type, actor_id, created_at\)\s+SELECT \$1, \$2, \$3, \$4, \$5\s+WHERE NOT EXISTS \(`)
+	unread := regexp.MustCompile(`(?s)NOT EXISTS \(\s*SELECT 1 FROM notifications\s+WHERE recipient_id = \$6 AND type = \$7 AND actor_id = \$8 AND read_at IS NULL\s*\)`)
+	if !insert.MatchString(s.sql) || !unread.MatchString(s.sql) {
+		t.Errorf("sql = %s, want an insert guarded by an unread notification check", s.sql)
+	}
+
+	want := []string{e.AuthorID.String(), model.NotificationTypeNewFollower, e.FollowerID.String()}
+	if len(s.args) != 8 {
+		t.Fatalf("args = %v, want 8", s.args)
+	}
+	for i, w := range want {
+		// 作る通知と、未読の通知を探す条件が同じ受信者・種類・フォロワーを指す
+		if s.args[1+i] != w || s.args[5+i] != w {
+			t.Errorf("args = %v, want %q at $%d and $%d", s.args, w, 2+i, 6+i)
+		}
+	}
+}
+
+func TestRecordArticlePublished(t *testing.T) {
+	db, rec := openRecordingDB(t, nil)
+	e := event.ArticlePublished{ArticleID: uuid.New(), AuthorID: uuid.New()}
+	if err := Record(db, e); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if len(rec.statements) != 1 {
+		t.Fatalf("executed %v, want one statement", rec.statements)
+	}
+	s := rec.statements[0]
+	for _, want := range []string{"FROM author_follows WHERE author_id = $5", "UNION", "WHERE article_tags.article_id = $6", "recipients.follower_id <> $7"} {
+		if !strings.Contains(s.sql, want) {
+			t.Errorf("sql does not contain %q: %s", want, s.sql)
+		}
+	}
+	if s.args[4] != e.AuthorID.String() || s.args[5] != e.ArticleID.String() || s.args[6] != e.AuthorID.String() {
+		t.Errorf("args = %v, want the author's and the tags' followers except the author", s.args)
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	errDB := errors.New("connection reset")
+	db, _ := openRecordingDB(t, errDB)
+
+	err := Record(db, event.UserFollowed{FollowerID: uuid.New(), AuthorID: uuid.New()})
+	if !errors.Is(err, errDB) || !strings.Contains(err.Error(), "event.UserFollowed") {
+		t.Errorf("Record() error = %v, want the DB error with the event type", err)
+	}
+
+	type unknownEvent struct{ event.Event }
+	if err := Record(db, unknownEvent{}); err == nil {
+		t.Error("Record() with an unknown event did not fail")
+	}
+}
+
+// recorder 実行されたSQLと引数を記録する。問い合わせは0行を返す
+type recorder struct {
+	err        error
+	statements []statement
+}
+
+type statement struct {
+	sql  string
+	args []string
+}
+
+var insertColumnsPattern = regexp.MustCompile(`^INSERT INTO "notifications" \(([^)]*)\)`)
+
+// inserted gorm で作成した通知を列名から値への対応にする
+func (r *recorder) inserted() []map[string]string {
+	var rows []map[string]string
+	for _, s := range r.statements {
+		m := insertColumnsPattern.FindStringSubmatch(s.sql)
+		if m == nil {
+			continue
+		}
+		cols := strings.Split(strings.ReplaceAll(m[1], `"`, ""), ",")
+		for i := 0; i+len(cols) <= len(s.args); i += len(cols) {
+			row := make(map[string]string, len(cols))
+			for j, c := range cols {
+				row[c] = s.args[i+j]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (r *recorder) record(query string, args []driver.NamedValue) error {
+	s := statement{sql: query}
+	for _, a := range args {
+		v, _ := a.Value.(string)
+		s.args = append(s.args, v)
+	}
+	r.statements = append(r.statements, s)
+	return r.err
+}
+
+func openRecordingDB(t *testing.T, err error) (*gorm.DB, *recorder) {
+	t.Helper()
+	rec := &recorder{err: err}
+	sqlDB := sql.OpenDB(recordingConnector{rec})
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, openErr := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:                 logger.Discard,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if openErr != nil {
+		t.Fatalf("failed to open gorm: %v", openErr)
+	}
+	return db, rec
+}
+
+type recordingConnector struct {
+	rec *recorder
+}
+
+func (c recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return recordingConn(c), nil
+}
+
+func (c recordingConnector) Driver() driver.Driver {
+	return recordingDriver{}
+}
+
+type recordingDriver struct{}
+
+func (recordingDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("use the connector")
+}
+
+type recordingConn struct {
+	rec *recorder
+}
+
+func (c recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c recordingConn) Close() error {
+	return nil
+}
+
+func (c recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c recordingConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.rec.record(query, args); err != nil {
+		return nil, err
+	}
+	return emptyRows{}, nil
+}
+
+func (c recordingConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.rec.record(query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return []string{"id"} }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
@@ -0,0 +1,76 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+const subscriberBuffer = 16
+
+// Memory プロセス内で完結するBroker。単一レプリカでの運用とテスト用
+type Memory struct {
+	mu     sync.RWMutex
+	topics map[string]map[chan []byte]struct{}
+}
+
+var _ Broker = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{topics: map[string]map[chan []byte]struct{}{}}
+}
+
+func (m *Memory) Publish(_ context.Context, topic string, payload []byte) error {
+	m.deliver(topic, payload)
+	return nil
+}
+
+// deliver 受信側のバッファが一杯の場合は、配信を待たずにそのメッセージを捨てる
+func (m *Memory) deliver(topic string, payload []byte) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for ch := range m.topics[topic] {
+		select {
+		case ch <- payload:
+		default:
+		}
+	}
+}
+
+func (m *Memory) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	ch := make(chan []byte, subscriberBuffer)
+
+	m.mu.Lock()
+	if m.topics[topic] == nil {
+		m.topics[topic] = map[chan []byte]struct{}{}
+	}
+	m.topics[topic][ch] = struct{}{}
+	m.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		// Closeで既に閉じられている場合は何もしない
+		if _, ok := m.topics[topic][ch]; !ok {
+			return
+		}
+		delete(m.topics[topic], ch)
+		if len(m.topics[topic]) == 0 {
+			delete(m.topics, topic)
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
+
+// Close すべての購読を終了する
+func (m *Memory) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for topic, subs := range m.topics {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(m.topics, topic)
+	}
+}
@@ -0,0 +1,136 @@
+package pubsub
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// notifyChannel すべてのトピックを1つのチャネルに流し、受信側でトピックごとに振り分ける
+	notifyChannel = "sblog_pubsub"
+	// maxPayloadSize NOTIFYのペイロードは8000バイトまで
+	maxPayloadSize = 7900
+
+	minReconnectDelay = 500 * time.Millisecond
+	maxReconnectDelay = 30 * time.Second
+)
+
+type envelope struct {
+	Topic   string          `json:"t"`
+	Payload json.RawMessage `json:"p"`
+}
+
+// Postgres LISTEN/NOTIFYで複数レプリカにメッセージを配るBroker
+// 自分が発行したNOTIFYも受信するため、ローカルの購読者にもLISTEN経由で届ける
+type Postgres struct {
+	db     *sql.DB
+	logger *log.Logger
+	local  *Memory
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ Broker = (*Postgres)(nil)
+
+// NewPostgres LISTEN用にコネクションプールから1本を専有するゴルーチンを起動する
+func NewPostgres(ctx context.Context, db *sql.DB, logger *log.Logger) *Postgres {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Postgres{
+		db:     db,
+		logger: logger,
+		local:  NewMemory(),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go p.listen(ctx)
+	return p
+}
+
+func (p *Postgres) Publish(ctx context.Context, topic string, payload []byte) error {
+	if !json.Valid(payload) {
+		return fmt.Errorf("pubsub: payload must be JSON")
+	}
+	b, err := json.Marshal(envelope{Topic: topic, Payload: payload})
+	if err != nil {
+		return err
+	}
+	if len(b) > maxPayloadSize {
+		return fmt.Errorf("pubsub: payload too large (%d bytes)", len(b))
+	}
+	_, err = p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", notifyChannel, string(b))
+	return err
+}
+
+func (p *Postgres) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	return p.local.Subscribe(ctx, topic)
+}
+
+// Close LISTENを止めてコネクションをプールに返す
+func (p *Postgres) Close() {
+	p.cancel()
+	<-p.done
+	p.local.Close()
+}
+
+// listen 接続が切れた場合はバックオフしながら再接続する
+func (p *Postgres) listen(ctx context.Context) {
+	defer close(p.done)
+	delay := minReconnectDelay
+	for {
+		err := p.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		p.logger.Warn(ctx, "pubsub listener disconnected", zap.Error(err), zap.Duration("retry_in", delay))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func (p *Postgres) listenOnce(ctx context.Context) error {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		c, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("pubsub: unexpected driver connection %T", driverConn)
+		}
+		pgConn := c.Conn()
+		if _, err := pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{notifyChannel}.Sanitize()); err != nil {
+			return err
+		}
+		// LISTEN状態のコネクションをプールに返さないよう、終了時は破棄する
+		defer pgConn.Close(context.Background())
+
+		p.logger.Info(ctx, "pubsub listener started")
+		for {
+			n, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			var e envelope
+			if err := json.Unmarshal([]byte(n.Payload), &e); err != nil {
+				p.logger.Warn(ctx, "pubsub: invalid notification", zap.Error(err))
+				continue
+			}
+			p.local.deliver(e.Topic, e.Payload)
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package pubsub
+
+import (
+	"context"
+)
+
+// Broker トピック単位のpub/sub
+// 購読者が受信に追いつかない場合、メッセージは取りこぼされることがある
+type Broker interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe はctxがキャンセルされるまでメッセージを受け取るチャネルを返す
+	// ctxがキャンセルされるとチャネルは閉じられる
+	Subscribe(ctx context.Context, topic string) (<-chan []byte, error)
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval この間隔ごとに満杯に戻ったバケットを捨てる
+const sweepInterval = time.Minute
+
+// Memory プロセス内でバケットを保持するStore
+// 複数台で動かすと台数分の回数を許可してしまう
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	rate    Rate
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]*bucket{}, lastSweep: time.Now()}
+}
+
+func (m *Memory) Take(_ context.Context, key string, rate Rate) (Result, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > sweepInterval {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rate.Burst), updated: now, rate: rate}
+		m.buckets[key] = b
+	}
+	b.tokens = b.refilled(now)
+	b.updated = now
+	b.rate = rate
+
+	if b.tokens < 1 {
+		return newResult(false, b.tokens, rate), nil
+	}
+	b.tokens--
+	return newResult(true, b.tokens, rate), nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if b.refilled(now) >= float64(b.rate.Burst) {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
+
+func (b *bucket) refilled(now time.Time) float64 {
+	elapsed := now.Sub(b.updated)
+	return math.Min(float64(b.rate.Burst), b.tokens+float64(elapsed)/float64(b.rate.Interval))
+}
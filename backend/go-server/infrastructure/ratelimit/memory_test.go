@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewResult(t *testing.T) {
+	rate := Rate{Burst: 10, Interval: time.Second}
+	tests := []struct {
+		name    string
+		allowed bool
+		tokens  float64
+		want    Result
+	}{
+		{
+			name:    "full",
+			allowed: true,
+			tokens:  10,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 10},
+		},
+		{
+			name:    "fractional tokens are rounded down",
+			allowed: true,
+			tokens:  2.5,
+			want:    Result{Allowed: true, Limit: 10, Remaining: 2, Reset: 7500 * time.Millisecond},
+		},
+		{
+			name:    "denied waits for the missing fraction",
+			allowed: false,
+			tokens:  0.25,
+			want:    Result{Allowed: false, Limit: 10, Remaining: 0, RetryAfter: 750 * time.Millisecond, Reset: 9750 * time.Millisecond},
+		},
+		{
+			name:    "empty",
+			allowed: false,
+			tokens:  0,
+			want:    Result{Allowed: false, Limit: 10, Remaining: 0, RetryAfter: time.Second, Reset: 10 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newResult(tt.allowed, tt.tokens, rate); got != tt.want {
+				t.Errorf("newResult(%v, %g) = %+v, want %+v", tt.allowed, tt.tokens, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketRefilled(t *testing.T) {
+	now := time.Now()
+	rate := Rate{Burst: 5, Interval: time.Second}
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{name: "no time passed", tokens: 1, elapsed: 0, want: 1},
+		{name: "one interval adds one token", tokens: 1, elapsed: time.Second, want: 2},
+		{name: "partial interval", tokens: 0, elapsed: 500 * time.Millisecond, want: 0.5},
+		{name: "capped at burst", tokens: 4, elapsed: time.Hour, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bucket{tokens: tt.tokens, updated: now.Add(-tt.elapsed), rate: rate}
+			if got := b.refilled(now); got != tt.want {
+				t.Errorf("refilled() = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryTake(t *testing.T) {
+	ctx := context.Background()
+	// 補充はテスト中に起きない程度に遅くする
+	rate := Rate{Burst: 3, Interval: time.Hour}
+
+	tests := []struct {
+		name          string
+		key           string
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{name: "first request uses the burst", key: "a", wantAllowed: true, wantRemaining: 2},
+		{name: "second", key: "a", wantAllowed: true, wantRemaining: 1},
+		{name: "last token", key: "a", wantAllowed: true, wantRemaining: 0},
+		{name: "exhausted", key: "a", wantAllowed: false, wantRemaining: 0},
+		{name: "still exhausted", key: "a", wantAllowed: false, wantRemaining: 0},
+		{name: "other keys have their own bucket", key: "b", wantAllowed: true, wantRemaining: 2},
+	}
+
+	m := NewMemory()
+	for _, tt := range tests {
+		res, err := m.Take(ctx, tt.key, rate)
+		if err != nil {
+			t.Fatalf("%s: Take() error = %v", tt.name, err)
+		}
+		if res.Allowed != tt.wantAllowed || res.Remaining != tt.wantRemaining || res.Limit != rate.Burst {
+			t.Errorf("%s: Take(%q) = %+v, want allowed=%v remaining=%d limit=%d",
+				tt.name, tt.key, res, tt.wantAllowed, tt.wantRemaining, rate.Burst)
+		}
+		if !res.Allowed && (res.RetryAfter <= 0 || res.RetryAfter > rate.Interval) {
+			t.Errorf("%s: RetryAfter = %s, want within (0, %s]", tt.name, res.RetryAfter, rate.Interval)
+		}
+	}
+}
+
+func TestMemoryTakeRefills(t *testing.T) {
+	ctx := context.Background()
+	rate := Rate{Burst: 1, Interval: 20 * time.Millisecond}
+	m := NewMemory()
+
+	if res, _ := m.Take(ctx, "k", rate); !res.Allowed {
+		t.Fatalf("first Take() denied: %+v", res)
+	}
+	if res, _ := m.Take(ctx, "k", rate); res.Allowed {
+		t.Fatalf("second Take() allowed before refill: %+v", res)
+	}
+	time.Sleep(2 * rate.Interval)
+	if res, _ := m.Take(ctx, "k", rate); !res.Allowed {
+		t.Fatalf("Take() after refill denied: %+v", res)
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	now := time.Now()
+	rate := Rate{Burst: 2, Interval: time.Second}
+	m := NewMemory()
+	m.buckets["full"] = &bucket{tokens: 1, updated: now.Add(-time.Minute), rate: rate}
+	m.buckets["draining"] = &bucket{tokens: 0, updated: now, rate: rate}
+
+	m.sweep(now)
+
+	if _, ok := m.buckets["full"]; ok {
+		t.Error("sweep kept a bucket that has refilled to the burst")
+	}
+	if _, ok := m.buckets["draining"]; !ok {
+		t.Error("sweep dropped a bucket that is still below the burst")
+	}
+}
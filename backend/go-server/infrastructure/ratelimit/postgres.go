@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// refillSQL 前回の更新から補充されたトークン数を加えた値
+const refillSQL = `LEAST(CAST(@burst AS float8), rate_limit_buckets.tokens + CAST(EXTRACT(EPOCH FROM now() - rate_limit_buckets.updated_at) AS float8) / CAST(@interval AS float8))`
+
+// takeSQL 補充と取得を1文で行い、同じキーへの同時アクセスでも行ロックで直列化する
+// UPDATE の各式は更新前の行を参照するため、補充後のトークン数を繰り返し書いている
+const takeSQL = `
+INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+VALUES (@key, CAST(@burst AS float8) - 1, true, now())
+ON CONFLICT (key) DO UPDATE SET
+	tokens = CASE WHEN ` + refillSQL + ` >= 1 THEN ` + refillSQL + ` - 1 ELSE ` + refillSQL + ` END,
+	allowed = ` + refillSQL + ` >= 1,
+	updated_at = now()
+RETURNING tokens, allowed`
+
+// Postgres バケットをDBに保持するStore。複数台で制限を共有できる
+type Postgres struct {
+	db     *gorm.DB
+	logger *log.Logger
+}
+
+func NewPostgres(db *gorm.DB, logger *log.Logger) *Postgres {
+	return &Postgres{db: db, logger: logger}
+}
+
+func (p *Postgres) Take(ctx context.Context, key string, rate Rate) (Result, error) {
+	var row model.RateLimitBucket
+	err := p.db.WithContext(ctx).Raw(takeSQL,
+		sql.Named("key", key),
+		sql.Named("burst", rate.Burst),
+		sql.Named("interval", rate.Interval.Seconds()),
+	).Scan(&row).Error
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(row.Allowed, row.Tokens, rate), nil
+}
+
+// Prune 更新されていないバケットを定期的に削除する。ctxがキャンセルされるまで戻らない
+// maxAge は設定しているどのバケットも満杯に戻る時間より長くする
+func (p *Postgres) Prune(ctx context.Context, every, maxAge time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := p.db.WithContext(ctx).
+				Where("updated_at < ?", time.Now().Add(-maxAge)).
+				Delete(&model.RateLimitBucket{}).Error
+			if err != nil && ctx.Err() == nil {
+				p.logger.Warn(ctx, "failed to prune rate limit buckets", zap.Error(err))
+			}
+		}
+	}
+}
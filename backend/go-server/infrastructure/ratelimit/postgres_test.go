@@ -0,0 +1,179 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testPostgres TEST_DATABASE_DSN のDBに接続する。設定されていない場合はスキップする
+func testPostgres(t *testing.T) *Postgres {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	if err := db.AutoMigrate(&model.RateLimitBucket{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return NewPostgres(db, log.New(io.Discard))
+}
+
+// testKey テスト間、実行間でバケットが混ざらないキー
+func testKey(t *testing.T, p *Postgres) string {
+	key := "test:" + t.Name() + ":" + uuid.NewString()
+	t.Cleanup(func() {
+		p.db.Where("key = ?", key).Delete(&model.RateLimitBucket{})
+	})
+	return key
+}
+
+func TestPostgresTake(t *testing.T) {
+	p := testPostgres(t)
+	ctx := context.Background()
+	key := testKey(t, p)
+	rate := Rate{Burst: 3, Interval: time.Hour}
+
+	tests := []struct {
+		name          string
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{name: "insert uses the burst", wantAllowed: true, wantRemaining: 2},
+		{name: "update takes a token", wantAllowed: true, wantRemaining: 1},
+		{name: "last token", wantAllowed: true, wantRemaining: 0},
+		{name: "exhausted", wantAllowed: false, wantRemaining: 0},
+		{name: "denied requests do not go negative", wantAllowed: false, wantRemaining: 0},
+	}
+	for _, tt := range tests {
+		res, err := p.Take(ctx, key, rate)
+		if err != nil {
+			t.Fatalf("%s: Take() error = %v", tt.name, err)
+		}
+		if res.Allowed != tt.wantAllowed || res.Remaining != tt.wantRemaining {
+			t.Errorf("%s: Take() = %+v, want allowed=%v remaining=%d", tt.name, res, tt.wantAllowed, tt.wantRemaining)
+		}
+	}
+
+	var row model.RateLimitBucket
+	if err := p.db.First(&row, "key = ?", key).Error; err != nil {
+		t.Fatalf("failed to fetch bucket: %v", err)
+	}
+	if row.Tokens < 0 || row.Tokens >= 1 {
+		t.Errorf("tokens = %g, want in [0, 1)", row.Tokens)
+	}
+}
+
+func TestPostgresTakeRefills(t *testing.T) {
+	p := testPostgres(t)
+	ctx := context.Background()
+	key := testKey(t, p)
+	rate := Rate{Burst: 2, Interval: time.Minute}
+
+	for range rate.Burst {
+		if _, err := p.Take(ctx, key, rate); err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+	}
+	// 1.5 回分の補充に相当する時間を経過させる
+	err := p.db.Model(&model.RateLimitBucket{}).Where("key = ?", key).
+		Update("updated_at", gorm.Expr("now() - interval '90 seconds'")).Error
+	if err != nil {
+		t.Fatalf("failed to rewind bucket: %v", err)
+	}
+
+	res, err := p.Take(ctx, key, rate)
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if !res.Allowed || res.Remaining != 0 {
+		t.Errorf("Take() after refill = %+v, want allowed with 0 remaining", res)
+	}
+}
+
+func TestPostgresTakeConcurrent(t *testing.T) {
+	p := testPostgres(t)
+	ctx := context.Background()
+	key := testKey(t, p)
+	rate := Rate{Burst: 5, Interval: time.Hour}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := p.Take(ctx, key, rate)
+			if err != nil {
+				t.Errorf("Take() error = %v", err)
+				return
+			}
+			if res.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != rate.Burst {
+		t.Errorf("allowed %d of 20 concurrent requests, want %d", allowed, rate.Burst)
+	}
+}
+
+func TestPostgresPrune(t *testing.T) {
+	p := testPostgres(t)
+	stale := testKey(t, p)
+	fresh := testKey(t, p)
+	rate := Rate{Burst: 1, Interval: time.Hour}
+
+	for _, key := range []string{stale, fresh} {
+		if _, err := p.Take(context.Background(), key, rate); err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+	}
+	err := p.db.Model(&model.RateLimitBucket{}).Where("key = ?", stale).
+		Update("updated_at", gorm.Expr("now() - interval '2 hours'")).Error
+	if err != nil {
+		t.Fatalf("failed to rewind bucket: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Prune(ctx, 10*time.Millisecond, time.Hour)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	var keys []string
+	p.db.Model(&model.RateLimitBucket{}).Where("key IN ?", []string{stale, fresh}).Pluck("key", &keys)
+	if len(keys) != 1 || keys[0] != fresh {
+		t.Errorf("remaining buckets = %v, want only %s", keys, fresh)
+	}
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Rate トークンバケットの設定
+// Burst 個まで貯まり、Interval ごとに1個補充される
+type Rate struct {
+	Burst    int
+	Interval time.Duration
+}
+
+// PerMinute 1分あたり n 回、最大 burst 回まで連続して許可する
+func PerMinute(n, burst int) Rate {
+	return Rate{Burst: burst, Interval: time.Minute / time.Duration(n)}
+}
+
+// Result 1回分のトークンを取得した結果
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter 拒否された場合に次のトークンが補充されるまでの時間
+	RetryAfter time.Duration
+	// Reset バケットが満杯に戻るまでの時間
+	Reset time.Duration
+}
+
+// Store キーごとのトークンバケットを保持する
+type Store interface {
+	// Take key のバケットからトークンを1個取得する
+	Take(ctx context.Context, key string, rate Rate) (Result, error)
+}
+
+// newResult 取得後の残りトークン数から結果を組み立てる
+func newResult(allowed bool, tokens float64, rate Rate) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     rate.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     time.Duration((float64(rate.Burst) - tokens) * float64(rate.Interval)),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) * float64(rate.Interval))
+	}
+	return res
+}
@@ -0,0 +1,39 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+)
+
+type reportKey struct{}
+
+// Report 操作ごとに取得した結果を、HTTPのレスポンスヘッダーに書くまで保持する
+type Report struct {
+	mu     sync.Mutex
+	result *Result
+}
+
+// WithReport 以降の Record の結果を Report に残す
+func WithReport(ctx context.Context) (context.Context, *Report) {
+	r := &Report{}
+	return context.WithValue(ctx, reportKey{}, r), r
+}
+
+// Record ctx に Report があれば結果を残す。WebSocket のように Report がない場合は何もしない
+func Record(ctx context.Context, res Result) {
+	if r, ok := ctx.Value(reportKey{}).(*Report); ok {
+		r.mu.Lock()
+		r.result = &res
+		r.mu.Unlock()
+	}
+}
+
+// Result 記録された結果。記録されていない場合は false
+func (r *Report) Result() (Result, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.result == nil {
+		return Result{}, false
+	}
+	return *r.result, true
+}
@@ -0,0 +1,195 @@
+package related
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/worker"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Options struct {
+	// MaxResults 記事ごとに保存する関連記事の数
+	MaxResults int
+	// Candidates スコアを計算する記事の数の上限。公開日時の新しいものから選ぶ
+	Candidates int
+	// TagWeight タグの重なりのスコアの比重。残りを本文の類似度に割り当てる
+	TagWeight float64
+	// MinScore これ未満のスコアの記事は保存しない
+	MinScore float64
+}
+
+// Recommender 関連記事をバックグラウンドで計算し、related_articles に保存する
+type Recommender struct {
+	db   *gorm.DB
+	pool *worker.Pool
+	opts Options
+}
+
+func NewRecommender(db *gorm.DB, pool *worker.Pool, opts Options) *Recommender {
+	return &Recommender{db: db, pool: pool, opts: opts}
+}
+
+// Enqueue 記事の関連記事の計算をバックグラウンドワーカーに積む
+// 関連度は双方向なので、計算後に関連記事として選ばれた記事も計算し直す
+func (r *Recommender) Enqueue(articleID uuid.UUID) error {
+	return r.enqueue(articleID, true)
+}
+
+func (r *Recommender) enqueue(articleID uuid.UUID, cascade bool) error {
+	return r.pool.Enqueue("related.compute:"+articleID.String(), func(ctx context.Context) error {
+		return r.Compute(ctx, articleID, cascade)
+	})
+}
+
+// ResumeStale 関連記事が未計算の公開済みの記事と、計算後に編集された記事を再度キューに積む
+func (r *Recommender) ResumeStale(ctx context.Context) error {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&model.Article{}).
+		Joins("LEFT JOIN related_article_runs ON related_article_runs.article_id = articles.id").
+		Where("articles.published_at IS NOT NULL AND articles.published_at <= ?", time.Now()).
+		Where("related_article_runs.article_id IS NULL OR related_article_runs.article_updated_at < articles.updated_at").
+		Pluck("articles.id", &ids).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch stale related articles: %w", err)
+	}
+	for _, id := range ids {
+		// まとめて計算し直すので、関連記事への波及は不要
+		if err := r.enqueue(id, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Refresh ctx がキャンセルされるまで interval ごとに ResumeStale を呼ぶ
+// 公開日時を予約した記事や、ミューテーションを経由せずに編集された記事を拾う
+func (r *Recommender) Refresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.ResumeStale(ctx); err != nil {
+				log.MustFromContext(ctx).Warn(ctx, "failed to refresh related articles", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Compute 記事の関連記事を計算し直して保存する
+// 未公開の記事は関連記事を表示しないので、保存済みのものを消すだけにする
+func (r *Recommender) Compute(ctx context.Context, articleID uuid.UUID, cascade bool) error {
+	db := r.db.WithContext(ctx)
+
+	var article model.Article
+	if err := db.First(&article, "id = ?", articleID).Error; err != nil {
+		return fmt.Errorf("failed to fetch article %s: %w", articleID, err)
+	}
+	now := time.Now()
+	if article.PublishedAt == nil || article.PublishedAt.After(now) {
+		return db.Where("article_id = ?", articleID).Delete(&model.RelatedArticle{}).Error
+	}
+
+	docs, err := r.documents(db, &article, now)
+	if err != nil {
+		return err
+	}
+	rows := make([]*model.RelatedArticle, 0, r.opts.MaxResults)
+	for _, s := range Rank(articleID, docs, r.opts.TagWeight) {
+		if len(rows) == r.opts.MaxResults || s.Score < r.opts.MinScore {
+			break
+		}
+		rows = append(rows, &model.RelatedArticle{
+			ArticleID: articleID,
+			RelatedID: s.ID,
+			Rank:      len(rows),
+			Score:     s.Score,
+		})
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("article_id = ?", articleID).Delete(&model.RelatedArticle{}).Error; err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			if err := tx.Create(rows).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&model.RelatedArticleRun{
+			ArticleID:        articleID,
+			ArticleUpdatedAt: article.UpdatedAt,
+			ComputedAt:       now,
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save related articles of %s: %w", articleID, err)
+	}
+
+	if cascade {
+		for _, row := range rows {
+			if err := r.enqueue(row.RelatedID, false); err != nil {
+				// 次の Refresh では拾われないが、その記事が次に計算されるまで古い結果を表示するだけ
+				log.MustFromContext(ctx).Warn(ctx, "failed to enqueue related articles",
+					zap.String("article_id", row.RelatedID.String()), zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
+
+// documents 候補の記事とタグを読み込む。article は候補に入っていなくても含める
+func (r *Recommender) documents(db *gorm.DB, article *model.Article, now time.Time) ([]Document, error) {
+	var articles []*model.Article
+	err := db.Select("id", "title", "content").
+		Where("published_at IS NOT NULL AND published_at <= ? AND id <> ?", now, article.ID).
+		Order("published_at desc").
+		Limit(r.opts.Candidates).
+		Find(&articles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch candidate articles: %w", err)
+	}
+	articles = append(articles, article)
+
+	ids := make([]uuid.UUID, 0, len(articles))
+	for _, a := range articles {
+		ids = append(ids, a.ID)
+	}
+	var tags []struct {
+		ArticleID uuid.UUID
+		Name      string
+	}
+	err = db.Table("article_tags").
+		Select("article_tags.article_id, tags.name").
+		Joins("JOIN tags ON tags.id = article_tags.tag_id AND tags.deleted_at IS NULL").
+		Where("article_tags.article_id IN ?", ids).
+		Scan(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags of candidate articles: %w", err)
+	}
+	tagsByArticle := make(map[uuid.UUID][]string, len(articles))
+	for _, t := range tags {
+		tagsByArticle[t.ArticleID] = append(tagsByArticle[t.ArticleID], t.Name)
+	}
+
+	docs := make([]Document, 0, len(articles))
+	for _, a := range articles {
+		docs = append(docs, Document{
+			ID:      a.ID,
+			Title:   a.Title,
+			Content: a.Content,
+			Tags:    tagsByArticle[a.ID],
+		})
+	}
+	return docs, nil
+}
@@ -0,0 +1,208 @@
+package related
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Document スコアの計算に使う記事の内容
+type Document struct {
+	ID      uuid.UUID
+	Title   string
+	Content string
+	Tags    []string
+}
+
+// Scored 関連記事の候補とスコア。スコアは0から1の間
+type Scored struct {
+	ID    uuid.UUID
+	Score float64
+}
+
+// titleWeight タイトルの語を本文の何回分として数えるか
+const titleWeight = 3
+
+// Rank docs の中から target に似た記事をスコアの高い順に返す
+// タグの重なりはIDFで重み付けし、多くの記事に付いているタグほど小さく数える
+// tagWeight をタグのスコアの比重とし、残りを本文のTF-IDFのコサイン類似度に割り当てる
+func Rank(target uuid.UUID, docs []Document, tagWeight float64) []Scored {
+	tagIDF := tagIDF(docs)
+	vectors := termVectors(docs)
+
+	var targetDoc *Document
+	var targetVec map[string]float64
+	for i := range docs {
+		if docs[i].ID == target {
+			targetDoc, targetVec = &docs[i], vectors[i]
+			break
+		}
+	}
+	if targetDoc == nil {
+		return nil
+	}
+
+	scored := make([]Scored, 0, len(docs))
+	for i := range docs {
+		if docs[i].ID == target {
+			continue
+		}
+		score := tagWeight*tagSimilarity(targetDoc.Tags, docs[i].Tags, tagIDF) +
+			(1-tagWeight)*cosine(targetVec, vectors[i])
+		if score > 0 {
+			scored = append(scored, Scored{ID: docs[i].ID, Score: score})
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+	return scored
+}
+
+// tagIDF ln(1 + N/df)。すべての記事に付いているタグでも0にはしない
+func tagIDF(docs []Document) map[string]float64 {
+	df := make(map[string]int)
+	for _, d := range docs {
+		for _, t := range uniqueTags(d.Tags) {
+			df[t]++
+		}
+	}
+	idf := make(map[string]float64, len(df))
+	for t, n := range df {
+		idf[t] = math.Log(1 + float64(len(docs))/float64(n))
+	}
+	return idf
+}
+
+// tagSimilarity IDFで重み付けしたJaccard係数
+func tagSimilarity(a, b []string, idf map[string]float64) float64 {
+	inA := make(map[string]bool, len(a))
+	for _, t := range uniqueTags(a) {
+		inA[t] = true
+	}
+	var shared, union float64
+	for _, t := range uniqueTags(b) {
+		if inA[t] {
+			shared += idf[t]
+			delete(inA, t)
+		}
+		union += idf[t]
+	}
+	for t := range inA {
+		union += idf[t]
+	}
+	if union == 0 {
+		return 0
+	}
+	return shared / union
+}
+
+func uniqueTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(t)
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// termVectors 記事ごとのTF-IDFベクトル。長さ1に正規化する
+// TF は 1 + ln(出現回数)、IDF は ln((1+N)/(1+df)) + 1
+func termVectors(docs []Document) []map[string]float64 {
+	counts := make([]map[string]int, len(docs))
+	df := make(map[string]int)
+	for i, d := range docs {
+		c := make(map[string]int)
+		for _, t := range tokenize(d.Title) {
+			c[t] += titleWeight
+		}
+		for _, t := range tokenize(d.Content) {
+			c[t]++
+		}
+		for t := range c {
+			df[t]++
+		}
+		counts[i] = c
+	}
+
+	n := float64(len(docs))
+	vectors := make([]map[string]float64, len(docs))
+	for i, c := range counts {
+		v := make(map[string]float64, len(c))
+		var norm float64
+		for t, tf := range c {
+			w := (1 + math.Log(float64(tf))) * (math.Log((1+n)/(1+float64(df[t]))) + 1)
+			v[t] = w
+			norm += w * w
+		}
+		// 語のない記事はゼロベクトルのままにして、どの記事とも類似度を0にする
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for t := range v {
+				v[t] /= norm
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+func cosine(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var dot float64
+	for t, w := range a {
+		dot += w * b[t]
+	}
+	return dot
+}
+
+// tokenize 英数字は単語ごとに、漢字とカタカナは分かち書きをせずに2文字ずつ区切る
+// 1文字の英数字とひらがなは助詞などが多く、類似度の役に立たないので除く
+func tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 1 {
+			tokens = append(tokens, string(word))
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Katakana) || r == 'ー':
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) && !unicode.Is(unicode.Hiragana, r), unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
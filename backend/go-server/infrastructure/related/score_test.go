@@ -0,0 +1,229 @@
+package related
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+const epsilon = 1e-9
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "empty", text: "", want: nil},
+		{name: "words are lowercased", text: "Hello, World", want: []string{"hello", "world"}},
+		{name: "single letters are dropped", text: "a go b", want: []string{"go"}},
+		{name: "digits", text: "Go 1.24", want: []string{"go", "24"}},
+		{name: "kanji bigrams", text: "言語処理", want: []string{"言語", "語処", "処理"}},
+		{name: "single kanji is kept", text: "本", want: []string{"本"}},
+		{name: "katakana with long vowel mark", text: "サーバー", want: []string{"サー", "ーバ", "バー"}},
+		{name: "hiragana splits and is dropped", text: "東京の天気", want: []string{"東京", "天気"}},
+		{name: "mixed scripts", text: "Go言語入門", want: []string{"go", "言語", "語入", "入門"}},
+		{name: "digits end a cjk run", text: "2024年版", want: []string{"2024", "年版"}},
+		{name: "only hiragana and punctuation", text: "これは、です。", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagIDF(t *testing.T) {
+	docs := []Document{
+		{Tags: []string{"Go", "go", "rust"}},
+		{Tags: []string{"go"}},
+		{Tags: []string{"go", "web"}},
+		{},
+	}
+	idf := tagIDF(docs)
+
+	tests := []struct {
+		tag  string
+		want float64
+	}{
+		{tag: "go", want: math.Log(1 + 4.0/3)},
+		{tag: "rust", want: math.Log(1 + 4.0/1)},
+		{tag: "web", want: math.Log(1 + 4.0/1)},
+	}
+	for _, tt := range tests {
+		if got := idf[tt.tag]; math.Abs(got-tt.want) > epsilon {
+			t.Errorf("idf[%q] = %g, want %g", tt.tag, got, tt.want)
+		}
+	}
+	if len(idf) != len(tests) {
+		t.Errorf("idf has %d tags, want %d: %v", len(idf), len(tests), idf)
+	}
+	if idf["go"] >= idf["rust"] {
+		t.Errorf("common tag weight %g should be below rare tag weight %g", idf["go"], idf["rust"])
+	}
+
+	if got := tagIDF(nil); len(got) != 0 {
+		t.Errorf("tagIDF(nil) = %v, want empty", got)
+	}
+}
+
+func TestTagSimilarity(t *testing.T) {
+	idf := map[string]float64{"go": 1, "rust": 3, "web": 2}
+	tests := []struct {
+		name string
+		a, b []string
+		want float64
+	}{
+		{name: "both empty", want: 0},
+		{name: "one empty", a: []string{"go"}, want: 0},
+		{name: "identical", a: []string{"go", "rust"}, b: []string{"rust", "go"}, want: 1},
+		{name: "case and duplicates are ignored", a: []string{"Go", "go"}, b: []string{"GO"}, want: 1},
+		{name: "disjoint", a: []string{"go"}, b: []string{"rust"}, want: 0},
+		{name: "sharing a common tag", a: []string{"go", "rust"}, b: []string{"go", "web"}, want: 1.0 / 6},
+		{name: "sharing a rare tag", a: []string{"go", "rust"}, b: []string{"rust", "web"}, want: 3.0 / 6},
+		{name: "unknown tags weigh nothing", a: []string{"go", "unknown"}, b: []string{"go"}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tagSimilarity(tt.a, tt.b, idf)
+			if math.Abs(got-tt.want) > epsilon {
+				t.Errorf("tagSimilarity(%q, %q) = %g, want %g", tt.a, tt.b, got, tt.want)
+			}
+			if rev := tagSimilarity(tt.b, tt.a, idf); math.Abs(rev-got) > epsilon {
+				t.Errorf("tagSimilarity is not symmetric: %g and %g", got, rev)
+			}
+		})
+	}
+}
+
+func TestTermVectors(t *testing.T) {
+	docs := []Document{
+		{Title: "Go", Content: "go channels and goroutines"},
+		{Content: "rust ownership"},
+		{},
+		{Title: "の", Content: "これは、です。"},
+	}
+	vectors := termVectors(docs)
+	if len(vectors) != len(docs) {
+		t.Fatalf("got %d vectors, want %d", len(vectors), len(docs))
+	}
+
+	tests := []struct {
+		name     string
+		index    int
+		wantNorm float64
+	}{
+		{name: "normalized", index: 0, wantNorm: 1},
+		{name: "content only", index: 1, wantNorm: 1},
+		{name: "empty document", index: 2, wantNorm: 0},
+		{name: "no usable tokens", index: 3, wantNorm: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var norm float64
+			for term, w := range vectors[tt.index] {
+				if math.IsNaN(w) || math.IsInf(w, 0) {
+					t.Fatalf("weight of %q = %g", term, w)
+				}
+				norm += w * w
+			}
+			if math.Abs(math.Sqrt(norm)-tt.wantNorm) > epsilon {
+				t.Errorf("norm = %g, want %g", math.Sqrt(norm), tt.wantNorm)
+			}
+		})
+	}
+
+	// タイトルの語は本文より重く、ほかの記事に出る語より固有の語が重い
+	v := vectors[0]
+	if v["go"] <= v["channels"] {
+		t.Errorf("title term weight %g should exceed content term weight %g", v["go"], v["channels"])
+	}
+	if got := cosine(vectors[0], vectors[2]); got != 0 {
+		t.Errorf("cosine with an empty document = %g, want 0", got)
+	}
+}
+
+func TestRank(t *testing.T) {
+	var (
+		target  = uuid.New()
+		rare    = uuid.New()
+		common  = uuid.New()
+		content = uuid.New()
+		none    = uuid.New()
+		empty   = uuid.New()
+	)
+	docs := []Document{
+		{ID: target, Title: "Kubernetes operators", Content: "writing a kubernetes operator in go", Tags: []string{"go", "kubernetes"}},
+		{ID: rare, Title: "Helm charts", Content: "packaging applications", Tags: []string{"kubernetes"}},
+		{ID: common, Title: "Generics", Content: "type parameters", Tags: []string{"go"}},
+		{ID: content, Title: "Kubernetes operator patterns", Content: "reconcile loops for an operator"},
+		{ID: none, Title: "Baking bread", Content: "flour water salt", Tags: []string{"cooking"}},
+		{ID: empty},
+		{Title: "filler", Content: "more", Tags: []string{"go"}},
+		{Title: "filler", Content: "more", Tags: []string{"go"}},
+	}
+
+	tests := []struct {
+		name      string
+		target    uuid.UUID
+		tagWeight float64
+		wantOrder []uuid.UUID
+	}{
+		{
+			name:      "tags only ranks the rare shared tag first",
+			target:    target,
+			tagWeight: 1,
+			wantOrder: []uuid.UUID{rare, common},
+		},
+		{
+			name:      "content only ranks the similar text first",
+			target:    target,
+			tagWeight: 0,
+			wantOrder: []uuid.UUID{content},
+		},
+		{
+			name:      "empty target has nothing in common",
+			target:    empty,
+			tagWeight: 0.5,
+		},
+		{
+			name:      "unknown target",
+			target:    uuid.New(),
+			tagWeight: 0.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Rank(tt.target, docs, tt.tagWeight)
+			if len(got) < len(tt.wantOrder) {
+				t.Fatalf("Rank() returned %d results, want at least %d: %+v", len(got), len(tt.wantOrder), got)
+			}
+			for i, id := range tt.wantOrder {
+				if got[i].ID != id {
+					t.Errorf("result %d = %s, want %s", i, got[i].ID, id)
+				}
+			}
+			for i, s := range got {
+				if s.ID == tt.target {
+					t.Errorf("target is ranked against itself")
+				}
+				if s.ID == none || s.ID == empty {
+					t.Errorf("unrelated document %s is ranked with score %g", s.ID, s.Score)
+				}
+				if !(s.Score > 0 && s.Score <= 1+epsilon) {
+					t.Errorf("score %g is outside (0, 1]", s.Score)
+				}
+				if i > 0 && got[i-1].Score < s.Score {
+					t.Errorf("results are not sorted by score: %g before %g", got[i-1].Score, s.Score)
+				}
+			}
+			if tt.wantOrder == nil && len(got) != 0 {
+				t.Errorf("Rank() = %+v, want no results", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package spam
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/s-blog/backend/go-server/domain/model"
+	"gorm.io/gorm"
+)
+
+// Honeypot ボットが埋めがちな非表示の入力欄に値があれば疑う
+type Honeypot struct{}
+
+func (Honeypot) Check(_ context.Context, _ *gorm.DB, c *Candidate) (string, error) {
+	if strings.TrimSpace(c.Honeypot) != "" {
+		return "honeypot field filled", nil
+	}
+	return "", nil
+}
+
+var linkPattern = regexp.MustCompile(`(?i)https?://|www\.`)
+
+// LinkLimit リンクが Max 個を超えるコメントを疑う
+type LinkLimit struct {
+	Max int
+}
+
+func (l LinkLimit) Check(_ context.Context, _ *gorm.DB, c *Candidate) (string, error) {
+	if len(linkPattern.FindAllStringIndex(c.Content, -1)) > l.Max {
+		return "too many links", nil
+	}
+	return "", nil
+}
+
+// BannedWords banned_words テーブルの語句を含むコメントを疑う
+type BannedWords struct{}
+
+func (BannedWords) Check(ctx context.Context, db *gorm.DB, c *Candidate) (string, error) {
+	var count int64
+	err := db.WithContext(ctx).Model(&model.BannedWord{}).
+		Where("strpos(?, word) > 0", strings.ToLower(c.Content)).
+		Limit(1).
+		Count(&count).Error
+	if err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return "contains banned word", nil
+	}
+	return "", nil
+}
+
+// Duplicate 同じユーザーまたはIPから Window 以内に同じ内容が投稿されていれば疑う
+type Duplicate struct {
+	Window time.Duration
+}
+
+func (d Duplicate) Check(ctx context.Context, db *gorm.DB, c *Candidate) (string, error) {
+	q := db.WithContext(ctx).Model(&model.Comment{}).
+		Where("content = ? AND created_at > ?", c.Content, time.Now().Add(-d.Window))
+	if c.IP != "" {
+		q = q.Where("(user_id = ? OR ip_address = ?)", c.UserID, c.IP)
+	} else {
+		q = q.Where("user_id = ?", c.UserID)
+	}
+	var count int64
+	if err := q.Limit(1).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return "duplicate content", nil
+	}
+	return "", nil
+}
+
+// Velocity Window 以内の投稿数がユーザーごと・IPごとの上限に達していれば疑う
+// 上限が0以下の場合はその条件を使わない
+type Velocity struct {
+	Window     time.Duration
+	MaxPerUser int
+	MaxPerIP   int
+}
+
+func (v Velocity) Check(ctx context.Context, db *gorm.DB, c *Candidate) (string, error) {
+	since := time.Now().Add(-v.Window)
+	if v.MaxPerUser > 0 {
+		n, err := countSince(ctx, db, "user_id = ?", c.UserID, since)
+		if err != nil {
+			return "", err
+		}
+		if n >= int64(v.MaxPerUser) {
+			return "too many comments from user", nil
+		}
+	}
+	if v.MaxPerIP > 0 && c.IP != "" {
+		n, err := countSince(ctx, db, "ip_address = ?", c.IP, since)
+		if err != nil {
+			return "", err
+		}
+		if n >= int64(v.MaxPerIP) {
+			return "too many comments from IP address", nil
+		}
+	}
+	return "", nil
+}
+
+func countSince(ctx context.Context, db *gorm.DB, cond string, value any, since time.Time) (int64, error) {
+	var count int64
+	err := db.WithContext(ctx).Model(&model.Comment{}).
+		Where(cond, value).
+		Where("created_at > ?", since).
+		Count(&count).Error
+	return count, err
+}
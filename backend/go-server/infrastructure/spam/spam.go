@@ -0,0 +1,48 @@
+package spam
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Candidate 保存前のコメント
+type Candidate struct {
+	Content string
+	UserID  uuid.UUID
+	// IP 取得できない場合は空
+	IP string
+	// Honeypot 画面に表示しない入力欄の値。人間が入力することはない
+	Honeypot string
+}
+
+// Checker コメントがスパムの疑いがあるかを判定する
+// 疑いがある場合はその理由を、問題がない場合は空文字を返す
+type Checker interface {
+	Check(ctx context.Context, db *gorm.DB, c *Candidate) (string, error)
+}
+
+// Chain 複数のCheckerを順に実行する
+type Chain struct {
+	checkers []Checker
+}
+
+func NewChain(checkers ...Checker) *Chain {
+	return &Chain{checkers: checkers}
+}
+
+// Check 最初に疑いありと判定したCheckerの理由を返す
+// db はコメントを保存するトランザクションを渡す
+func (c *Chain) Check(ctx context.Context, db *gorm.DB, candidate *Candidate) (string, error) {
+	for _, checker := range c.checkers {
+		reason, err := checker.Check(ctx, db, candidate)
+		if err != nil {
+			return "", err
+		}
+		if reason != "" {
+			return reason, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,392 @@
+package spam
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestHoneypot(t *testing.T) {
+	tests := []struct {
+		honeypot string
+		want     bool
+	}{
+		{honeypot: "", want: false},
+		{honeypot: "  \n", want: false},
+		{honeypot: "http://spam.example.com", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.honeypot, func(t *testing.T) {
+			reason, err := Honeypot{}.Check(context.Background(), nil, &Candidate{Honeypot: tt.honeypot})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (reason != "") != tt.want {
+				t.Errorf("Check() = %q, want suspicious %v", reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "no links", content: "良い記事でした", want: false},
+		{name: "at the limit", content: "https://a.example.com と http://b.example.com", want: false},
+		{name: "over the limit", content: "https://a.example.com http://b.example.com www.c.example.com", want: true},
+		{name: "case insensitive", content: "HTTPS://a HTTP://b WWW.c", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := LinkLimit{Max: 2}.Check(context.Background(), nil, &Candidate{Content: tt.content})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (reason != "") != tt.want {
+				t.Errorf("Check() = %q, want suspicious %v", reason, tt.want)
+			}
+		})
+	}
+}
+
+func TestBannedWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int64
+		want  bool
+	}{
+		{name: "no banned word", count: 0, want: false},
+		{name: "banned word", count: 1, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, f := openCountDB(t)
+			f.counts[`FROM "banned_words"`] = tt.count
+			reason, err := BannedWords{}.Check(context.Background(), db, &Candidate{Content: "Buy CHEAP Pills"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (reason != "") != tt.want {
+				t.Errorf("Check() = %q, want suspicious %v", reason, tt.want)
+			}
+			// 語句は小文字で保存しているので、本文も小文字にして比べる
+			if q := f.last(); len(q.args) == 0 || q.args[0] != "buy cheap pills" {
+				t.Errorf("query args = %v, want the lowercased content first", q.args)
+			}
+		})
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name   string
+		ip     string
+		count  int64
+		want   bool
+		wantIP bool
+	}{
+		{name: "first post", ip: "192.0.2.1", count: 0, want: false, wantIP: true},
+		{name: "same content from the user or IP", ip: "192.0.2.1", count: 1, want: true, wantIP: true},
+		{name: "unknown IP only checks the user", count: 1, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, f := openCountDB(t)
+			f.counts[`FROM "comments"`] = tt.count
+			reason, err := Duplicate{Window: time.Hour}.Check(context.Background(), db,
+				&Candidate{Content: "same", UserID: userID, IP: tt.ip})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (reason != "") != tt.want {
+				t.Errorf("Check() = %q, want suspicious %v", reason, tt.want)
+			}
+			q := f.last()
+			if got := strings.Contains(q.sql, "ip_address"); got != tt.wantIP {
+				t.Errorf("query %q checks the IP = %v, want %v", q.sql, got, tt.wantIP)
+			}
+			assertSince(t, q, time.Hour)
+		})
+	}
+}
+
+func TestVelocity(t *testing.T) {
+	tests := []struct {
+		name      string
+		velocity  Velocity
+		ip        string
+		userCount int64
+		ipCount   int64
+		want      string
+		// wantQueries user_id と ip_address のどちらで数えたか
+		wantQueries []string
+	}{
+		{
+			name:        "under both limits",
+			velocity:    Velocity{Window: time.Minute, MaxPerUser: 3, MaxPerIP: 5},
+			ip:          "192.0.2.1",
+			userCount:   2,
+			ipCount:     4,
+			wantQueries: []string{"user_id", "ip_address"},
+		},
+		{
+			name:        "user limit reached",
+			velocity:    Velocity{Window: time.Minute, MaxPerUser: 3, MaxPerIP: 5},
+			ip:          "192.0.2.1",
+			userCount:   3,
+			want:        "too many comments from user",
+			wantQueries: []string{"user_id"},
+		},
+		{
+			name:        "IP limit reached",
+			velocity:    Velocity{Window: time.Minute, MaxPerUser: 3, MaxPerIP: 5},
+			ip:          "192.0.2.1",
+			userCount:   0,
+			ipCount:     5,
+			want:        "too many comments from IP address",
+			wantQueries: []string{"user_id", "ip_address"},
+		},
+		{
+			name:        "unknown IP is not counted",
+			velocity:    Velocity{Window: time.Minute, MaxPerUser: 3, MaxPerIP: 5},
+			ipCount:     100,
+			wantQueries: []string{"user_id"},
+		},
+		{
+			name:        "zero limits are disabled",
+			velocity:    Velocity{Window: time.Minute},
+			ip:          "192.0.2.1",
+			userCount:   100,
+			ipCount:     100,
+			wantQueries: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, f := openCountDB(t)
+			f.counts[`user_id = `] = tt.userCount
+			f.counts[`ip_address = `] = tt.ipCount
+			reason, err := tt.velocity.Check(context.Background(), db, &Candidate{UserID: uuid.New(), IP: tt.ip})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reason != tt.want {
+				t.Errorf("Check() = %q, want %q", reason, tt.want)
+			}
+			if len(f.queries) != len(tt.wantQueries) {
+				t.Fatalf("ran %d queries, want %d", len(f.queries), len(tt.wantQueries))
+			}
+			for i, col := range tt.wantQueries {
+				if !strings.Contains(f.queries[i].sql, col) {
+					t.Errorf("query %d = %q, want it to count by %s", i, f.queries[i].sql, col)
+				}
+				assertSince(t, f.queries[i], tt.velocity.Window)
+			}
+		})
+	}
+}
+
+// assertSince 問い合わせが window 前からの投稿だけを数えているか
+func assertSince(t *testing.T, q countQuery, window time.Duration) {
+	t.Helper()
+	for _, a := range q.args {
+		if since, ok := a.(time.Time); ok {
+			if d := time.Since(since); d < window || d > window+time.Minute {
+				t.Errorf("counted comments since %s ago, want %s", d, window)
+			}
+			return
+		}
+	}
+	t.Errorf("query %q has no time bound", q.sql)
+}
+
+// stubChecker 決められた結果を返し、呼ばれた順番を記録する
+type stubChecker struct {
+	name   string
+	reason string
+	err    error
+	calls  *[]string
+}
+
+func (s stubChecker) Check(context.Context, *gorm.DB, *Candidate) (string, error) {
+	*s.calls = append(*s.calls, s.name)
+	return s.reason, s.err
+}
+
+func TestChain(t *testing.T) {
+	errDB := errors.New("db down")
+	tests := []struct {
+		name       string
+		checkers   []stubChecker
+		want       string
+		wantErr    error
+		wantCalled string
+	}{
+		{
+			name:       "all pass",
+			checkers:   []stubChecker{{name: "a"}, {name: "b"}, {name: "c"}},
+			wantCalled: "a b c",
+		},
+		{
+			name:       "first suspicious check wins",
+			checkers:   []stubChecker{{name: "a"}, {name: "b", reason: "from b"}, {name: "c", reason: "from c"}},
+			want:       "from b",
+			wantCalled: "a b",
+		},
+		{
+			name:       "error stops the chain",
+			checkers:   []stubChecker{{name: "a", err: errDB}, {name: "b", reason: "from b"}},
+			wantErr:    errDB,
+			wantCalled: "a",
+		},
+		{
+			name: "empty chain",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			checkers := make([]Checker, 0, len(tt.checkers))
+			for _, c := range tt.checkers {
+				c.calls = &calls
+				checkers = append(checkers, c)
+			}
+			reason, err := NewChain(checkers...).Check(context.Background(), nil, &Candidate{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+			if reason != tt.want {
+				t.Errorf("Check() = %q, want %q", reason, tt.want)
+			}
+			if got := strings.Join(calls, " "); got != tt.wantCalled {
+				t.Errorf("called %q, want %q", got, tt.wantCalled)
+			}
+		})
+	}
+}
+
+// openCountDB COUNT の問い合わせに、SQLに含まれる文字列ごとに決めた件数を返す
+func openCountDB(t *testing.T) (*gorm.DB, *countDB) {
+	t.Helper()
+	f := &countDB{counts: map[string]int64{}}
+	sqlDB := sql.OpenDB(f)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+	return db, f
+}
+
+type countDB struct {
+	mu      sync.Mutex
+	counts  map[string]int64
+	queries []countQuery
+}
+
+type countQuery struct {
+	sql  string
+	args []any
+}
+
+func (f *countDB) last() countQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.queries) == 0 {
+		return countQuery{}
+	}
+	return f.queries[len(f.queries)-1]
+}
+
+func (f *countDB) Connect(context.Context) (driver.Conn, error) {
+	return countConn{f}, nil
+}
+
+func (f *countDB) Driver() driver.Driver {
+	return countDriver{}
+}
+
+type countDriver struct{}
+
+func (countDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("use the connector")
+}
+
+type countConn struct {
+	db *countDB
+}
+
+func (c countConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c countConn) Close() error {
+	return nil
+}
+
+func (c countConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+var countPattern = regexp.MustCompile(`(?i)^SELECT count\(`)
+
+func (c countConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !countPattern.MatchString(query) {
+		return nil, errors.New("unexpected query: " + query)
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	q := countQuery{sql: query}
+	for _, a := range args {
+		q.args = append(q.args, a.Value)
+	}
+	c.db.queries = append(c.db.queries, q)
+	var n int64
+	for substr, count := range c.db.counts {
+		if strings.Contains(query, substr) {
+			n = count
+		}
+	}
+	return &countRows{n: n}, nil
+}
+
+type countRows struct {
+	n    int64
+	done bool
+}
+
+func (r *countRows) Columns() []string {
+	return []string{"count"}
+}
+
+func (r *countRows) Close() error {
+	return nil
+}
+
+func (r *countRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	dest[0] = r.n
+	r.done = true
+	return nil
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage アップロードされたファイルの保存先
+type Storage interface {
+	// Put はkeyにオブジェクトを保存する。同じkeyが存在する場合は上書きする
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get はオブジェクトを取得する。存在しない場合はErrNotFoundを返す
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// URL はクライアントに返す公開URLを組み立てる
+	URL(key string) string
+}
+
+func joinURL(base, key string) string {
+	if base == "" {
+		return "/" + key
+	}
+	if base[len(base)-1] == '/' {
+		return base + key
+	}
+	return base + "/" + key
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStorage Memory と Local が同じ振る舞いをすることを確認する
+func TestStorage(t *testing.T) {
+	backends := []struct {
+		name string
+		new  func(t *testing.T) Storage
+	}{
+		{name: "memory", new: func(t *testing.T) Storage { return NewMemory("") }},
+		{name: "local", new: func(t *testing.T) Storage {
+			l, err := NewLocal(filepath.Join(t.TempDir(), "media"), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return l
+		}},
+	}
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := b.new(t)
+			key := "media/ab/abcdef.png"
+
+			if ok, err := st.Exists(ctx, key); err != nil || ok {
+				t.Fatalf("Exists() before Put = %v, %v, want false", ok, err)
+			}
+			if _, err := st.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get() before Put error = %v, want ErrNotFound", err)
+			}
+
+			put := func(content string) {
+				t.Helper()
+				if err := st.Put(ctx, key, strings.NewReader(content), int64(len(content)), "image/png"); err != nil {
+					t.Fatalf("Put() error = %v", err)
+				}
+			}
+			get := func() string {
+				t.Helper()
+				rc, err := st.Get(ctx, key)
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				defer rc.Close()
+				b, err := io.ReadAll(rc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return string(b)
+			}
+
+			put("first")
+			if ok, err := st.Exists(ctx, key); err != nil || !ok {
+				t.Fatalf("Exists() after Put = %v, %v, want true", ok, err)
+			}
+			if got := get(); got != "first" {
+				t.Errorf("Get() = %q, want %q", got, "first")
+			}
+
+			put("second")
+			if got := get(); got != "second" {
+				t.Errorf("Get() after overwrite = %q, want %q", got, "second")
+			}
+
+			if err := st.Delete(ctx, key); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if ok, err := st.Exists(ctx, key); err != nil || ok {
+				t.Fatalf("Exists() after Delete = %v, %v, want false", ok, err)
+			}
+			if err := st.Delete(ctx, key); err != nil {
+				t.Errorf("Delete() of a missing key error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLocal(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "media/ab/file.png", want: filepath.Join(dir, "media", "ab", "file.png")},
+		{key: "/media/file.png", want: filepath.Join(dir, "media", "file.png")},
+		{key: "../../etc/passwd", want: filepath.Join(dir, "etc", "passwd")},
+		{key: "media/../../../secret", want: filepath.Join(dir, "secret")},
+		{key: "", wantErr: true},
+		{key: "..", wantErr: true},
+		{key: "/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := l.path(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("path(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("path(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalPutLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLocal(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := l.Put(ctx, "a/b.txt", strings.NewReader("x"), 1, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	// 読み込みに失敗した場合も書きかけのファイルを残さない
+	if err := l.Put(ctx, "a/c.txt", errReader{}, 1, "text/plain"); err == nil {
+		t.Fatal("Put() with a failing reader succeeded")
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 1 || names[0] != "b.txt" {
+		t.Errorf("files = %q, want only b.txt", names)
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		base string
+		want string
+	}{
+		{base: "", want: "/media/a.png"},
+		{base: "http://localhost:8080/media", want: "http://localhost:8080/media/media/a.png"},
+		{base: "https://cdn.example.com/", want: "https://cdn.example.com/media/a.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.base, func(t *testing.T) {
+			if got := NewMemory(tt.base).URL("media/a.png"); got != tt.want {
+				t.Errorf("Memory.URL() = %q, want %q", got, tt.want)
+			}
+			l, err := NewLocal(t.TempDir(), tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := l.URL("media/a.png"); got != tt.want {
+				t.Errorf("Local.URL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
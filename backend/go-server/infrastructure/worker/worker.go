@@ -0,0 +1,120 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrQueueFull = errors.New("worker: queue is full")
+	ErrStopped   = errors.New("worker: pool is stopped")
+)
+
+// Job バックグラウンドで実行する処理
+type Job func(ctx context.Context) error
+
+type task struct {
+	name string
+	job  Job
+}
+
+// Pool インプロセスのジョブキューと、それを処理するゴルーチンの集まり
+type Pool struct {
+	logger  *log.Logger
+	workers int
+	tasks   chan task
+
+	mu      sync.RWMutex
+	stopped bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewPool(logger *log.Logger, workers, queueSize int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Pool{
+		logger:  logger,
+		workers: workers,
+		tasks:   make(chan task, queueSize),
+	}
+}
+
+// Start ワーカーを起動する。ctxはジョブに引き継がれる
+func (p *Pool) Start(ctx context.Context) {
+	ctx, p.cancel = context.WithCancel(ctx)
+	ctx = log.WithContext(ctx, p.logger)
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for t := range p.tasks {
+				p.run(ctx, t)
+			}
+		}()
+	}
+}
+
+// Enqueue ジョブをキューに積む。キューが満杯の場合はブロックせずにErrQueueFullを返す
+func (p *Pool) Enqueue(name string, job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return ErrStopped
+	}
+	select {
+	case p.tasks <- task{name: name, job: job}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Stop 新しいジョブの受付を止め、キューに残ったジョブが終わるまで待つ
+// ctxがキャンセルされた場合は実行中のジョブもキャンセルする
+func (p *Pool) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return nil
+	}
+	p.stopped = true
+	close(p.tasks)
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		if p.cancel != nil {
+			p.cancel()
+		}
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) run(ctx context.Context, t task) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error(ctx, "worker job panicked", zap.String("job", t.name), zap.Error(fmt.Errorf("%v", r)))
+		}
+	}()
+	if err := t.job(ctx); err != nil {
+		p.logger.Error(ctx, "worker job failed", zap.String("job", t.name), zap.Error(err))
+		return
+	}
+	p.logger.Debug(ctx, "worker job finished", zap.String("job", t.name))
+}
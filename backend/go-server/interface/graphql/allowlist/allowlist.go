@@ -0,0 +1,171 @@
+package allowlist
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+
+	"go.uber.org/zap"
+)
+
+const errNotAllowed = "OPERATION_NOT_ALLOWED"
+
+// defaultManifest フロントエンドのクエリから `make allowlist-generate` で生成したマニフェスト
+//
+//go:embed operations.json
+var defaultManifest []byte
+
+// Manifest 実行を許可する操作の一覧
+type Manifest struct {
+	Operations []Operation `json:"operations"`
+}
+
+type Operation struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+	// Source 操作を定義しているファイル
+	Source string `json:"source,omitempty"`
+}
+
+// Allowlist マニフェストにある操作だけを許可する
+// 空白や改行の違い、クライアントが自動で付ける __typename は区別しない
+type Allowlist struct {
+	allowed map[string]string
+}
+
+// Load path が空の場合は埋め込みのマニフェストを使う
+func Load(path string) (*Allowlist, error) {
+	m, err := LoadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(m)
+}
+
+// LoadManifest path が空の場合は埋め込みのマニフェストを返す
+func LoadManifest(path string) (Manifest, error) {
+	b := defaultManifest
+	if path != "" {
+		var err error
+		if b, err = os.ReadFile(path); err != nil {
+			return Manifest{}, err
+		}
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return Manifest{}, fmt.Errorf("invalid allowlist manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Names マニフェストにある操作の名前
+func (m Manifest) Names() []string {
+	names := make([]string, 0, len(m.Operations))
+	for _, op := range m.Operations {
+		names = append(names, op.Name)
+	}
+	return names
+}
+
+func New(m Manifest) (*Allowlist, error) {
+	a := &Allowlist{allowed: make(map[string]string, len(m.Operations))}
+	for _, op := range m.Operations {
+		key, err := Canonical(op.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation %q in allowlist: %w", op.Name, err)
+		}
+		a.allowed[key] = op.Name
+	}
+	return a, nil
+}
+
+// Allows query がマニフェストのいずれかの操作と一致するか
+func (a *Allowlist) Allows(query string) bool {
+	key, err := Canonical(query)
+	if err != nil {
+		return false
+	}
+	_, ok := a.allowed[key]
+	return ok
+}
+
+func (a *Allowlist) Len() int {
+	return len(a.allowed)
+}
+
+// Canonical クエリを比較用の正規形にする
+func Canonical(query string) (string, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return "", err
+	}
+	for _, op := range doc.Operations {
+		op.SelectionSet = withoutTypename(op.SelectionSet)
+	}
+	for _, f := range doc.Fragments {
+		f.SelectionSet = withoutTypename(f.SelectionSet)
+	}
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf, formatter.WithCompacted()).FormatQueryDocument(doc)
+	return buf.String(), nil
+}
+
+func withoutTypename(set ast.SelectionSet) ast.SelectionSet {
+	out := make(ast.SelectionSet, 0, len(set))
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if sel.Name == "__typename" && sel.Alias == sel.Name {
+				continue
+			}
+			sel.SelectionSet = withoutTypename(sel.SelectionSet)
+		case *ast.InlineFragment:
+			sel.SelectionSet = withoutTypename(sel.SelectionSet)
+		}
+		out = append(out, sel)
+	}
+	return out
+}
+
+// Extension 許可リストにない操作を実行前に拒否する
+type Extension struct {
+	Allowlist *Allowlist
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = Extension{}
+
+func (Extension) ExtensionName() string {
+	return "OperationAllowlist"
+}
+
+func (e Extension) Validate(graphql.ExecutableSchema) error {
+	if e.Allowlist == nil {
+		return fmt.Errorf("allowlist: Allowlist can not be nil")
+	}
+	return nil
+}
+
+func (e Extension) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if e.Allowlist.Allows(opCtx.RawQuery) {
+		return nil
+	}
+	log.MustFromContext(ctx).Warn(ctx, "rejected graphql operation: not in allowlist",
+		zap.String("operation", opCtx.OperationName), zap.String("query", opCtx.RawQuery))
+	err := gqlerror.Errorf("operation is not allowed")
+	errcode.Set(err, errNotAllowed)
+	return err
+}
@@ -0,0 +1,37 @@
+package allowlist
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+var gqlTemplate = regexp.MustCompile("gql`([^`]*)`")
+
+// Extract TypeScriptのソースから gql タグ付きテンプレートの操作を取り出す
+// テンプレート内の ${...} (フラグメントの埋め込みなど) には対応していない
+func Extract(source, filename string) ([]Operation, error) {
+	var ops []Operation
+	for _, m := range gqlTemplate.FindAllStringSubmatch(source, -1) {
+		query := strings.TrimSpace(m[1])
+		if strings.Contains(query, "${") {
+			return nil, fmt.Errorf("%s: interpolated gql templates are not supported", filename)
+		}
+		doc, err := parser.ParseQuery(&ast.Source{Name: filename, Input: query})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		if len(doc.Operations) != 1 || doc.Operations[0].Name == "" {
+			return nil, fmt.Errorf("%s: each gql template must define exactly one named operation", filename)
+		}
+		ops = append(ops, Operation{
+			Name:   doc.Operations[0].Name,
+			Query:  query,
+			Source: filename,
+		})
+	}
+	return ops, nil
+}
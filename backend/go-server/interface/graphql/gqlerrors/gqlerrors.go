@@ -0,0 +1,87 @@
+package gqlerrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/morikuni/failure"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"go.uber.org/zap"
+)
+
+const codeInternal = "INTERNAL"
+
+// extensionCodes ドメインのエラーコードと extensions.code の対応
+var extensionCodes = map[failure.Code]string{
+	domainerrors.CodeNotFound:        "NOT_FOUND",
+	domainerrors.CodeInvalidArgument: "INVALID_ARGUMENT",
+	domainerrors.CodeUnauthenticated: "UNAUTHENTICATED",
+	domainerrors.CodeForbidden:       "FORBIDDEN",
+	domainerrors.CodeConflict:        "CONFLICT",
+	domainerrors.CodeRateLimited:     "RATE_LIMITED",
+	domainerrors.CodeInternal:        codeInternal,
+}
+
+// Presenter ドメインのエラーコードを extensions.code に載せる
+// コードのないエラーは想定外のものとしてログに出力し、クライアントには詳細を返さない
+// logger は ctx にロガーがない場合に使う
+func Presenter(logger *log.Logger) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		var gqlErr *gqlerror.Error
+		if !errors.As(err, &gqlErr) {
+			gqlErr = gqlerror.WrapPath(graphql.GetPath(ctx), err)
+		}
+		// パースやバリデーション、拡張が作ったエラーはクライアント向けなのでそのまま返す
+		if gqlErr.Err == nil {
+			return gqlErr
+		}
+
+		if c, ok := failure.CodeOf(err); ok {
+			if code, ok := extensionCodes[c]; ok {
+				return present(gqlErr, gqlErr.Err.Error(), code)
+			}
+		}
+		fromContext(ctx, logger).Error(ctx, "unexpected graphql error",
+			zap.String("path", gqlErr.Path.String()), zap.Error(gqlErr.Err))
+		return present(gqlErr, domainerrors.ErrInternal.Error(), codeInternal)
+	}
+}
+
+// Recover リゾルバーのパニックをスタックトレース付きでログに出力し、内部エラーとして返す
+func Recover(logger *log.Logger) graphql.RecoverFunc {
+	return func(ctx context.Context, p any) error {
+		fields := []zap.Field{zap.String("panic", fmt.Sprint(p)), zap.Stack("stack")}
+		if fc := graphql.GetFieldContext(ctx); fc != nil {
+			fields = append(fields, zap.String("path", fc.Path().String()))
+		}
+		fromContext(ctx, logger).Error(ctx, "recovered from panic in graphql resolver", fields...)
+		return domainerrors.ErrInternal
+	}
+}
+
+func present(gqlErr *gqlerror.Error, message, code string) *gqlerror.Error {
+	extensions := maps.Clone(gqlErr.Extensions)
+	if extensions == nil {
+		extensions = map[string]any{}
+	}
+	extensions["code"] = code
+	return &gqlerror.Error{
+		Message:    message,
+		Path:       gqlErr.Path,
+		Locations:  gqlErr.Locations,
+		Extensions: extensions,
+	}
+}
+
+func fromContext(ctx context.Context, fallback *log.Logger) *log.Logger {
+	if l, ok := log.FromContext(ctx); ok {
+		return l
+	}
+	return fallback
+}
@@ -0,0 +1,140 @@
+package gqlerrors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"gorm.io/gorm"
+)
+
+func TestPresenter(t *testing.T) {
+	path := ast.Path{ast.PathName("article"), ast.PathName("comments"), ast.PathIndex(0)}
+
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+		wantLogged  bool
+	}{
+		{name: "not found", err: domainerrors.NotFound("article not found"), wantCode: "NOT_FOUND", wantMessage: "article not found"},
+		{name: "invalid argument", err: domainerrors.InvalidArgument("invalid ID"), wantCode: "INVALID_ARGUMENT", wantMessage: "invalid ID"},
+		{name: "unauthenticated", err: domainerrors.Unauthenticated("authentication required"), wantCode: "UNAUTHENTICATED", wantMessage: "authentication required"},
+		{name: "forbidden", err: domainerrors.Forbidden("permission denied"), wantCode: "FORBIDDEN", wantMessage: "permission denied"},
+		{name: "conflict", err: domainerrors.Conflict("slug is taken"), wantCode: "CONFLICT", wantMessage: "slug is taken"},
+		{name: "rate limited", err: domainerrors.RateLimited("slow down"), wantCode: "RATE_LIMITED", wantMessage: "slow down"},
+		{name: "internal", err: domainerrors.ErrInternal, wantCode: "INTERNAL", wantMessage: "internal system error"},
+		{name: "wrapped domain error", err: fmt.Errorf("resolve: %w", domainerrors.NotFound("gone")), wantCode: "NOT_FOUND", wantMessage: "resolve: gone"},
+		{name: "domain error wrapped by gqlgen", err: gqlerror.WrapPath(path, domainerrors.Forbidden("no")), wantCode: "FORBIDDEN", wantMessage: "no"},
+		// コードのないエラーは内部の情報を含みうるので、詳細を返さない
+		{name: "plain error", err: errors.New("dial tcp 10.0.0.5:5432: connection refused"), wantCode: "INTERNAL", wantMessage: "internal system error", wantLogged: true},
+		{name: "gorm error", err: gorm.ErrRecordNotFound, wantCode: "INTERNAL", wantMessage: "internal system error", wantLogged: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fallback, logs bytes.Buffer
+			ctx := log.WithContext(context.Background(), log.New(&logs))
+
+			got := Presenter(log.New(&fallback))(ctx, tt.err)
+
+			if got.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", got.Message, tt.wantMessage)
+			}
+			if code := got.Extensions["code"]; code != tt.wantCode {
+				t.Errorf("extensions.code = %v, want %s", code, tt.wantCode)
+			}
+			var wrapped *gqlerror.Error
+			if errors.As(tt.err, &wrapped) && got.Path.String() != path.String() {
+				t.Errorf("path = %s, want %s", got.Path, path)
+			}
+			if logged := strings.Contains(logs.String(), tt.err.Error()); logged != tt.wantLogged {
+				t.Errorf("logged the error = %v, want %v: %s", logged, tt.wantLogged, logs.String())
+			}
+			if fallback.Len() > 0 {
+				t.Errorf("used the fallback logger although ctx has one: %s", fallback.String())
+			}
+		})
+	}
+}
+
+// TestPresenterPassesClientErrors パースやバリデーションのエラーはそのまま返す
+func TestPresenterPassesClientErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *gqlerror.Error
+	}{
+		{
+			name: "parse error",
+			err: &gqlerror.Error{
+				Message:    "Expected Name, found <EOF>",
+				Locations:  []gqlerror.Location{{Line: 1, Column: 12}},
+				Extensions: map[string]any{"code": "GRAPHQL_PARSE_FAILED"},
+			},
+		},
+		{
+			name: "validation error",
+			err: &gqlerror.Error{
+				Message:    `Cannot query field "nope" on type "Query".`,
+				Locations:  []gqlerror.Location{{Line: 1, Column: 3}},
+				Extensions: map[string]any{"code": "GRAPHQL_VALIDATION_FAILED"},
+			},
+		},
+		{
+			name: "error without extensions",
+			err:  gqlerror.Errorf("operation has complexity 6000, which exceeds the limit of 5000"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			got := Presenter(log.New(&logs))(context.Background(), tt.err)
+			if got != tt.err {
+				t.Errorf("Presenter() = %+v, want the error unchanged", got)
+			}
+			if logs.Len() > 0 {
+				t.Errorf("logged a client error: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestPresenterKeepsExtensions(t *testing.T) {
+	ext := map[string]any{"retryAfter": 3}
+	err := &gqlerror.Error{Message: "x", Err: domainerrors.RateLimited("slow down"), Extensions: ext}
+
+	got := Presenter(log.New(&bytes.Buffer{}))(context.Background(), err)
+	if got.Extensions["retryAfter"] != 3 || got.Extensions["code"] != "RATE_LIMITED" {
+		t.Errorf("extensions = %v, want retryAfter kept and the code added", got.Extensions)
+	}
+	if _, ok := ext["code"]; ok {
+		t.Error("modified the original extensions")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var logs bytes.Buffer
+	err := Recover(log.New(&logs))(context.Background(), "assignment to entry in nil map")
+
+	if !errors.Is(err, domainerrors.ErrInternal) {
+		t.Errorf("Recover() = %v, want ErrInternal", err)
+	}
+	for _, want := range []string{"recovered from panic", "assignment to entry in nil map", `"stack":"`, "gqlerrors_test.go"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log does not contain %q: %s", want, logs.String())
+		}
+	}
+
+	// Presenter を通すと内部エラーとして返る
+	got := Presenter(log.New(&logs))(context.Background(), err)
+	if got.Extensions["code"] != "INTERNAL" || got.Message != "internal system error" {
+		t.Errorf("presented panic = %q %v, want INTERNAL", got.Message, got.Extensions)
+	}
+}
@@ -0,0 +1,84 @@
+package gqlmetrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	// anonymousOperation 名前のない操作はまとめて数える
+	anonymousOperation = "anonymous"
+	// rejectedOperation パースやバリデーション、深さなどの上限で実行前に拒否された操作
+	rejectedOperation = "rejected"
+	// otherOperation KnownOperations にない操作。クライアントが任意の名前で系列を増やせないようにまとめる
+	otherOperation = "other"
+)
+
+// KnownOperations 操作名のラベルにそのまま使う名前。許可リストのマニフェストから作る
+type KnownOperations []string
+
+// Extension 操作ごとの処理時間とエラーの件数を記録する
+// 処理時間にはパースとバリデーションも含める
+type Extension struct {
+	Metrics *metrics.GraphQL
+	Known   KnownOperations
+	known   map[string]bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Extension{}
+
+func (e *Extension) ExtensionName() string {
+	return "Metrics"
+}
+
+func (e *Extension) Validate(graphql.ExecutableSchema) error {
+	if e.Metrics == nil {
+		return fmt.Errorf("gqlmetrics: Metrics can not be nil")
+	}
+	e.known = make(map[string]bool, len(e.Known))
+	for _, name := range e.Known {
+		e.known[name] = true
+	}
+	return nil
+}
+
+// InterceptResponse サブスクリプションではイベントを配信するたびにエラーを数える
+// 処理時間は開始からの経過時間になってしまうので、サブスクリプションでは記録しない
+func (e *Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if !graphql.HasOperationContext(ctx) {
+		e.Metrics.Errors.WithLabelValues(rejectedOperation, "unknown").Inc()
+		return resp
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	name, opType := opCtx.OperationName, "unknown"
+	if opCtx.Operation != nil {
+		if name == "" {
+			name = opCtx.Operation.Name
+		}
+		opType = string(opCtx.Operation.Operation)
+	}
+	switch {
+	case name == "":
+		name = anonymousOperation
+	case !e.known[name]:
+		name = otherOperation
+	}
+
+	if opType != string(ast.Subscription) {
+		e.Metrics.Duration.WithLabelValues(name, opType).Observe(time.Since(opCtx.Stats.OperationStart).Seconds())
+	}
+	if resp != nil && len(resp.Errors) > 0 {
+		e.Metrics.Errors.WithLabelValues(name, opType).Inc()
+	}
+	return resp
+}
@@ -0,0 +1,82 @@
+package gqlratelimit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/clientip"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/ratelimit"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"go.uber.org/zap"
+)
+
+const errRateLimited = "RATE_LIMITED"
+
+// Limits クエリとミューテーションで別々の回数制限を設ける
+// サブスクリプションは開始時にクエリとして数える
+type Limits struct {
+	Query    ratelimit.Rate
+	Mutation ratelimit.Rate
+}
+
+// Extension ログイン中のユーザー、未ログインの場合はクライアントのIPごとに操作の回数を制限する
+// APQ の解決とパースの後に操作の種類で判定するので、WebSocket を含むどのトランスポートでも同じように数える
+// 結果は ratelimit.Record で残し、HTTPでは http.WithRateLimit がレスポンスヘッダーに書く
+type Extension struct {
+	Store  ratelimit.Store
+	Limits Limits
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Extension{}
+
+func (e *Extension) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (e *Extension) Validate(graphql.ExecutableSchema) error {
+	if e.Store == nil {
+		return fmt.Errorf("gqlratelimit: Store can not be nil")
+	}
+	return nil
+}
+
+func (e *Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	kind, rate := "query", e.Limits.Query
+	if opCtx := graphql.GetOperationContext(ctx); opCtx.Operation != nil && opCtx.Operation.Operation == ast.Mutation {
+		kind, rate = "mutation", e.Limits.Mutation
+	}
+
+	res, err := e.Store.Take(ctx, Key(ctx)+":"+kind, rate)
+	if err != nil {
+		// 制限できなくても操作は通す
+		log.MustFromContext(ctx).Warn(ctx, "failed to check rate limit", zap.Error(err))
+		return next(ctx)
+	}
+	ratelimit.Record(ctx, res)
+	if !res.Allowed {
+		err := gqlerror.Errorf("rate limit exceeded")
+		errcode.Set(err, errRateLimited)
+		return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{err}})
+	}
+	return next(ctx)
+}
+
+// Key 回数を数える単位
+func Key(ctx context.Context) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return "user:" + p.UserID.String()
+	}
+	if ip := clientip.FromContext(ctx); ip != "" {
+		return "ip:" + ip
+	}
+	return "ip:unknown"
+}
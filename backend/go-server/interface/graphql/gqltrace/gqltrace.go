@@ -0,0 +1,102 @@
+package gqltrace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/s-blog/backend/go-server/infrastructure/tracing"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Extension 操作ごとと、リゾルバーを持つフィールドごとにスパンを作る
+// 構造体のフィールドを返すだけのフィールドはスパンが多くなりすぎるので記録しない
+type Extension struct {
+	TracerProvider trace.TracerProvider
+	tracer         trace.Tracer
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Extension{}
+
+func (e *Extension) ExtensionName() string {
+	return "Tracing"
+}
+
+func (e *Extension) Validate(graphql.ExecutableSchema) error {
+	if e.TracerProvider == nil {
+		return fmt.Errorf("gqltrace: TracerProvider can not be nil")
+	}
+	e.tracer = e.TracerProvider.Tracer(tracing.InstrumentationName)
+	return nil
+}
+
+// InterceptResponse サブスクリプションではイベントを配信するたびに呼ばれる
+func (e *Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	opCtx := graphql.GetOperationContext(ctx)
+	opName := opCtx.OperationName
+
+	attrs := []attribute.KeyValue{semconv.GraphQLDocument(opCtx.RawQuery)}
+	name := "graphql"
+	if opCtx.Operation != nil {
+		// operationName を省略したリクエストではドキュメント内の名前を使う
+		if opName == "" {
+			opName = opCtx.Operation.Name
+		}
+		name = string(opCtx.Operation.Operation)
+		attrs = append(attrs, semconv.GraphQLOperationTypeKey.String(name))
+	}
+	if opName != "" {
+		name += " " + opName
+		attrs = append(attrs, semconv.GraphQLOperationName(opName))
+	}
+
+	// パースとバリデーションの時間も含める
+	ctx, span := e.tracer.Start(ctx, name,
+		trace.WithTimestamp(opCtx.Stats.OperationStart), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	resp := next(ctx)
+	if resp != nil && len(resp.Errors) > 0 {
+		span.SetStatus(codes.Error, resp.Errors.Error())
+		span.SetAttributes(attribute.Int("graphql.errors.count", len(resp.Errors)))
+	}
+	return resp
+}
+
+func (e *Extension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, span := e.tracer.Start(ctx, fc.Object+"."+fc.Field.Name, trace.WithAttributes(
+		attribute.String("graphql.field.path", fc.Path().String()),
+		attribute.String("graphql.field.type", typeName(fc.Field.Definition)),
+	))
+	defer span.End()
+
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
+
+func typeName(def *ast.FieldDefinition) string {
+	if def == nil || def.Type == nil {
+		return ""
+	}
+	return def.Type.String()
+}
@@ -2,6 +2,12 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
 type Article struct {
 	ID          string     `json:"id"`
 	Title       string     `json:"title"`
@@ -13,12 +19,43 @@ type Article struct {
 	Likes       int        `json:"likes"`
 	Comments    []*Comment `json:"comments"`
 	ReadingTime *string    `json:"readingTime,omitempty"`
+	CoverImage  *Media     `json:"coverImage,omitempty"`
+	// 関連度の高い順に最大 first 件(既定は5件、上限は20件)
+	// 計算済みの関連記事が足りない場合は、同じタグの新しい記事で補う
+	RelatedArticles []*Article `json:"relatedArticles"`
+	// 連載に含まれていない、または記事が公開されていない場合は null
+	Series    *SeriesPart `json:"series,omitempty"`
+	LikedByMe bool        `json:"likedByMe"`
+}
+
+type ArticleConnection struct {
+	Edges    []*ArticleEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type ArticleEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   *Article `json:"node"`
+}
+
+type ArticleLikes struct {
+	ArticleID string `json:"articleId"`
+	Likes     int    `json:"likes"`
 }
 
 type Author struct {
-	Name   string  `json:"name"`
-	Avatar string  `json:"avatar"`
-	Bio    *string `json:"bio,omitempty"`
+	ID       string  `json:"id"`
+	Username string  `json:"username"`
+	Name     string  `json:"name"`
+	Avatar   string  `json:"avatar"`
+	Bio      *string `json:"bio,omitempty"`
+	Website  *string `json:"website,omitempty"`
+	Twitter  *string `json:"twitter,omitempty"`
+	Github   *string `json:"github,omitempty"`
+	// 公開済みの記事を新しい順に返す
+	Articles      *ArticleConnection `json:"articles"`
+	FollowerCount int                `json:"followerCount"`
+	FollowedByMe  bool               `json:"followedByMe"`
 }
 
 type Comment struct {
@@ -26,11 +63,297 @@ type Comment struct {
 	Content   string  `json:"content"`
 	CreatedAt string  `json:"createdAt"`
 	Author    *Author `json:"author"`
+	// 返信先のコメント。トップレベルのコメントでは null
+	ParentID *string `json:"parentId,omitempty"`
+	// 直接の返信を古い順に返す
+	Replies    []*Comment    `json:"replies"`
+	ReplyCount int           `json:"replyCount"`
+	Status     CommentStatus `json:"status"`
+	// モデレーション待ちになった理由。編集者にのみ返す
+	ModerationReason *string `json:"moderationReason,omitempty"`
+}
+
+type CreateSeriesInput struct {
+	Title string `json:"title"`
+	// URLに使う。a-z、0-9、- の200文字以内
+	Slug        string  `json:"slug"`
+	Description *string `json:"description,omitempty"`
+	// パートにする自分の記事を順番に指定する
+	ArticleIds []string `json:"articleIds,omitempty"`
+}
+
+// authorId と tag のどちらか一方を指定する
+type FollowTargetInput struct {
+	AuthorID *string `json:"authorId,omitempty"`
+	Tag      *string `json:"tag,omitempty"`
+}
+
+type Media struct {
+	ID       string          `json:"id"`
+	URL      string          `json:"url"`
+	FileName string          `json:"fileName"`
+	MimeType string          `json:"mimeType"`
+	Size     int             `json:"size"`
+	Width    *int            `json:"width,omitempty"`
+	Height   *int            `json:"height,omitempty"`
+	Checksum string          `json:"checksum"`
+	Status   MediaStatus     `json:"status"`
+	Variants []*MediaVariant `json:"variants"`
+	// <img srcset> にそのまま使える "url 320w, url 640w" 形式の文字列。バリアント生成前はnull
+	Srcset    *string `json:"srcset,omitempty"`
+	CreatedAt string  `json:"createdAt"`
+}
+
+type MediaVariant struct {
+	URL    string      `json:"url"`
+	Format ImageFormat `json:"format"`
+	Width  int         `json:"width"`
+	Height int         `json:"height"`
+	Size   int         `json:"size"`
+}
+
+type Mutation struct {
+}
+
+type Notification struct {
+	ID        string           `json:"id"`
+	Type      NotificationType `json:"type"`
+	Actor     *Author          `json:"actor,omitempty"`
+	Article   *Article         `json:"article,omitempty"`
+	Comment   *Comment         `json:"comment,omitempty"`
+	Read      bool             `json:"read"`
+	CreatedAt string           `json:"createdAt"`
+}
+
+type NotificationConnection struct {
+	Edges    []*NotificationEdge `json:"edges"`
+	PageInfo *PageInfo           `json:"pageInfo"`
+}
+
+type NotificationEdge struct {
+	Cursor string        `json:"cursor"`
+	Node   *Notification `json:"node"`
+}
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
 }
 
 type Query struct {
 }
 
+// 複数回に分けた記事をまとめる連載
+type Series struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Slug        string  `json:"slug"`
+	Description *string `json:"description,omitempty"`
+	Author      *Author `json:"author"`
+	// 公開済みのパートを順番に返す
+	Articles []*Article `json:"articles"`
+}
+
+// 連載の中での記事の位置。公開済みのパートだけで数える
+type SeriesPart struct {
+	Series *Series `json:"series"`
+	// 1から始まる
+	Position int      `json:"position"`
+	Total    int      `json:"total"`
+	Previous *Article `json:"previous,omitempty"`
+	Next     *Article `json:"next,omitempty"`
+}
+
+type Subscription struct {
+}
+
 type Tag struct {
-	Name string `json:"name"`
+	Name          string `json:"name"`
+	FollowerCount int    `json:"followerCount"`
+	FollowedByMe  bool   `json:"followedByMe"`
+}
+
+type UpdateProfileInput struct {
+	Username *string `json:"username,omitempty"`
+	Name     *string `json:"name,omitempty"`
+	Avatar   *string `json:"avatar,omitempty"`
+	Bio      *string `json:"bio,omitempty"`
+	Website  *string `json:"website,omitempty"`
+	Twitter  *string `json:"twitter,omitempty"`
+	Github   *string `json:"github,omitempty"`
+}
+
+type CommentStatus string
+
+const (
+	CommentStatusPublished CommentStatus = "PUBLISHED"
+	// スパムの疑いがあり、編集者の確認を待っている
+	CommentStatusPending  CommentStatus = "PENDING"
+	CommentStatusRejected CommentStatus = "REJECTED"
+)
+
+var AllCommentStatus = []CommentStatus{
+	CommentStatusPublished,
+	CommentStatusPending,
+	CommentStatusRejected,
+}
+
+func (e CommentStatus) IsValid() bool {
+	switch e {
+	case CommentStatusPublished, CommentStatusPending, CommentStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e CommentStatus) String() string {
+	return string(e)
+}
+
+func (e *CommentStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CommentStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CommentStatus", str)
+	}
+	return nil
+}
+
+func (e CommentStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ImageFormat string
+
+const (
+	ImageFormatJpeg ImageFormat = "JPEG"
+	ImageFormatWebp ImageFormat = "WEBP"
+)
+
+var AllImageFormat = []ImageFormat{
+	ImageFormatJpeg,
+	ImageFormatWebp,
+}
+
+func (e ImageFormat) IsValid() bool {
+	switch e {
+	case ImageFormatJpeg, ImageFormatWebp:
+		return true
+	}
+	return false
+}
+
+func (e ImageFormat) String() string {
+	return string(e)
+}
+
+func (e *ImageFormat) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ImageFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ImageFormat", str)
+	}
+	return nil
+}
+
+func (e ImageFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type MediaStatus string
+
+const (
+	MediaStatusPending    MediaStatus = "PENDING"
+	MediaStatusProcessing MediaStatus = "PROCESSING"
+	MediaStatusReady      MediaStatus = "READY"
+	MediaStatusFailed     MediaStatus = "FAILED"
+)
+
+var AllMediaStatus = []MediaStatus{
+	MediaStatusPending,
+	MediaStatusProcessing,
+	MediaStatusReady,
+	MediaStatusFailed,
+}
+
+func (e MediaStatus) IsValid() bool {
+	switch e {
+	case MediaStatusPending, MediaStatusProcessing, MediaStatusReady, MediaStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e MediaStatus) String() string {
+	return string(e)
+}
+
+func (e *MediaStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = MediaStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid MediaStatus", str)
+	}
+	return nil
+}
+
+func (e MediaStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NotificationType string
+
+const (
+	NotificationTypeCommentOnArticle NotificationType = "COMMENT_ON_ARTICLE"
+	NotificationTypeCommentReply     NotificationType = "COMMENT_REPLY"
+	NotificationTypeNewFollower      NotificationType = "NEW_FOLLOWER"
+	NotificationTypeArticlePublished NotificationType = "ARTICLE_PUBLISHED"
+)
+
+var AllNotificationType = []NotificationType{
+	NotificationTypeCommentOnArticle,
+	NotificationTypeCommentReply,
+	NotificationTypeNewFollower,
+	NotificationTypeArticlePublished,
+}
+
+func (e NotificationType) IsValid() bool {
+	switch e {
+	case NotificationTypeCommentOnArticle, NotificationTypeCommentReply, NotificationTypeNewFollower, NotificationTypeArticlePublished:
+		return true
+	}
+	return false
+}
+
+func (e NotificationType) String() string {
+	return string(e)
+}
+
+func (e *NotificationType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationType", str)
+	}
+	return nil
+}
+
+func (e NotificationType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
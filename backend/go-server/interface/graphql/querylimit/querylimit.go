@@ -0,0 +1,131 @@
+package querylimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"go.uber.org/zap"
+)
+
+const (
+	errDepthLimit      = "DEPTH_LIMIT_EXCEEDED"
+	errComplexityLimit = "COMPLEXITY_LIMIT_EXCEEDED"
+	// complexityStatsKey extension.GetComplexityStats で参照できるよう gqlgen と同じ名前で保存する
+	complexityStatsKey = "ComplexityLimit"
+)
+
+// Limits 1回の操作で許可する深さと複雑度。0以下の場合は制限しない
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// Extension 深さと複雑度が上限を超える操作を実行前に拒否し、その値をログに残す
+type Extension struct {
+	// Limits リクエストごとの上限を返す。ログイン中のユーザーのロールで変えられる
+	Limits func(ctx context.Context) Limits
+	es     graphql.ExecutableSchema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &Extension{}
+
+func (e *Extension) ExtensionName() string {
+	return "QueryLimit"
+}
+
+func (e *Extension) Validate(schema graphql.ExecutableSchema) error {
+	if e.Limits == nil {
+		return fmt.Errorf("querylimit: Limits func can not be nil")
+	}
+	e.es = schema
+	return nil
+}
+
+func (e *Extension) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	limits := e.Limits(ctx)
+	logger := log.MustFromContext(ctx).With(zap.String("operation", opCtx.OperationName))
+
+	depth := Depth(opCtx.Operation.SelectionSet)
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		logger.Warn(ctx, "rejected graphql operation: depth limit exceeded",
+			zap.Int("depth", depth), zap.Int("limit", limits.MaxDepth), zap.String("query", opCtx.RawQuery))
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, limits.MaxDepth)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+
+	cost := complexity.Calculate(e.es, opCtx.Operation, opCtx.Variables)
+	opCtx.Stats.SetExtension(complexityStatsKey, &extension.ComplexityStats{
+		Complexity:      cost,
+		ComplexityLimit: limits.MaxComplexity,
+	})
+	if limits.MaxComplexity > 0 && cost > limits.MaxComplexity {
+		logger.Warn(ctx, "rejected graphql operation: complexity limit exceeded",
+			zap.Int("complexity", cost), zap.Int("limit", limits.MaxComplexity), zap.String("query", opCtx.RawQuery))
+		err := gqlerror.Errorf("operation has complexity %d, which exceeds the limit of %d", cost, limits.MaxComplexity)
+		errcode.Set(err, errComplexityLimit)
+		return err
+	}
+	return nil
+}
+
+// ByRole ログイン中のユーザーのロールに応じた上限を返す
+// byRole にないロールと未ログインの場合は anonymous を使う
+func ByRole(anonymous Limits, byRole map[string]Limits) func(ctx context.Context) Limits {
+	return func(ctx context.Context) Limits {
+		p, ok := auth.FromContext(ctx)
+		if !ok {
+			return anonymous
+		}
+		if l, ok := byRole[p.Role]; ok {
+			return l
+		}
+		return anonymous
+	}
+}
+
+// Depth 選択セットの最大の深さを返す。イントロスペクション用のフィールドは数えない
+func Depth(set ast.SelectionSet) int {
+	return depth(set, map[string]bool{})
+}
+
+func depth(set ast.SelectionSet, visiting map[string]bool) int {
+	maxDepth := 0
+	for _, sel := range set {
+		var d int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+			d = 1 + depth(sel.SelectionSet, visiting)
+		case *ast.InlineFragment:
+			d = depth(sel.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			// 循環するフラグメントはバリデーションで弾かれるが、念のため辿らない
+			if sel.Definition == nil || visiting[sel.Name] {
+				continue
+			}
+			visiting[sel.Name] = true
+			d = depth(sel.Definition.SelectionSet, visiting)
+			delete(visiting, sel.Name)
+		}
+		if d > maxDepth {
+			maxDepth = d
+		}
+	}
+	return maxDepth
+}
@@ -0,0 +1,96 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/domain/event"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/notification"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PublishArticle is the resolver for the publishArticle field.
+func (r *mutationResolver) PublishArticle(ctx context.Context, id string) (*gqlmodel.Article, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, domainerrors.InvalidArgument("invalid article ID format")
+	}
+
+	var newlyPublished bool
+	err = r.Transactor.Transaction(ctx, func(tx *gorm.DB) error {
+		// やり直した場合に前回の結果が残らないようにする
+		newlyPublished = false
+		var domainArticle domainmodel.Article
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&domainArticle, "id = ?", parsedID).Error
+		if err != nil {
+			return err
+		}
+		if domainArticle.AuthorID != user.UserID {
+			return errForbidden
+		}
+		if domainArticle.PublishedAt != nil {
+			return nil
+		}
+
+		if err := tx.Model(&domainArticle).Update("published_at", time.Now()).Error; err != nil {
+			return err
+		}
+		newlyPublished = true
+		if err := notification.Record(tx, event.ArticlePublished{
+			ArticleID: domainArticle.ID,
+			AuthorID:  domainArticle.AuthorID,
+		}); err != nil {
+			return err
+		}
+		// 配信レコードは公開と同じトランザクションで作り、公開だけされて配信されない状態を防ぐ
+		return r.Newsletter.QueueArticle(tx, domainArticle.ID)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NotFound("article not found")
+		}
+		if errors.Is(err, errForbidden) {
+			return nil, err
+		}
+		logger(ctx).Error(ctx, "failed to publish article", zap.String("article_id", id), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	if newlyPublished {
+		logger(ctx).Info(ctx, "published article", zap.String("article_id", id))
+		// キューが満杯の場合も、配信レコードは残るため起動時のResumePendingで送信される
+		if err := r.Newsletter.Dispatch(parsedID); err != nil {
+			logger(ctx).Error(ctx, "failed to dispatch newsletter", zap.String("article_id", id), zap.Error(err))
+		}
+		// キューが満杯の場合は Refresh で未計算の記事として拾われる
+		if err := r.Related.Enqueue(parsedID); err != nil {
+			logger(ctx).Warn(ctx, "failed to enqueue related articles", zap.String("article_id", id), zap.Error(err))
+		}
+	}
+
+	var domainArticle domainmodel.Article
+	err = r.DB.WithContext(ctx).Preload("Author").
+		Preload("Tags").
+		First(&domainArticle, "id = ?", parsedID).Error
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch article", zap.String("article_id", id), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return toGQLArticle(&domainArticle), nil
+}
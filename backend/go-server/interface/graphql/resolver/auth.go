@@ -0,0 +1,22 @@
+package resolver
+
+import (
+	"context"
+
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+)
+
+var (
+	errUnauthenticated = domainerrors.Unauthenticated("authentication required")
+	errForbidden       = domainerrors.Forbidden("permission denied")
+)
+
+// currentUser ログイン中のユーザーを返す。未ログインの場合はエラー
+func currentUser(ctx context.Context) (*auth.Principal, error) {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+	return p, nil
+}
@@ -0,0 +1,83 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Articles is the resolver for the articles field.
+func (r *authorResolver) Articles(ctx context.Context, obj *gqlmodel.Author, first *int, after *string) (*gqlmodel.ArticleConnection, error) {
+	if obj.ID == "" {
+		return toArticleConnection(nil, false), nil
+	}
+	conn, err := paginateArticles(r.DB.WithContext(ctx).Where("articles.author_id = ?", obj.ID), first, after)
+	if err != nil {
+		if errors.Is(err, errInvalidCursor) {
+			return nil, err
+		}
+		logger(ctx).Error(ctx, "failed to fetch articles for author", zap.String("author_id", obj.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return conn, nil
+}
+
+// UpdateMyProfile is the resolver for the updateMyProfile field.
+func (r *mutationResolver) UpdateMyProfile(ctx context.Context, input gqlmodel.UpdateProfileInput) (*gqlmodel.Author, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var domainUser domainmodel.User
+	if err := r.DB.WithContext(ctx).First(&domainUser, "id = ?", user.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NotFound("user not found")
+		}
+		logger(ctx).Error(ctx, "failed to fetch user", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	if err := applyProfileInput(&domainUser, input); err != nil {
+		return nil, err
+	}
+
+	err = r.DB.WithContext(ctx).Model(&domainUser).
+		Select("Username", "Name", "Avatar", "Bio", "Website", "Twitter", "GitHub").
+		Updates(&domainUser).Error
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, domainerrors.Conflict("username is already taken")
+		}
+		logger(ctx).Error(ctx, "failed to update profile", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	logger(ctx).Info(ctx, "updated profile")
+
+	return toGQLAuthor(&domainUser), nil
+}
+
+// Author is the resolver for the author field.
+func (r *queryResolver) Author(ctx context.Context, username string) (*gqlmodel.Author, error) {
+	var domainUser domainmodel.User
+	err := r.DB.WithContext(ctx).Where("username = ?", strings.ToLower(username)).First(&domainUser).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger(ctx).Debug(ctx, "author not found", zap.String("username", username))
+			return nil, domainerrors.NotFound("author not found")
+		}
+		logger(ctx).Error(ctx, "failed to fetch author", zap.String("username", username), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return toGQLAuthor(&domainUser), nil
+}
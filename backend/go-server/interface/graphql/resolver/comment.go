@@ -0,0 +1,90 @@
+package resolver
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"gorm.io/gorm"
+)
+
+const maxCommentLength = 5000
+
+// maxCommentDepth 返信を入れ子にできる深さ。トップレベルのコメントは深さ0
+const maxCommentDepth = 5
+
+var (
+	errParentCommentNotFound = domainerrors.NotFound("parent comment not found")
+	errCommentTooDeep        = domainerrors.InvalidArgument("replies cannot be nested more than %d levels", maxCommentDepth)
+)
+
+// commentThreadSQL anchor に一致するコメントとその子孫を1回のクエリで取得する
+// 公開されていないコメントとその子孫は含めない
+const commentThreadSQL = `
+WITH RECURSIVE thread AS (
+	SELECT * FROM comments WHERE %s AND status = 'PUBLISHED' AND deleted_at IS NULL
+	UNION ALL
+	SELECT c.* FROM comments c
+	JOIN thread t ON c.parent_id = t.id
+	WHERE c.status = 'PUBLISHED' AND c.deleted_at IS NULL
+)
+SELECT * FROM thread ORDER BY created_at ASC, id ASC`
+
+func validateCommentContent(content string) (string, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return "", domainerrors.InvalidArgument("comment must not be empty")
+	}
+	if utf8.RuneCountInString(content) > maxCommentLength {
+		return "", domainerrors.InvalidArgument("comment must be at most %d characters", maxCommentLength)
+	}
+	return content, nil
+}
+
+func validateReplyDepth(parent *domainmodel.Comment) error {
+	if parent.Depth+1 > maxCommentDepth {
+		return errCommentTooDeep
+	}
+	return nil
+}
+
+// loadCommentThreads anchor (例: "article_id = ? AND parent_id IS NULL") に一致するコメントを
+// 返信を含む木として返す。返り値は anchor に一致したコメントのみで、子孫は Replies に入る
+func loadCommentThreads(db *gorm.DB, anchor string, args ...any) ([]*gqlmodel.Comment, error) {
+	var comments []*domainmodel.Comment
+	err := db.Raw(fmt.Sprintf(commentThreadSQL, anchor), args...).
+		Preload("User").
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+	return buildCommentTree(comments), nil
+}
+
+// buildCommentTree 作成日時順に並んだコメントを親子関係で組み立てる
+// 親が結果に含まれないコメントは根として扱う
+func buildCommentTree(comments []*domainmodel.Comment) []*gqlmodel.Comment {
+	nodes := make(map[string]*gqlmodel.Comment, len(comments))
+	for _, comment := range comments {
+		node := toGQLComment(comment)
+		node.Replies = []*gqlmodel.Comment{}
+		nodes[node.ID] = node
+	}
+
+	roots := []*gqlmodel.Comment{}
+	for _, comment := range comments {
+		node := nodes[comment.ID.String()]
+		if comment.ParentID != nil {
+			if parent, ok := nodes[comment.ParentID.String()]; ok {
+				parent.Replies = append(parent.Replies, node)
+				parent.ReplyCount++
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots
+}
@@ -0,0 +1,143 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/domain/event"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/clientip"
+	"github.com/s-blog/backend/go-server/infrastructure/notification"
+	"github.com/s-blog/backend/go-server/infrastructure/spam"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// AddComment is the resolver for the addComment field.
+func (r *mutationResolver) AddComment(ctx context.Context, articleID string, content string, parentID *string, honeypot *string) (*gqlmodel.Comment, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parsedArticleID, err := uuid.Parse(articleID)
+	if err != nil {
+		return nil, domainerrors.InvalidArgument("invalid article ID format")
+	}
+	content, err = validateCommentContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedParentID *uuid.UUID
+	if parentID != nil {
+		id, err := uuid.Parse(*parentID)
+		if err != nil {
+			return nil, domainerrors.InvalidArgument("invalid parent comment ID format")
+		}
+		parsedParentID = &id
+	}
+
+	var comment *domainmodel.Comment
+	err = r.Transactor.Transaction(ctx, func(tx *gorm.DB) error {
+		var article domainmodel.Article
+		if err := publishedArticles(tx).Select("id", "author_id").First(&article, "id = ?", parsedArticleID).Error; err != nil {
+			return err
+		}
+
+		var parentAuthorID *uuid.UUID
+		if parsedParentID != nil {
+			var parent domainmodel.Comment
+			err := tx.Select("id", "article_id", "user_id", "depth").
+				First(&parent, "id = ? AND article_id = ? AND status = ?", *parsedParentID, parsedArticleID, domainmodel.CommentStatusPublished).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errParentCommentNotFound
+			}
+			if err != nil {
+				return err
+			}
+			if err := validateReplyDepth(&parent); err != nil {
+				return err
+			}
+			comment = domainmodel.NewReply(uuid.New(), content, &parent, user.UserID)
+			parentAuthorID = &parent.UserID
+		} else {
+			comment = domainmodel.NewComment(uuid.New(), content, parsedArticleID, user.UserID)
+		}
+
+		comment.IPAddress = clientip.FromContext(ctx)
+		reason, err := r.SpamChecker.Check(ctx, tx, &spam.Candidate{
+			Content:  content,
+			UserID:   user.UserID,
+			IP:       comment.IPAddress,
+			Honeypot: stringValue(honeypot),
+		})
+		if err != nil {
+			return err
+		}
+		if reason != "" {
+			comment.Status = domainmodel.CommentStatusPending
+			comment.ModerationReason = reason
+		}
+
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		if comment.Status != domainmodel.CommentStatusPublished {
+			// 通知は編集者が承認したときに送る
+			return nil
+		}
+		return notification.Record(tx, event.CommentAdded{
+			CommentID:       comment.ID,
+			ArticleID:       article.ID,
+			ArticleAuthorID: article.AuthorID,
+			CommenterID:     user.UserID,
+			ParentAuthorID:  parentAuthorID,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NotFound("article not found")
+		}
+		if errors.Is(err, errParentCommentNotFound) || errors.Is(err, errCommentTooDeep) {
+			return nil, err
+		}
+		logger(ctx).Error(ctx, "failed to add comment", zap.String("article_id", articleID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	if err := r.DB.WithContext(ctx).Preload("User").First(comment, "id = ?", comment.ID).Error; err != nil {
+		logger(ctx).Error(ctx, "failed to fetch comment", zap.Stringer("comment_id", comment.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	if comment.Status == domainmodel.CommentStatusPublished {
+		r.publish(ctx, commentAddedTopic(parsedArticleID), commentAddedMessage{CommentID: comment.ID})
+	} else {
+		logger(ctx).Info(ctx, "comment held for moderation", zap.Stringer("comment_id", comment.ID), zap.String("reason", comment.ModerationReason))
+	}
+	return toGQLComment(comment), nil
+}
+
+// CommentThread is the resolver for the commentThread field.
+func (r *queryResolver) CommentThread(ctx context.Context, id string) (*gqlmodel.Comment, error) {
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, domainerrors.InvalidArgument("invalid comment ID format")
+	}
+	threads, err := loadCommentThreads(r.DB.WithContext(ctx),
+		"id = ? AND article_id IN (?)", parsedID, publishedArticles(r.DB.Model(&domainmodel.Article{})).Select("id"))
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch comment thread", zap.String("comment_id", id), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	if len(threads) == 0 {
+		return nil, domainerrors.NotFound("comment not found")
+	}
+	return threads[0], nil
+}
@@ -0,0 +1,204 @@
+package resolver
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+)
+
+func TestBuildCommentTree(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	byName := map[string]*domainmodel.Comment{}
+	// 作成日時順に並べる。commentThreadSQL も同じ順で返す
+	var comments []*domainmodel.Comment
+	add := func(name, parent string) {
+		c := &domainmodel.Comment{ID: uuid.New(), Content: name, CreatedAt: start.Add(time.Duration(len(comments)) * time.Minute)}
+		if parent != "" {
+			c.ParentID = &byName[parent].ID
+			c.Depth = byName[parent].Depth + 1
+		}
+		byName[name] = c
+		comments = append(comments, c)
+	}
+	add("a", "")
+	add("b", "")
+	add("a1", "a")
+	add("a1x", "a1")
+	add("a2", "a")
+	add("b1", "b")
+	add("a1y", "a1")
+
+	roots := buildCommentTree(comments)
+
+	want := "a[a1[a1x a1y] a2] b[b1]"
+	if got := formatCommentTree(roots); got != want {
+		t.Errorf("tree = %s, want %s", got, want)
+	}
+	counts := map[string]int{"a": 2, "a1": 2, "a1x": 0, "a2": 0, "b": 1}
+	var check func(nodes []*gqlmodel.Comment)
+	check = func(nodes []*gqlmodel.Comment) {
+		for _, n := range nodes {
+			if want, ok := counts[n.Content]; ok && n.ReplyCount != want {
+				t.Errorf("%s.replyCount = %d, want %d", n.Content, n.ReplyCount, want)
+			}
+			check(n.Replies)
+		}
+	}
+	check(roots)
+
+	t.Run("thread anchored on a reply", func(t *testing.T) {
+		// commentThread は返信から始まることがあり、その親は結果に含まれない
+		roots := buildCommentTree([]*domainmodel.Comment{byName["a1"], byName["a1x"], byName["a1y"]})
+		if got := formatCommentTree(roots); got != "a1[a1x a1y]" {
+			t.Errorf("tree = %s, want a1[a1x a1y]", got)
+		}
+	})
+}
+
+// formatCommentTree "a[a1 a2] b" のように木を文字列にする
+func formatCommentTree(nodes []*gqlmodel.Comment) string {
+	parts := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		s := n.Content
+		if len(n.Replies) > 0 {
+			s += "[" + formatCommentTree(n.Replies) + "]"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestCommentThreadSQL(t *testing.T) {
+	anchor, recursive, ok := strings.Cut(commentThreadSQL, "UNION ALL")
+	if !ok {
+		t.Fatal("commentThreadSQL is not a recursive query")
+	}
+	// 起点も、たどる返信も公開済みのものだけにする
+	// 再帰部分で除いたコメントからはたどらないので、その下の返信も含まれない
+	for _, part := range []struct {
+		name, sql, prefix string
+	}{
+		{name: "anchor", sql: anchor},
+		{name: "recursive", sql: recursive, prefix: "c."},
+	} {
+		for _, cond := range []string{"status = 'PUBLISHED'", "deleted_at IS NULL"} {
+			if !strings.Contains(part.sql, part.prefix+cond) {
+				t.Errorf("%s part does not filter on %s%s", part.name, part.prefix, cond)
+			}
+		}
+	}
+	if !strings.Contains(recursive, "JOIN thread t ON c.parent_id = t.id") {
+		t.Error("recursive part does not follow replies of comments already in the thread")
+	}
+	if !strings.Contains(recursive, "ORDER BY created_at ASC, id ASC") {
+		t.Error("thread is not ordered by creation time")
+	}
+}
+
+func TestArticleComments(t *testing.T) {
+	articleID := uuid.New()
+	userID := uuid.New()
+	root, reply := uuid.New(), uuid.New()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r, f := newFakeResolver(t)
+	f.on(`WITH RECURSIVE thread`).returns(columns("id, content, article_id, user_id, parent_id, depth, status, created_at"),
+		[]driver.Value{root.String(), "root", articleID.String(), userID.String(), nil, int64(0), "PUBLISHED", start},
+		[]driver.Value{reply.String(), "reply", articleID.String(), userID.String(), root.String(), int64(1), "PUBLISHED", start.Add(time.Minute)},
+	)
+	f.on(`FROM "users"`).returns(userColumns, []driver.Value{userID.String(), "user", "User"})
+
+	got, err := r.Article().Comments(context.Background(), &gqlmodel.Article{ID: articleID.String()})
+	if err != nil {
+		t.Fatalf("Comments() error = %v", err)
+	}
+	if s := formatCommentTree(got); s != "root[reply]" {
+		t.Errorf("comments = %s, want root[reply]", s)
+	}
+	if got[0].Replies[0].Author.Name != "User" {
+		t.Errorf("reply author = %q, want User", got[0].Replies[0].Author.Name)
+	}
+
+	q := f.executed(`WITH RECURSIVE thread`)
+	if len(q) != 1 {
+		t.Fatalf("ran the thread query %d times, want once for the whole thread", len(q))
+	}
+	if !strings.Contains(q[0].sql, "article_id = $1 AND parent_id IS NULL") {
+		t.Errorf("thread query does not start from top-level comments: %s", q[0].sql)
+	}
+}
+
+func TestValidateReplyDepth(t *testing.T) {
+	for depth := 0; depth <= maxCommentDepth; depth++ {
+		err := validateReplyDepth(&domainmodel.Comment{Depth: depth})
+		if wantErr := depth >= maxCommentDepth; (err != nil) != wantErr {
+			t.Errorf("reply to depth %d: error = %v, wantErr %v", depth, err, wantErr)
+		}
+	}
+}
+
+func TestAddCommentReply(t *testing.T) {
+	articleID := uuid.New()
+	parentID := uuid.New()
+	authorID := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name        string
+		parentDepth int
+		noParent    bool
+		wantErr     func(error) bool
+		wantDepth   int64
+	}{
+		{name: "reply to a top-level comment", parentDepth: 0, wantDepth: 1},
+		{name: "reply at the deepest level", parentDepth: maxCommentDepth - 1, wantDepth: maxCommentDepth},
+		{name: "reply below the deepest level", parentDepth: maxCommentDepth, wantErr: hasCode(domainerrors.CodeInvalidArgument)},
+		// 未公開、削除済み、他の記事のコメントは見つからない
+		{name: "parent not found", noParent: true, wantErr: hasCode(domainerrors.CodeNotFound)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, f := newFakeResolver(t)
+			f.on(`FROM "articles"`).returns(columns("id, author_id"), []driver.Value{articleID.String(), authorID.String()})
+			parent := f.on(`FROM "comments" WHERE \(id = \$1 AND article_id = \$2 AND status = \$3\)`).onlyOnce()
+			if !tt.noParent {
+				parent.returns(columns("id, article_id, user_id, depth"),
+					[]driver.Value{parentID.String(), articleID.String(), authorID.String(), int64(tt.parentDepth)})
+			}
+			f.on(`FROM "comments"`).returns(columns("status"), []driver.Value{"PUBLISHED"})
+
+			ctx := auth.WithContext(context.Background(), &auth.Principal{UserID: userID})
+			parentIDStr := parentID.String()
+			_, err := r.Mutation().AddComment(ctx, articleID.String(), "返信です", &parentIDStr, nil)
+
+			inserts := f.executed(`^INSERT INTO "comments"`)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("AddComment() error = %v", err)
+				}
+				if len(inserts) > 0 {
+					t.Error("comment was inserted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddComment() error = %v", err)
+			}
+			if len(inserts) != 1 {
+				t.Fatalf("inserted %d comments, want 1", len(inserts))
+			}
+			row := inserts[0].inserted()[0]
+			if row["depth"] != tt.wantDepth || row["parent_id"] != parentID.String() {
+				t.Errorf("inserted depth %v parent %v, want depth %d parent %s", row["depth"], row["parent_id"], tt.wantDepth, parentID)
+			}
+		})
+	}
+}
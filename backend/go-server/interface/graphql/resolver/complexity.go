@@ -0,0 +1,44 @@
+package resolver
+
+import (
+	"github.com/s-blog/backend/go-server/interface/graphql/generated"
+	"github.com/s-blog/backend/go-server/interface/graphql/model"
+)
+
+const (
+	// estimatedListSize 件数を指定できないリストフィールドで見積もる件数
+	estimatedListSize = 20
+)
+
+// Complexity クエリの複雑度の計算方法
+// 指定のないフィールドは gqlgen の既定どおり 1 + 子の複雑度 になる
+func Complexity() generated.ComplexityRoot {
+	var c generated.ComplexityRoot
+
+	c.Query.Articles = listComplexity
+	c.Query.ArticlesByTag = func(child int, _ string) int { return listComplexity(child) }
+	c.Query.TrendingArticles = listComplexity
+	c.Query.MyFeed = func(child int, first *int, _ *string) int { return pageComplexity(child, first) }
+	c.Query.Notifications = func(child int, first *int, _ *string, _ *bool) int { return pageComplexity(child, first) }
+	c.Query.ModerationQueue = func(child int, first *int) int { return pageComplexity(child, first) }
+	c.Author.Articles = func(child int, first *int, _ *string) int { return pageComplexity(child, first) }
+	c.Article.Comments = listComplexity
+	c.Series.Articles = listComplexity
+	// 記事ごとに連載と公開済みのパートを問い合わせるため、記事の一覧から読むと件数分だけ問い合わせが増える
+	c.Article.Series = func(child int) int { return estimatedListSize + child }
+	c.Article.RelatedArticles = func(child int, first *int) int { return 1 + child*relatedPageSize(first) }
+	// 返信は Article.comments などがスレッド全体を1回のクエリで読み込むため、件数を掛けない
+	c.Comment.Replies = func(child int) int { return 1 + child }
+	c.Media.Variants = func(child int, _ *model.ImageFormat) int { return listComplexity(child) }
+
+	return c
+}
+
+func listComplexity(child int) int {
+	return 1 + child*estimatedListSize
+}
+
+// pageComplexity 取得する件数分だけ子の複雑度を掛ける
+func pageComplexity(child int, first *int) int {
+	return 1 + child*pageSize(first)
+}
@@ -0,0 +1,70 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/s-blog/backend/go-server/interface/graphql/generated"
+	"github.com/vektah/gqlparser/v2"
+)
+
+// defaultMaxComplexityAnonymous GRAPHQL_MAX_COMPLEXITY_ANONYMOUS の既定値
+const defaultMaxComplexityAnonymous = 5000
+
+// commentThreadQuery depth 段の返信を含むスレッドを取得するクエリ
+func commentThreadQuery(depth int) string {
+	fields := "id content createdAt author { name }"
+	selection := fields
+	for range depth {
+		selection = fields + " replies { " + selection + " }"
+	}
+	return "query { article(id: \"1\") { id title comments { " + selection + " } } }"
+}
+
+func TestCommentThreadComplexity(t *testing.T) {
+	es := generated.NewExecutableSchema(generated.Config{
+		Resolvers:  &Resolver{},
+		Complexity: Complexity(),
+	})
+
+	var prev, step int
+	for depth := 0; depth <= maxCommentDepth; depth++ {
+		query := commentThreadQuery(depth)
+		doc, errs := gqlparser.LoadQuery(es.Schema(), query)
+		if errs != nil {
+			t.Fatalf("depth %d: invalid query: %v", depth, errs)
+		}
+		got := complexity.Calculate(es, doc.Operations[0], nil)
+		if got > defaultMaxComplexityAnonymous {
+			t.Errorf("depth %d: complexity %d exceeds the anonymous limit %d", depth, got, defaultMaxComplexityAnonymous)
+		}
+		// 返信の深さに対して線形に増える
+		if depth == 1 {
+			step = got - prev
+		} else if depth > 1 && got-prev != step {
+			t.Errorf("depth %d: complexity grew by %d, want %d as for the previous level", depth, got-prev, step)
+		}
+		prev = got
+	}
+}
+
+func TestArticleSeriesComplexity(t *testing.T) {
+	es := generated.NewExecutableSchema(generated.Config{
+		Resolvers:  &Resolver{},
+		Complexity: Complexity(),
+	})
+	calculate := func(query string) int {
+		doc, errs := gqlparser.LoadQuery(es.Schema(), query)
+		if errs != nil {
+			t.Fatalf("invalid query: %v", errs)
+		}
+		return complexity.Calculate(es, doc.Operations[0], nil)
+	}
+
+	// 記事ごとに問い合わせるため、一覧の件数分だけ高くなる
+	plain := calculate(`{ articles { id } }`)
+	withSeries := calculate(`{ articles { id series { position } } }`)
+	if want := plain + estimatedListSize*(estimatedListSize+1); withSeries < want {
+		t.Errorf("complexity with series = %d, want at least %d", withSeries, want)
+	}
+}
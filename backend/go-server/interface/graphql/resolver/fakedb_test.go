@@ -0,0 +1,259 @@
+package resolver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	infragorm "github.com/s-blog/backend/go-server/infrastructure/gorm"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/pubsub"
+	"github.com/s-blog/backend/go-server/infrastructure/spam"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// fakeDB 実行されたSQLを記録し、正規表現で一致したルールの結果を返すドライバー
+// どのルールにも一致しない問い合わせは0行、更新は0件として扱う
+// トランザクションの開始と終了は BEGIN、COMMIT、ROLLBACK として記録する
+type fakeDB struct {
+	mu      sync.Mutex
+	rules   []*fakeRule
+	queries []fakeQuery
+}
+
+type fakeRule struct {
+	pattern  *regexp.Regexp
+	columns  []string
+	rows     [][]driver.Value
+	affected int64
+	err      error
+	// once 一度使ったら以降は一致させない
+	once bool
+	used bool
+}
+
+type fakeQuery struct {
+	sql  string
+	args []any
+}
+
+// newFakeResolver fakeDB を使うリゾルバーを作る。トランザクションはやり直さない
+func newFakeResolver(t *testing.T) (*Resolver, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{}
+	sqlDB := sql.OpenDB(fakeConnector{f})
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               gormlogger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+	return &Resolver{
+		DB:          db,
+		Transactor:  infragorm.NewTransactor(db, log.New(io.Discard), infragorm.TransactorOptions{MaxAttempts: 1}),
+		PubSub:      pubsub.NewMemory(),
+		SpamChecker: spam.NewChain(),
+	}, f
+}
+
+// on pattern に一致するSQLの結果を登録する。先に登録したルールが優先される
+func (f *fakeDB) on(pattern string) *fakeRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := &fakeRule{pattern: regexp.MustCompile(pattern)}
+	f.rules = append(f.rules, r)
+	return r
+}
+
+func (r *fakeRule) returns(columns []string, rows ...[]driver.Value) *fakeRule {
+	r.columns = columns
+	r.rows = rows
+	return r
+}
+
+func (r *fakeRule) affects(n int64) *fakeRule {
+	r.affected = n
+	return r
+}
+
+func (r *fakeRule) fails(err error) *fakeRule {
+	r.err = err
+	return r
+}
+
+func (r *fakeRule) onlyOnce() *fakeRule {
+	r.once = true
+	return r
+}
+
+func (f *fakeDB) match(query string, args []driver.NamedValue) *fakeRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := fakeQuery{sql: query}
+	for _, a := range args {
+		q.args = append(q.args, a.Value)
+	}
+	f.queries = append(f.queries, q)
+	for _, r := range f.rules {
+		if r.once && r.used {
+			continue
+		}
+		if r.pattern.MatchString(query) {
+			r.used = true
+			return r
+		}
+	}
+	return &fakeRule{}
+}
+
+// executed pattern に一致した問い合わせを実行順に返す
+func (f *fakeDB) executed(pattern string) []fakeQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	re := regexp.MustCompile(pattern)
+	var matched []fakeQuery
+	for _, q := range f.queries {
+		if re.MatchString(q.sql) {
+			matched = append(matched, q)
+		}
+	}
+	return matched
+}
+
+// indexOf pattern に最初に一致した問い合わせの順番。実行されていない場合は -1
+func (f *fakeDB) indexOf(pattern string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	re := regexp.MustCompile(pattern)
+	for i, q := range f.queries {
+		if re.MatchString(q.sql) {
+			return i
+		}
+	}
+	return -1
+}
+
+var insertColumnsPattern = regexp.MustCompile(`^INSERT INTO "[a-z_]+" \(([^)]*)\)`)
+
+// inserted INSERT の各行を列名から値への対応にする
+func (q fakeQuery) inserted() []map[string]any {
+	m := insertColumnsPattern.FindStringSubmatch(q.sql)
+	if m == nil {
+		return nil
+	}
+	cols := strings.Split(strings.ReplaceAll(m[1], `"`, ""), ",")
+	var rows []map[string]any
+	for i := 0; i+len(cols) <= len(q.args); i += len(cols) {
+		row := make(map[string]any, len(cols))
+		for j, c := range cols {
+			row[c] = q.args[i+j]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+type fakeConnector struct {
+	db *fakeDB
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: c.db}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("use the connector")
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	if r := c.db.match("BEGIN", nil); r.err != nil {
+		return nil, r.err
+	}
+	return fakeTx{db: c.db}, nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	r := c.db.match(query, args)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &fakeRows{columns: r.columns, rows: r.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	r := c.db.match(query, args)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return driver.RowsAffected(r.affected), nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (tx fakeTx) Commit() error {
+	return tx.db.match("COMMIT", nil).err
+}
+
+func (tx fakeTx) Rollback() error {
+	return tx.db.match("ROLLBACK", nil).err
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if r.columns == nil {
+		return []string{"id"}
+	}
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// columns "id, name" のような列名の一覧
+func columns(names string) []string {
+	return strings.Split(strings.ReplaceAll(names, " ", ""), ",")
+}
@@ -0,0 +1,100 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/domain/event"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/notification"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// followTarget フォロー対象の著者またはタグ
+type followTarget struct {
+	authorID uuid.UUID
+	tagID    uuid.UUID
+}
+
+// resolveFollowTarget 入力を検証し、対象が存在することを確認する
+func (r *Resolver) resolveFollowTarget(ctx context.Context, user *auth.Principal, target gqlmodel.FollowTargetInput) (*followTarget, error) {
+	if (target.AuthorID == nil) == (target.Tag == nil) {
+		return nil, domainerrors.InvalidArgument("exactly one of authorId or tag must be specified")
+	}
+	db := r.DB.WithContext(ctx)
+
+	if target.AuthorID != nil {
+		authorID, err := uuid.Parse(*target.AuthorID)
+		if err != nil {
+			return nil, domainerrors.InvalidArgument("invalid author ID format")
+		}
+		if authorID == user.UserID {
+			return nil, domainerrors.InvalidArgument("you cannot follow yourself")
+		}
+		if err := db.Select("id").First(&domainmodel.User{}, "id = ?", authorID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, domainerrors.NotFound("author not found")
+			}
+			return nil, err
+		}
+		return &followTarget{authorID: authorID}, nil
+	}
+
+	var tag domainmodel.Tag
+	if err := db.Select("id").Where("name = ?", *target.Tag).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NotFound("tag not found")
+		}
+		return nil, err
+	}
+	return &followTarget{tagID: tag.ID}, nil
+}
+
+// follow 既にフォロー済みの場合は何もしない
+// 著者を新たにフォローした場合は同じトランザクションで著者への通知を作る(同じユーザーからの未読の通知があれば作らない)
+func (r *Resolver) follow(ctx context.Context, followerID uuid.UUID, t *followTarget) error {
+	if t.tagID != uuid.Nil {
+		return r.DB.WithContext(ctx).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(domainmodel.NewTagFollow(followerID, t.tagID)).Error
+	}
+
+	return r.Transactor.Transaction(ctx, func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(domainmodel.NewAuthorFollow(followerID, t.authorID))
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return notification.Record(tx, event.UserFollowed{FollowerID: followerID, AuthorID: t.authorID})
+	})
+}
+
+func (r *Resolver) unfollow(ctx context.Context, followerID uuid.UUID, t *followTarget) error {
+	db := r.DB.WithContext(ctx)
+	if t.authorID != uuid.Nil {
+		return db.Delete(&domainmodel.AuthorFollow{}, "follower_id = ? AND author_id = ?", followerID, t.authorID).Error
+	}
+	return db.Delete(&domainmodel.TagFollow{}, "follower_id = ? AND tag_id = ?", followerID, t.tagID).Error
+}
+
+// feedArticles フォロー中の著者の記事とフォロー中のタグが付いた記事
+// サブクエリで絞り込むため、両方に該当する記事も重複しない
+func feedArticles(db *gorm.DB, followerID uuid.UUID) *gorm.DB {
+	followedAuthors := db.Session(&gorm.Session{NewDB: true}).
+		Model(&domainmodel.AuthorFollow{}).
+		Select("author_id").
+		Where("follower_id = ?", followerID)
+	followedTagArticles := db.Session(&gorm.Session{NewDB: true}).
+		Table("article_tags").
+		Select("article_tags.article_id").
+		Joins("JOIN tag_follows ON tag_follows.tag_id = article_tags.tag_id").
+		Where("tag_follows.follower_id = ?", followerID)
+
+	return db.Model(&domainmodel.Article{}).
+		Where("articles.author_id IN (?) OR articles.id IN (?)", followedAuthors, followedTagArticles)
+}
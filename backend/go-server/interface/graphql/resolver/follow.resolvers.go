@@ -0,0 +1,144 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"errors"
+
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FollowerCount is the resolver for the followerCount field.
+func (r *authorResolver) FollowerCount(ctx context.Context, obj *gqlmodel.Author) (int, error) {
+	if obj.ID == "" {
+		return 0, nil
+	}
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&domainmodel.AuthorFollow{}).Where("author_id = ?", obj.ID).Count(&count).Error
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to count followers", zap.String("author_id", obj.ID), zap.Error(err))
+		return 0, domainerrors.ErrInternal
+	}
+	return int(count), nil
+}
+
+// FollowedByMe is the resolver for the followedByMe field.
+func (r *authorResolver) FollowedByMe(ctx context.Context, obj *gqlmodel.Author) (bool, error) {
+	user, ok := auth.FromContext(ctx)
+	if !ok || obj.ID == "" {
+		return false, nil
+	}
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&domainmodel.AuthorFollow{}).
+		Where("follower_id = ? AND author_id = ?", user.UserID, obj.ID).
+		Count(&count).Error
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to check follow", zap.String("author_id", obj.ID), zap.Error(err))
+		return false, domainerrors.ErrInternal
+	}
+	return count > 0, nil
+}
+
+// Follow is the resolver for the follow field.
+func (r *mutationResolver) Follow(ctx context.Context, target gqlmodel.FollowTargetInput) (bool, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return false, err
+	}
+	t, err := r.resolveFollowTarget(ctx, user, target)
+	if err != nil {
+		return false, err
+	}
+	if err := r.follow(ctx, user.UserID, t); err != nil {
+		logger(ctx).Error(ctx, "failed to follow", zap.Any("target", target), zap.Error(err))
+		return false, domainerrors.ErrInternal
+	}
+	return true, nil
+}
+
+// Unfollow is the resolver for the unfollow field.
+func (r *mutationResolver) Unfollow(ctx context.Context, target gqlmodel.FollowTargetInput) (bool, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return false, err
+	}
+	t, err := r.resolveFollowTarget(ctx, user, target)
+	if err != nil {
+		return false, err
+	}
+	if err := r.unfollow(ctx, user.UserID, t); err != nil {
+		logger(ctx).Error(ctx, "failed to unfollow", zap.Any("target", target), zap.Error(err))
+		return false, domainerrors.ErrInternal
+	}
+	return true, nil
+}
+
+// Tag is the resolver for the tag field.
+func (r *queryResolver) Tag(ctx context.Context, name string) (*gqlmodel.Tag, error) {
+	var tag domainmodel.Tag
+	if err := r.DB.WithContext(ctx).Where("name = ?", name).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NotFound("tag not found")
+		}
+		logger(ctx).Error(ctx, "failed to fetch tag", zap.String("tag", name), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return &gqlmodel.Tag{Name: tag.Name}, nil
+}
+
+// MyFeed is the resolver for the myFeed field.
+func (r *queryResolver) MyFeed(ctx context.Context, first *int, after *string) (*gqlmodel.ArticleConnection, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := paginateArticles(feedArticles(r.DB.WithContext(ctx), user.UserID), first, after)
+	if err != nil {
+		if errors.Is(err, errInvalidCursor) {
+			return nil, err
+		}
+		logger(ctx).Error(ctx, "failed to fetch feed", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return conn, nil
+}
+
+// FollowerCount is the resolver for the followerCount field.
+func (r *tagResolver) FollowerCount(ctx context.Context, obj *gqlmodel.Tag) (int, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&domainmodel.TagFollow{}).
+		Joins("JOIN tags ON tags.id = tag_follows.tag_id").
+		Where("tags.name = ?", obj.Name).
+		Count(&count).Error
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to count followers", zap.String("tag", obj.Name), zap.Error(err))
+		return 0, domainerrors.ErrInternal
+	}
+	return int(count), nil
+}
+
+// FollowedByMe is the resolver for the followedByMe field.
+func (r *tagResolver) FollowedByMe(ctx context.Context, obj *gqlmodel.Tag) (bool, error) {
+	user, ok := auth.FromContext(ctx)
+	if !ok {
+		return false, nil
+	}
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&domainmodel.TagFollow{}).
+		Joins("JOIN tags ON tags.id = tag_follows.tag_id").
+		Where("tag_follows.follower_id = ? AND tags.name = ?", user.UserID, obj.Name).
+		Count(&count).Error
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to check follow", zap.String("tag", obj.Name), zap.Error(err))
+		return false, domainerrors.ErrInternal
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,306 @@
+package resolver
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+)
+
+func TestCursor(t *testing.T) {
+	id := uuid.New()
+	ts := time.Date(2026, 10, 1, 9, 30, 0, 123456789, time.FixedZone("JST", 9*60*60))
+
+	c, err := decodeCursor(encodeCursor(ts, id))
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if !c.Time.Equal(ts) || c.ID != id {
+		t.Errorf("decodeCursor() = %v %s, want %v %s", c.Time, c.ID, ts, id)
+	}
+
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, s := range []string{
+		"",
+		"not base64!",
+		encode("2026-10-01T00:00:00Z"),
+		encode("yesterday|" + id.String()),
+		encode("2026-10-01T00:00:00Z|not-a-uuid"),
+	} {
+		if _, err := decodeCursor(s); err != errInvalidCursor {
+			t.Errorf("decodeCursor(%q) error = %v, want errInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	tests := []struct {
+		first *int
+		want  int
+	}{
+		{first: nil, want: defaultPageSize},
+		{first: ptr(0), want: defaultPageSize},
+		{first: ptr(-1), want: defaultPageSize},
+		{first: ptr(5), want: 5},
+		{first: ptr(maxPageSize + 1), want: maxPageSize},
+	}
+	for _, tt := range tests {
+		if got := pageSize(tt.first); got != tt.want {
+			t.Errorf("pageSize(%v) = %d, want %d", tt.first, got, tt.want)
+		}
+	}
+}
+
+var feedColumns = columns("id, title, author_id, published_at")
+
+func TestMyFeed(t *testing.T) {
+	user := &auth.Principal{UserID: uuid.New(), Role: auth.RoleReader}
+	authorID := uuid.New()
+	now := time.Now().Truncate(time.Second)
+
+	// 新しい順の3件
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	rows := make([][]driver.Value, len(ids))
+	for i, id := range ids {
+		rows[i] = []driver.Value{id.String(), "article " + string(rune('a'+i)), authorID.String(), now.Add(-time.Duration(i) * time.Hour)}
+	}
+	after := encodeCursor(now.Add(-30*time.Minute), uuid.New())
+	afterCursor, _ := decodeCursor(after)
+
+	tests := []struct {
+		name      string
+		user      *auth.Principal
+		first     *int
+		after     *string
+		rows      [][]driver.Value
+		wantLimit int64
+		wantAfter bool
+		wantTitle []string
+		wantNext  bool
+		wantErr   func(error) bool
+	}{
+		{
+			name:      "first page with more articles",
+			user:      user,
+			first:     ptr(2),
+			rows:      rows,
+			wantLimit: 3,
+			wantTitle: []string{"article a", "article b"},
+			wantNext:  true,
+		},
+		{
+			name:      "last page",
+			user:      user,
+			first:     ptr(2),
+			after:     &after,
+			rows:      rows[1:],
+			wantLimit: 3,
+			wantAfter: true,
+			wantTitle: []string{"article b", "article c"},
+		},
+		{
+			name:      "empty feed",
+			user:      user,
+			wantLimit: 11,
+		},
+		{
+			name:      "first is clamped",
+			user:      user,
+			first:     ptr(1000),
+			rows:      rows,
+			wantLimit: 101,
+			wantTitle: []string{"article a", "article b", "article c"},
+		},
+		{
+			name:    "invalid cursor",
+			user:    user,
+			after:   ptr("broken"),
+			wantErr: hasCode(domainerrors.CodeInvalidArgument),
+		},
+		{
+			name:    "unauthenticated",
+			wantErr: hasCode(domainerrors.CodeUnauthenticated),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, f := newFakeResolver(t)
+			f.on(`FROM "articles"`).returns(feedColumns, tt.rows...)
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = auth.WithContext(ctx, tt.user)
+			}
+
+			conn, err := r.Query().MyFeed(ctx, tt.first, tt.after)
+
+			queries := f.executed(`FROM "articles"`)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("error = %v", err)
+				}
+				if len(queries) > 0 {
+					t.Errorf("queried articles: %v", queries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MyFeed() error = %v", err)
+			}
+			if len(queries) != 1 {
+				t.Fatalf("article queries = %v, want one", queries)
+			}
+			q := queries[0]
+
+			// フォロー中の著者とタグのサブクエリで絞り込み、公開日時とIDの降順で並べる
+			for _, want := range []string{
+				`articles.author_id IN (SELECT "author_id" FROM "author_follows" WHERE follower_id = $1)`,
+				`articles.id IN (SELECT article_tags.article_id FROM "article_tags" JOIN tag_follows ON tag_follows.tag_id = article_tags.tag_id WHERE tag_follows.follower_id = $2)`,
+				"articles.published_at IS NOT NULL",
+				"ORDER BY articles.published_at desc,articles.id desc LIMIT $",
+			} {
+				if !strings.Contains(q.sql, want) {
+					t.Errorf("query does not contain %q: %s", want, q.sql)
+				}
+			}
+			if q.args[0] != user.UserID.String() || q.args[1] != user.UserID.String() {
+				t.Errorf("args = %v, want the follower ID for both subqueries", q.args)
+			}
+			// 次のページの有無を知るために1件多く取得する
+			if limit := q.args[len(q.args)-1]; limit != tt.wantLimit {
+				t.Errorf("limit = %v, want %d", limit, tt.wantLimit)
+			}
+			hasAfter := strings.Contains(q.sql, "(articles.published_at, articles.id) < (")
+			if hasAfter != tt.wantAfter {
+				t.Errorf("after condition = %v, want %v: %s", hasAfter, tt.wantAfter, q.sql)
+			}
+			if tt.wantAfter && (!containsArg(q, afterCursor.ID.String()) || !containsTime(q, afterCursor.Time)) {
+				t.Errorf("args = %v, want the cursor %v %s", q.args, afterCursor.Time, afterCursor.ID)
+			}
+
+			var titles []string
+			for _, e := range conn.Edges {
+				titles = append(titles, e.Node.Title)
+			}
+			if strings.Join(titles, ", ") != strings.Join(tt.wantTitle, ", ") {
+				t.Errorf("titles = %q, want %q", titles, tt.wantTitle)
+			}
+			if conn.PageInfo.HasNextPage != tt.wantNext {
+				t.Errorf("hasNextPage = %v, want %v", conn.PageInfo.HasNextPage, tt.wantNext)
+			}
+			if len(conn.Edges) == 0 {
+				if conn.PageInfo.EndCursor != nil {
+					t.Errorf("endCursor = %q, want nil", *conn.PageInfo.EndCursor)
+				}
+				return
+			}
+			// 次のページは最後の記事の直後から始まる
+			last := conn.Edges[len(conn.Edges)-1]
+			if conn.PageInfo.EndCursor == nil || *conn.PageInfo.EndCursor != last.Cursor {
+				t.Errorf("endCursor = %v, want the last edge %s", conn.PageInfo.EndCursor, last.Cursor)
+			}
+			if c, err := decodeCursor(last.Cursor); err != nil || c.ID.String() != last.Node.ID {
+				t.Errorf("last cursor = %v (%v), want the ID %s", c, err, last.Node.ID)
+			}
+		})
+	}
+}
+
+// TestFeedPagesDoNotOverlap 前のページの endCursor で次のページを取得する
+func TestFeedPagesDoNotOverlap(t *testing.T) {
+	user := &auth.Principal{UserID: uuid.New(), Role: auth.RoleReader}
+	ctx := auth.WithContext(context.Background(), user)
+	published := time.Now().Add(-time.Hour)
+	// 公開日時が同じ記事はIDで順序を決める
+	ids := []string{"ffffffff-0000-4000-8000-000000000000", "eeeeeeee-0000-4000-8000-000000000000", "dddddddd-0000-4000-8000-000000000000"}
+	row := func(id string) []driver.Value { return []driver.Value{id, id[:8], uuid.NewString(), published} }
+
+	r, f := newFakeResolver(t)
+	f.on(`FROM "articles"`).onlyOnce().returns(feedColumns, row(ids[0]), row(ids[1]), row(ids[2]))
+	f.on(`FROM "articles"`).returns(feedColumns, row(ids[2]))
+
+	page1, err := r.Query().MyFeed(ctx, ptr(2), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page2, err := r.Query().MyFeed(ctx, ptr(2), page1.PageInfo.EndCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := f.executed(`FROM "articles"`)[1]
+	if !containsArg(q, ids[1]) || !containsTime(q, published) {
+		t.Errorf("second page args = %v, want after %v %s", q.args, published, ids[1])
+	}
+	var got []string
+	for _, p := range []*gqlmodel.ArticleConnection{page1, page2} {
+		for _, e := range p.Edges {
+			got = append(got, e.Node.ID)
+		}
+	}
+	if len(got) != 3 || got[0] != ids[0] || got[1] != ids[1] || got[2] != ids[2] {
+		t.Errorf("articles = %v, want %v", got, ids)
+	}
+	if page2.PageInfo.HasNextPage {
+		t.Error("hasNextPage on the last page")
+	}
+}
+
+func containsTime(q fakeQuery, want time.Time) bool {
+	for _, a := range q.args {
+		if ts, ok := a.(time.Time); ok && ts.Equal(want) {
+			return true
+		}
+	}
+	return false
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// TestFollowNotifiesOnce 既にフォロー済みの場合は著者に通知しない
+func TestFollowNotifiesOnce(t *testing.T) {
+	user := &auth.Principal{UserID: uuid.New(), Role: auth.RoleReader}
+	authorID := uuid.New()
+
+	tests := []struct {
+		name       string
+		target     gqlmodel.FollowTargetInput
+		affected   int64
+		wantNotify bool
+	}{
+		{name: "new follow", target: gqlmodel.FollowTargetInput{AuthorID: ptr(authorID.String())}, affected: 1, wantNotify: true},
+		{name: "already following", target: gqlmodel.FollowTargetInput{AuthorID: ptr(authorID.String())}},
+		{name: "tag follow", target: gqlmodel.FollowTargetInput{Tag: ptr("go")}, affected: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, f := newFakeResolver(t)
+			f.on(`FROM "users"`).returns(columns("id"), []driver.Value{authorID.String()})
+			f.on(`FROM "tags"`).returns(columns("id"), []driver.Value{uuid.NewString()})
+			f.on(`^INSERT INTO "(author|tag)_follows" .*ON CONFLICT DO NOTHING`).affects(tt.affected)
+
+			ok, err := r.Mutation().Follow(auth.WithContext(context.Background(), user), tt.target)
+			if err != nil || !ok {
+				t.Fatalf("Follow() = %v, %v", ok, err)
+			}
+			if len(f.executed(`^INSERT INTO "(author|tag)_follows"`)) != 1 {
+				t.Error("did not insert the follow")
+			}
+			notified := f.executed(`INSERT INTO notifications`)
+			if (len(notified) > 0) != tt.wantNotify {
+				t.Fatalf("notifications = %v, want notified %v", notified, tt.wantNotify)
+			}
+			if tt.wantNotify && (notified[0].args[1] != authorID.String() || notified[0].args[3] != user.UserID.String()) {
+				t.Errorf("notification args = %v, want the author notified of the follower", notified[0].args)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package resolver
+
+import (
+	"context"
+	"os"
+
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+)
+
+// fallbackLogger WithLogger を通らない呼び出し(テストなど)で使う
+var fallbackLogger = log.New(os.Stderr)
+
+// logger リクエストに紐づいたロガーを返す
+// リクエストID・ユーザーID・GraphQLの操作名は ctx から出力される
+func logger(ctx context.Context) *log.Logger {
+	if l, ok := log.FromContext(ctx); ok {
+		return l
+	}
+	return fallbackLogger
+}
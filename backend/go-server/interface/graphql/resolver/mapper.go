@@ -0,0 +1,107 @@
+package resolver
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+)
+
+// optionalString 空文字の場合はnullとして返す
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func toGQLAuthor(u *domainmodel.User) *gqlmodel.Author {
+	if u.ID == uuid.Nil { // Check if Author is valid
+		return &gqlmodel.Author{}
+	}
+	return &gqlmodel.Author{
+		ID:       u.ID.String(),
+		Username: u.Username,
+		Name:     u.Name,
+		Avatar:   u.Avatar,
+		Bio:      optionalString(u.Bio),
+		Website:  optionalString(u.Website),
+		Twitter:  optionalString(u.Twitter),
+		Github:   optionalString(u.GitHub),
+	}
+}
+
+// toGQLArticle Author と Tags はプリロード済みであること
+func toGQLArticle(article *domainmodel.Article) *gqlmodel.Article {
+	var publishedAtStr string
+	if article.PublishedAt != nil {
+		publishedAtStr = article.PublishedAt.String()
+	}
+	tags := make([]string, 0, len(article.Tags))
+	for _, tag := range article.Tags {
+		tags = append(tags, tag.Name)
+	}
+
+	return &gqlmodel.Article{
+		ID:          article.ID.String(),
+		Title:       article.Title,
+		Content:     article.Content,
+		Excerpt:     article.Excerpt,
+		PublishedAt: publishedAtStr,
+		Author:      toGQLAuthor(&article.Author),
+		Tags:        tags,
+		Likes:       0,                     // Resolved by articleResolver.Likes
+		Comments:    []*gqlmodel.Comment{}, // Resolved by articleResolver.Comments
+		ReadingTime: nil,
+	}
+}
+
+// toGQLComment User はプリロード済みであること
+func toGQLComment(comment *domainmodel.Comment) *gqlmodel.Comment {
+	var createdAtStr string
+	if !comment.CreatedAt.IsZero() {
+		createdAtStr = comment.CreatedAt.String()
+	}
+	var parentID *string
+	if comment.ParentID != nil {
+		id := comment.ParentID.String()
+		parentID = &id
+	}
+	return &gqlmodel.Comment{
+		ID:        comment.ID.String(),
+		Content:   comment.Content,
+		CreatedAt: createdAtStr,
+		Author:    toGQLAuthor(&comment.User),
+		ParentID:  parentID,
+		Replies:   []*gqlmodel.Comment{}, // Populated by loadCommentThreads
+		Status:    gqlmodel.CommentStatus(comment.Status),
+	}
+}
+
+// toGQLNotification 関連(Actor, Article, Comment)はプリロード済みであること
+func toGQLNotification(n *domainmodel.Notification) *gqlmodel.Notification {
+	gqlNotification := &gqlmodel.Notification{
+		ID:        n.ID.String(),
+		Type:      gqlmodel.NotificationType(strings.ToUpper(n.Type)),
+		Read:      n.ReadAt != nil,
+		CreatedAt: n.CreatedAt.String(),
+	}
+	if n.Actor != nil {
+		gqlNotification.Actor = toGQLAuthor(n.Actor)
+	}
+	if n.Article != nil {
+		gqlNotification.Article = toGQLArticle(n.Article)
+	}
+	if n.Comment != nil {
+		gqlNotification.Comment = toGQLComment(n.Comment)
+	}
+	return gqlNotification
+}
@@ -0,0 +1,130 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	// 画像サイズの取得に必要なデコーダーを登録する
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/imaging"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+)
+
+// allowedMediaTypes アップロードを受け付けるMIMEタイプと保存時の拡張子
+var allowedMediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// readUpload アップロードされたファイルを上限サイズまで読み込む
+func readUpload(r io.Reader, limit int64) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limit {
+		return nil, domainerrors.InvalidArgument("file is too large (max %d bytes)", limit)
+	}
+	if len(b) == 0 {
+		return nil, domainerrors.InvalidArgument("file is empty")
+	}
+	return b, nil
+}
+
+// storeMediaObject アップロードされた内容のハッシュをキーにしてストレージに保存する
+// 同じ内容のオブジェクトが既に存在する場合は保存しない
+// 公開されるファイルに撮影場所が残らないよう、保存前にEXIFの位置情報を取り除く
+func (r *Resolver) storeMediaObject(ctx context.Context, b []byte, mimeType string) (checksum, key string, size int64, err error) {
+	sum := sha256.Sum256(b)
+	checksum = hex.EncodeToString(sum[:])
+	key = fmt.Sprintf("media/%s/%s%s", checksum[:2], checksum, allowedMediaTypes[mimeType])
+
+	b = imaging.StripGPS(b)
+	size = int64(len(b))
+
+	exists, err := r.Storage.Exists(ctx, key)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("failed to check storage: %w", err)
+	}
+	if !exists {
+		if err := r.Storage.Put(ctx, key, bytes.NewReader(b), size, mimeType); err != nil {
+			return "", "", 0, fmt.Errorf("failed to store media: %w", err)
+		}
+	}
+	return checksum, key, size, nil
+}
+
+func detectMediaType(b []byte) (string, error) {
+	mimeType := http.DetectContentType(b)
+	if _, ok := allowedMediaTypes[mimeType]; !ok {
+		return "", domainerrors.InvalidArgument("unsupported media type: %s", mimeType)
+	}
+	return mimeType, nil
+}
+
+// imageSize サイズを読めない画像は0を返す。画素数が上限を超える画像はデコードできないので拒否する
+func imageSize(b []byte, maxPixels int64) (width, height int, err error) {
+	width, height, err = imaging.CheckPixels(b, maxPixels)
+	if errors.Is(err, imaging.ErrTooManyPixels) {
+		return 0, 0, domainerrors.InvalidArgument("image is too large (%dx%d, max %d pixels)", width, height, maxPixels)
+	}
+	if err != nil {
+		return 0, 0, nil
+	}
+	return width, height, nil
+}
+
+func (r *Resolver) toGQLMedia(m *domainmodel.Media) *gqlmodel.Media {
+	gqlMedia := &gqlmodel.Media{
+		ID:        m.ID.String(),
+		URL:       r.Storage.URL(m.StorageKey),
+		FileName:  m.FileName,
+		MimeType:  m.MimeType,
+		Size:      int(m.Size),
+		Checksum:  m.Checksum,
+		Status:    gqlmodel.MediaStatus(strings.ToUpper(m.Status)),
+		CreatedAt: m.CreatedAt.String(),
+	}
+	if m.Width > 0 && m.Height > 0 {
+		gqlMedia.Width = &m.Width
+		gqlMedia.Height = &m.Height
+	}
+	return gqlMedia
+}
+
+func (r *Resolver) toGQLMediaVariant(v *domainmodel.MediaVariant) *gqlmodel.MediaVariant {
+	return &gqlmodel.MediaVariant{
+		URL:    r.Storage.URL(v.StorageKey),
+		Format: gqlmodel.ImageFormat(strings.ToUpper(v.Format)),
+		Width:  v.Width,
+		Height: v.Height,
+		Size:   int(v.Size),
+	}
+}
+
+// fetchVariants 横幅の小さい順にバリアントを返す
+func (r *Resolver) fetchVariants(ctx context.Context, mediaID string, format *gqlmodel.ImageFormat) ([]*domainmodel.MediaVariant, error) {
+	q := r.DB.WithContext(ctx).Where("media_id = ?", mediaID)
+	if format != nil {
+		q = q.Where("format = ?", strings.ToLower(format.String()))
+	}
+	var variants []*domainmodel.MediaVariant
+	err := q.Order("width asc").Order("format asc").Find(&variants).Error
+	return variants, err
+}
@@ -0,0 +1,140 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	mediaproc "github.com/s-blog/backend/go-server/infrastructure/media"
+	"github.com/s-blog/backend/go-server/interface/graphql/generated"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// CoverImage is the resolver for the coverImage field.
+func (r *articleResolver) CoverImage(ctx context.Context, obj *gqlmodel.Article) (*gqlmodel.Media, error) {
+	var media domainmodel.Media
+	err := r.DB.WithContext(ctx).Joins("JOIN articles ON articles.cover_id = media.id").
+		Where("articles.id = ?", obj.ID).
+		First(&media).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger(ctx).Error(ctx, "failed to fetch cover image", zap.String("article_id", obj.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return r.toGQLMedia(&media), nil
+}
+
+// Variants is the resolver for the variants field.
+func (r *mediaResolver) Variants(ctx context.Context, obj *gqlmodel.Media, format *gqlmodel.ImageFormat) ([]*gqlmodel.MediaVariant, error) {
+	variants, err := r.fetchVariants(ctx, obj.ID, format)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch media variants", zap.String("media_id", obj.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	gqlVariants := make([]*gqlmodel.MediaVariant, 0, len(variants))
+	for _, v := range variants {
+		gqlVariants = append(gqlVariants, r.toGQLMediaVariant(v))
+	}
+	return gqlVariants, nil
+}
+
+// Srcset is the resolver for the srcset field.
+func (r *mediaResolver) Srcset(ctx context.Context, obj *gqlmodel.Media, format gqlmodel.ImageFormat) (*string, error) {
+	variants, err := r.fetchVariants(ctx, obj.ID, &format)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch media variants", zap.String("media_id", obj.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	if len(variants) == 0 {
+		return nil, nil
+	}
+
+	candidates := make([]string, 0, len(variants))
+	for _, v := range variants {
+		candidates = append(candidates, fmt.Sprintf("%s %dw", r.Storage.URL(v.StorageKey), v.Width))
+	}
+	srcset := strings.Join(candidates, ", ")
+	return &srcset, nil
+}
+
+// UploadMedia is the resolver for the uploadMedia field.
+func (r *mutationResolver) UploadMedia(ctx context.Context, file graphql.Upload) (*gqlmodel.Media, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := readUpload(file.File, r.MaxUploadBytes)
+	if err != nil {
+		return nil, err
+	}
+	mimeType, err := detectMediaType(b)
+	if err != nil {
+		return nil, err
+	}
+	width, height, err := imageSize(b, r.MaxImagePixels)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum, key, size, err := r.storeMediaObject(ctx, b, mimeType)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to store media", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	// 同じユーザーが同じ内容のファイルをアップロード済みならそれを返す
+	var media domainmodel.Media
+	err = r.DB.WithContext(ctx).Where("owner_id = ? AND checksum = ?", user.UserID, checksum).First(&media).Error
+	if err == nil {
+		logger(ctx).Debug(ctx, "media already uploaded", zap.String("checksum", checksum))
+		return r.toGQLMedia(&media), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger(ctx).Error(ctx, "failed to look up media", zap.String("checksum", checksum), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	media = *domainmodel.NewMedia(uuid.New(), user.UserID, file.Filename, mimeType, size, checksum, key)
+	media.Width, media.Height = width, height
+	if !mediaproc.Processable(mimeType) {
+		media.Status = domainmodel.MediaStatusReady
+	}
+	if err := r.DB.WithContext(ctx).Create(&media).Error; err != nil {
+		logger(ctx).Error(ctx, "failed to create media record", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	logger(ctx).Info(ctx, "uploaded media", zap.Stringer("media_id", media.ID), zap.String("mime_type", mimeType), zap.Int64("size", media.Size))
+
+	if media.Status == domainmodel.MediaStatusPending {
+		// キューが満杯でも、起動時のResumePendingで後から処理される
+		if err := r.MediaProcessor.Enqueue(media.ID); err != nil {
+			logger(ctx).Warn(ctx, "failed to enqueue media for processing", zap.Stringer("media_id", media.ID), zap.Error(err))
+		}
+	}
+
+	return r.toGQLMedia(&media), nil
+}
+
+// Media returns generated.MediaResolver implementation.
+func (r *Resolver) Media() generated.MediaResolver { return &mediaResolver{r} }
+
+// Mutation returns generated.MutationResolver implementation.
+func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+
+type mediaResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
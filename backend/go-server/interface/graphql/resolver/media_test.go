@@ -0,0 +1,112 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/s-blog/backend/go-server/infrastructure/storage"
+)
+
+// countingStorage Put の回数を数える
+type countingStorage struct {
+	*storage.Memory
+	puts int
+}
+
+func (s *countingStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	s.puts++
+	return s.Memory.Put(ctx, key, r, size, contentType)
+}
+
+func TestStoreMediaObjectDedupe(t *testing.T) {
+	ctx := context.Background()
+	st := &countingStorage{Memory: storage.NewMemory("")}
+	r := &Resolver{Storage: st}
+
+	// EXIFを含まないので、内容はそのまま保存される
+	first := encodePNG(t, color.White)
+	other := encodePNG(t, color.Black)
+
+	tests := []struct {
+		name     string
+		content  []byte
+		mimeType string
+		wantKey  string
+		wantPuts int
+	}{
+		{
+			name:     "first upload is stored",
+			content:  first,
+			mimeType: "image/png",
+			wantKey:  "media/%s/%s.png",
+			wantPuts: 1,
+		},
+		{
+			name:     "same content is not stored again",
+			content:  first,
+			mimeType: "image/png",
+			wantKey:  "media/%s/%s.png",
+			wantPuts: 1,
+		},
+		{
+			name:     "different content gets its own key",
+			content:  other,
+			mimeType: "image/png",
+			wantKey:  "media/%s/%s.png",
+			wantPuts: 2,
+		},
+	}
+
+	keys := map[string]string{}
+	for _, tt := range tests {
+		checksum, key, size, err := r.storeMediaObject(ctx, tt.content, tt.mimeType)
+		if err != nil {
+			t.Fatalf("%s: storeMediaObject() error = %v", tt.name, err)
+		}
+		if len(checksum) != 64 {
+			t.Errorf("%s: checksum %q is not a hex SHA-256", tt.name, checksum)
+		}
+		want := strings.Replace(strings.Replace(tt.wantKey, "%s", checksum[:2], 1), "%s", checksum, 1)
+		if key != want {
+			t.Errorf("%s: key = %q, want %q", tt.name, key, want)
+		}
+		if size != int64(len(tt.content)) {
+			t.Errorf("%s: size = %d, want %d", tt.name, size, len(tt.content))
+		}
+		if st.puts != tt.wantPuts {
+			t.Errorf("%s: Put called %d times, want %d", tt.name, st.puts, tt.wantPuts)
+		}
+		if prev, ok := keys[string(tt.content)]; ok && prev != key {
+			t.Errorf("%s: same content stored under %q and %q", tt.name, prev, key)
+		}
+		keys[string(tt.content)] = key
+
+		if ok, _ := st.Exists(ctx, key); !ok {
+			t.Errorf("%s: %s does not exist after storing", tt.name, key)
+		}
+	}
+	if keys[string(first)] == keys[string(other)] {
+		t.Error("different content shares a key")
+	}
+}
+
+func encodePNG(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 2, 2))
+	for x := range 2 {
+		for y := range 2 {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
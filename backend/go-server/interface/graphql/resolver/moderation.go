@@ -0,0 +1,136 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/domain/event"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/notification"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxBannedWordLength = 100
+
+var errCommentNotPending = domainerrors.Conflict("comment is not pending moderation")
+
+// requireEditor 編集者または管理者のみ許可する
+func requireEditor(ctx context.Context) (*auth.Principal, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != auth.RoleEditor && user.Role != auth.RoleAdmin {
+		return nil, errForbidden
+	}
+	return user, nil
+}
+
+func normalizeBannedWord(word string) (string, error) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" {
+		return "", domainerrors.InvalidArgument("word must not be empty")
+	}
+	if utf8.RuneCountInString(word) > maxBannedWordLength {
+		return "", domainerrors.InvalidArgument("word must be at most %d characters", maxBannedWordLength)
+	}
+	return word, nil
+}
+
+func listBannedWords(db *gorm.DB) ([]string, error) {
+	words := []string{}
+	err := db.Model(&domainmodel.BannedWord{}).Order("word asc").Pluck("word", &words).Error
+	return words, err
+}
+
+// commentAddedEvent 保存済みのコメントから通知用のイベントを組み立てる
+func commentAddedEvent(tx *gorm.DB, comment *domainmodel.Comment) (event.CommentAdded, error) {
+	e := event.CommentAdded{
+		CommentID:   comment.ID,
+		ArticleID:   comment.ArticleID,
+		CommenterID: comment.UserID,
+	}
+	var article domainmodel.Article
+	if err := tx.Select("id", "author_id").First(&article, "id = ?", comment.ArticleID).Error; err != nil {
+		return e, err
+	}
+	e.ArticleAuthorID = article.AuthorID
+	if comment.ParentID != nil {
+		var parent domainmodel.Comment
+		if err := tx.Select("id", "user_id").First(&parent, "id = ?", *comment.ParentID).Error; err != nil {
+			return e, err
+		}
+		e.ParentAuthorID = &parent.UserID
+	}
+	return e, nil
+}
+
+// moderateComment モデレーション待ちのコメントを公開または却下する
+// 公開した場合は投稿時に保留していた通知と配信を行う
+func (r *Resolver) moderateComment(ctx context.Context, id string, approve bool) (*gqlmodel.Comment, error) {
+	if _, err := requireEditor(ctx); err != nil {
+		return nil, err
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, domainerrors.InvalidArgument("invalid comment ID format")
+	}
+
+	var comment domainmodel.Comment
+	err = r.Transactor.Transaction(ctx, func(tx *gorm.DB) error {
+		comment = domainmodel.Comment{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&comment, "id = ?", parsedID).Error
+		if err != nil {
+			return err
+		}
+		if comment.Status != domainmodel.CommentStatusPending {
+			return errCommentNotPending
+		}
+
+		if !approve {
+			comment.Status = domainmodel.CommentStatusRejected
+			return tx.Model(&comment).Update("status", comment.Status).Error
+		}
+		comment.Status = domainmodel.CommentStatusPublished
+		comment.ModerationReason = ""
+		err = tx.Model(&comment).Updates(map[string]any{
+			"status":            comment.Status,
+			"moderation_reason": comment.ModerationReason,
+		}).Error
+		if err != nil {
+			return err
+		}
+		e, err := commentAddedEvent(tx, &comment)
+		if err != nil {
+			return err
+		}
+		return notification.Record(tx, e)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NotFound("comment not found")
+		}
+		if errors.Is(err, errCommentNotPending) {
+			return nil, err
+		}
+		logger(ctx).Error(ctx, "failed to moderate comment", zap.String("comment_id", id), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	if err := r.DB.WithContext(ctx).Preload("User").First(&comment, "id = ?", comment.ID).Error; err != nil {
+		logger(ctx).Error(ctx, "failed to fetch comment", zap.Stringer("comment_id", comment.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	if approve {
+		r.publish(ctx, commentAddedTopic(comment.ArticleID), commentAddedMessage{CommentID: comment.ID})
+	}
+	return toGQLComment(&comment), nil
+}
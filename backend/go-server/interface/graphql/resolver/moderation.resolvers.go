@@ -0,0 +1,121 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// ModerationReason is the resolver for the moderationReason field.
+func (r *commentResolver) ModerationReason(ctx context.Context, obj *gqlmodel.Comment) (*string, error) {
+	if obj.Status == gqlmodel.CommentStatusPublished {
+		return nil, nil
+	}
+	if _, err := requireEditor(ctx); err != nil {
+		return nil, nil
+	}
+	var comment domainmodel.Comment
+	if err := r.DB.WithContext(ctx).Select("moderation_reason").First(&comment, "id = ?", obj.ID).Error; err != nil {
+		logger(ctx).Error(ctx, "failed to fetch moderation reason", zap.String("comment_id", obj.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return optionalString(comment.ModerationReason), nil
+}
+
+// ApproveComment is the resolver for the approveComment field.
+func (r *mutationResolver) ApproveComment(ctx context.Context, id string) (*gqlmodel.Comment, error) {
+	return r.moderateComment(ctx, id, true)
+}
+
+// RejectComment is the resolver for the rejectComment field.
+func (r *mutationResolver) RejectComment(ctx context.Context, id string) (*gqlmodel.Comment, error) {
+	return r.moderateComment(ctx, id, false)
+}
+
+// AddBannedWord is the resolver for the addBannedWord field.
+func (r *mutationResolver) AddBannedWord(ctx context.Context, word string) ([]string, error) {
+	if _, err := requireEditor(ctx); err != nil {
+		return nil, err
+	}
+	word, err := normalizeBannedWord(word)
+	if err != nil {
+		return nil, err
+	}
+	db := r.DB.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(domainmodel.NewBannedWord(uuid.New(), word)).Error; err != nil {
+		logger(ctx).Error(ctx, "failed to add banned word", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	words, err := listBannedWords(db)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch banned words", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return words, nil
+}
+
+// RemoveBannedWord is the resolver for the removeBannedWord field.
+func (r *mutationResolver) RemoveBannedWord(ctx context.Context, word string) ([]string, error) {
+	if _, err := requireEditor(ctx); err != nil {
+		return nil, err
+	}
+	word, err := normalizeBannedWord(word)
+	if err != nil {
+		return nil, err
+	}
+	db := r.DB.WithContext(ctx)
+	if err := db.Delete(&domainmodel.BannedWord{}, "word = ?", word).Error; err != nil {
+		logger(ctx).Error(ctx, "failed to remove banned word", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	words, err := listBannedWords(db)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch banned words", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return words, nil
+}
+
+// ModerationQueue is the resolver for the moderationQueue field.
+func (r *queryResolver) ModerationQueue(ctx context.Context, first *int) ([]*gqlmodel.Comment, error) {
+	if _, err := requireEditor(ctx); err != nil {
+		return nil, err
+	}
+	var comments []*domainmodel.Comment
+	err := r.DB.WithContext(ctx).Preload("User").
+		Where("status = ?", domainmodel.CommentStatusPending).
+		Order("created_at asc").
+		Limit(pageSize(first)).
+		Find(&comments).Error
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch moderation queue", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	gqlComments := make([]*gqlmodel.Comment, 0, len(comments))
+	for _, comment := range comments {
+		gqlComments = append(gqlComments, toGQLComment(comment))
+	}
+	return gqlComments, nil
+}
+
+// BannedWords is the resolver for the bannedWords field.
+func (r *queryResolver) BannedWords(ctx context.Context) ([]string, error) {
+	if _, err := requireEditor(ctx); err != nil {
+		return nil, err
+	}
+	words, err := listBannedWords(r.DB.WithContext(ctx))
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch banned words", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return words, nil
+}
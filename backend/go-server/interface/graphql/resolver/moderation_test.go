@@ -0,0 +1,186 @@
+package resolver
+
+import (
+	"context"
+	"database/sql/driver"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/pubsub"
+)
+
+func TestModerateComment(t *testing.T) {
+	commentID := uuid.New()
+	articleID := uuid.New()
+	articleAuthorID := uuid.New()
+	commenterID := uuid.New()
+	parentID := uuid.New()
+	parentAuthorID := uuid.New()
+	editor := &auth.Principal{UserID: uuid.New(), Role: auth.RoleEditor}
+
+	tests := []struct {
+		name     string
+		user     *auth.Principal
+		approve  bool
+		status   string
+		reply    bool
+		notFound bool
+		wantErr  func(error) bool
+		// wantStatus 更新後の状態。空の場合は更新しない
+		wantStatus string
+		// wantNotified 通知を受け取るユーザーと種類
+		wantNotified []string
+	}{
+		{
+			name:         "approve notifies the article author",
+			user:         editor,
+			approve:      true,
+			status:       domainmodel.CommentStatusPending,
+			wantStatus:   domainmodel.CommentStatusPublished,
+			wantNotified: []string{articleAuthorID.String() + " " + domainmodel.NotificationTypeCommentOnArticle},
+		},
+		{
+			name:       "approve a reply also notifies the parent author",
+			user:       &auth.Principal{UserID: uuid.New(), Role: auth.RoleAdmin},
+			approve:    true,
+			status:     domainmodel.CommentStatusPending,
+			reply:      true,
+			wantStatus: domainmodel.CommentStatusPublished,
+			wantNotified: []string{
+				articleAuthorID.String() + " " + domainmodel.NotificationTypeCommentOnArticle,
+				parentAuthorID.String() + " " + domainmodel.NotificationTypeCommentReply,
+			},
+		},
+		{
+			name:       "reject does not notify",
+			user:       editor,
+			status:     domainmodel.CommentStatusPending,
+			wantStatus: domainmodel.CommentStatusRejected,
+		},
+		{
+			name:    "already published",
+			user:    editor,
+			approve: true,
+			status:  domainmodel.CommentStatusPublished,
+			wantErr: hasCode(domainerrors.CodeConflict),
+		},
+		{
+			name:    "already rejected",
+			user:    editor,
+			approve: true,
+			status:  domainmodel.CommentStatusRejected,
+			wantErr: hasCode(domainerrors.CodeConflict),
+		},
+		{
+			name:     "comment not found",
+			user:     editor,
+			approve:  true,
+			notFound: true,
+			wantErr:  hasCode(domainerrors.CodeNotFound),
+		},
+		{
+			name:    "readers cannot moderate",
+			user:    &auth.Principal{UserID: uuid.New(), Role: auth.RoleReader},
+			approve: true,
+			status:  domainmodel.CommentStatusPending,
+			wantErr: hasCode(domainerrors.CodeForbidden),
+		},
+		{
+			name:    "unauthenticated",
+			approve: true,
+			status:  domainmodel.CommentStatusPending,
+			wantErr: hasCode(domainerrors.CodeUnauthenticated),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, f := newFakeResolver(t)
+			var parent any
+			if tt.reply {
+				parent = parentID.String()
+			}
+			lock := f.on(`FROM "comments" .*FOR UPDATE`)
+			if !tt.notFound {
+				lock.returns(columns("id, article_id, user_id, parent_id, status, moderation_reason"),
+					[]driver.Value{commentID.String(), articleID.String(), commenterID.String(), parent, tt.status, "too many links"})
+			}
+			f.on(`FROM "articles"`).returns(columns("id, author_id"), []driver.Value{articleID.String(), articleAuthorID.String()})
+			f.on(`SELECT "id","user_id" FROM "comments"`).returns(columns("id, user_id"), []driver.Value{parentID.String(), parentAuthorID.String()})
+			f.on(`FROM "comments"`).returns(columns("id, article_id, user_id"), []driver.Value{commentID.String(), articleID.String(), commenterID.String()})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			messages, err := r.PubSub.(*pubsub.Memory).Subscribe(ctx, commentAddedTopic(articleID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.user != nil {
+				ctx = auth.WithContext(ctx, tt.user)
+			}
+
+			m := r.Mutation()
+			moderate := m.RejectComment
+			if tt.approve {
+				moderate = m.ApproveComment
+			}
+			comment, err := moderate(ctx, commentID.String())
+
+			updates := f.executed(`^UPDATE "comments"`)
+			notified := notifiedUsers(f)
+			published := len(messages) > 0
+
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("error = %v", err)
+				}
+				if len(updates) > 0 || len(notified) > 0 || published {
+					t.Errorf("updated %d, notified %q, published %v: want nothing changed", len(updates), notified, published)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if string(comment.Status) != tt.wantStatus {
+				t.Errorf("status = %s, want %s", comment.Status, tt.wantStatus)
+			}
+			if len(updates) != 1 || !containsArg(updates[0], tt.wantStatus) {
+				t.Errorf("updates = %v, want the status set to %s", updates, tt.wantStatus)
+			}
+			sort.Strings(tt.wantNotified)
+			if strings.Join(notified, ", ") != strings.Join(tt.wantNotified, ", ") {
+				t.Errorf("notified %q, want %q", notified, tt.wantNotified)
+			}
+			// 承認したコメントだけをサブスクリプションに配信する
+			if published != tt.approve {
+				t.Errorf("published = %v, want %v", published, tt.approve)
+			}
+		})
+	}
+}
+
+// notifiedUsers 作成した通知の "受信者 種類" を並べ替えて返す
+func notifiedUsers(f *fakeDB) []string {
+	var notified []string
+	for _, q := range f.executed(`^INSERT INTO "notifications"`) {
+		for _, row := range q.inserted() {
+			notified = append(notified, row["recipient_id"].(string)+" "+row["type"].(string))
+		}
+	}
+	sort.Strings(notified)
+	return notified
+}
+
+func containsArg(q fakeQuery, want any) bool {
+	for _, a := range q.args {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"errors"
+
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	"github.com/s-blog/backend/go-server/infrastructure/newsletter"
+	"go.uber.org/zap"
+)
+
+// Subscribe is the resolver for the subscribe field.
+func (r *mutationResolver) Subscribe(ctx context.Context, email string) (bool, error) {
+	if err := r.Newsletter.Subscribe(ctx, email); err != nil {
+		if errors.Is(err, newsletter.ErrInvalidEmail) {
+			return false, err
+		}
+		logger(ctx).Error(ctx, "failed to subscribe to newsletter", zap.Error(err))
+		return false, domainerrors.ErrInternal
+	}
+	return true, nil
+}
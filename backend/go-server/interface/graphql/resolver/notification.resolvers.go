@@ -0,0 +1,115 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+)
+
+// MarkNotificationsRead is the resolver for the markNotificationsRead field.
+func (r *mutationResolver) MarkNotificationsRead(ctx context.Context, ids []string) (int, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	q := r.DB.WithContext(ctx).Model(&domainmodel.Notification{}).
+		Where("recipient_id = ? AND read_at IS NULL", user.UserID)
+	if ids != nil {
+		parsedIDs := make([]uuid.UUID, 0, len(ids))
+		for _, id := range ids {
+			parsedID, err := uuid.Parse(id)
+			if err != nil {
+				return 0, domainerrors.InvalidArgument("invalid notification ID format")
+			}
+			parsedIDs = append(parsedIDs, parsedID)
+		}
+		if len(parsedIDs) == 0 {
+			return 0, nil
+		}
+		q = q.Where("id IN ?", parsedIDs)
+	}
+
+	res := q.Update("read_at", time.Now())
+	if res.Error != nil {
+		logger(ctx).Error(ctx, "failed to mark notifications read", zap.Error(res.Error))
+		return 0, domainerrors.ErrInternal
+	}
+	return int(res.RowsAffected), nil
+}
+
+// Notifications is the resolver for the notifications field.
+func (r *queryResolver) Notifications(ctx context.Context, first *int, after *string, unreadOnly *bool) (*gqlmodel.NotificationConnection, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := pageSize(first)
+	q := r.DB.WithContext(ctx).Where("recipient_id = ?", user.UserID)
+	if unreadOnly != nil && *unreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+	if after != nil && *after != "" {
+		c, err := decodeCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(created_at, id) < (?, ?)", c.Time, c.ID)
+	}
+
+	var domainNotifications []*domainmodel.Notification
+	err = q.Order("created_at desc").
+		Order("id desc").
+		Limit(limit + 1).
+		Preload("Actor").
+		Preload("Article.Author").
+		Preload("Article.Tags").
+		Preload("Comment.User").
+		Find(&domainNotifications).Error
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch notifications", zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	hasNext := len(domainNotifications) > limit
+	if hasNext {
+		domainNotifications = domainNotifications[:limit]
+	}
+	conn := &gqlmodel.NotificationConnection{
+		Edges:    make([]*gqlmodel.NotificationEdge, 0, len(domainNotifications)),
+		PageInfo: &gqlmodel.PageInfo{HasNextPage: hasNext},
+	}
+	for _, n := range domainNotifications {
+		c := encodeCursor(n.CreatedAt, n.ID)
+		conn.Edges = append(conn.Edges, &gqlmodel.NotificationEdge{Cursor: c, Node: toGQLNotification(n)})
+		conn.PageInfo.EndCursor = &c
+	}
+	return conn, nil
+}
+
+// UnreadNotificationCount is the resolver for the unreadNotificationCount field.
+func (r *queryResolver) UnreadNotificationCount(ctx context.Context) (int, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = r.DB.WithContext(ctx).Model(&domainmodel.Notification{}).
+		Where("recipient_id = ? AND read_at IS NULL", user.UserID).
+		Count(&count).Error
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to count unread notifications", zap.Error(err))
+		return 0, domainerrors.ErrInternal
+	}
+	return int(count), nil
+}
@@ -0,0 +1,118 @@
+package resolver
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+var errInvalidCursor = domainerrors.InvalidArgument("invalid cursor")
+
+// cursor 日時とIDによるキーセットページネーションのカーソル
+type cursor struct {
+	Time time.Time
+	ID   uuid.UUID
+}
+
+func encodeCursor(t time.Time, id uuid.UUID) string {
+	raw := t.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(b), "|")
+	if !ok {
+		return nil, errInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return &cursor{Time: t, ID: parsedID}, nil
+}
+
+func encodeArticleCursor(a *domainmodel.Article) string {
+	var publishedAt time.Time
+	if a.PublishedAt != nil {
+		publishedAt = *a.PublishedAt
+	}
+	return encodeCursor(publishedAt, a.ID)
+}
+
+func pageSize(first *int) int {
+	if first == nil || *first <= 0 {
+		return defaultPageSize
+	}
+	if *first > maxPageSize {
+		return maxPageSize
+	}
+	return *first
+}
+
+// publishedArticles 公開済みの記事に絞り込む
+func publishedArticles(q *gorm.DB) *gorm.DB {
+	return q.Where("articles.published_at IS NOT NULL AND articles.published_at <= ?", time.Now())
+}
+
+// paginateArticles 公開日時の新しい順に1ページ分の記事を取得する
+// qは絞り込み条件だけを設定したarticlesのクエリ
+func paginateArticles(q *gorm.DB, first *int, after *string) (*gqlmodel.ArticleConnection, error) {
+	limit := pageSize(first)
+	q = publishedArticles(q)
+	if after != nil && *after != "" {
+		c, err := decodeCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(articles.published_at, articles.id) < (?, ?)", c.Time, c.ID)
+	}
+
+	var domainArticles []*domainmodel.Article
+	err := q.Order("articles.published_at desc").
+		Order("articles.id desc").
+		Limit(limit + 1).
+		Preload("Author").
+		Preload("Tags").
+		Find(&domainArticles).Error
+	if err != nil {
+		return nil, err
+	}
+
+	hasNext := len(domainArticles) > limit
+	if hasNext {
+		domainArticles = domainArticles[:limit]
+	}
+	return toArticleConnection(domainArticles, hasNext), nil
+}
+
+func toArticleConnection(articles []*domainmodel.Article, hasNext bool) *gqlmodel.ArticleConnection {
+	conn := &gqlmodel.ArticleConnection{
+		Edges:    make([]*gqlmodel.ArticleEdge, 0, len(articles)),
+		PageInfo: &gqlmodel.PageInfo{HasNextPage: hasNext},
+	}
+	for _, a := range articles {
+		cursor := encodeArticleCursor(a)
+		conn.Edges = append(conn.Edges, &gqlmodel.ArticleEdge{Cursor: cursor, Node: toGQLArticle(a)})
+		conn.PageInfo.EndCursor = &cursor
+	}
+	return conn
+}
@@ -0,0 +1,108 @@
+package resolver
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+)
+
+const (
+	maxBioLength  = 500
+	maxNameLength = 100
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+	// handlePattern X(Twitter)とGitHubのハンドル。先頭の@は保存時に取り除く
+	handlePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,39}$`)
+)
+
+// applyProfileInput 入力を検証してユーザーに反映する。nilのフィールドは変更しない
+func applyProfileInput(u *domainmodel.User, input gqlmodel.UpdateProfileInput) error {
+	if input.Username != nil {
+		username := strings.ToLower(strings.TrimSpace(*input.Username))
+		if !usernamePattern.MatchString(username) {
+			return domainerrors.InvalidArgument("username must be 3-30 characters of a-z, 0-9 or _")
+		}
+		u.Username = username
+	}
+	if input.Name != nil {
+		name := strings.TrimSpace(*input.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			return domainerrors.InvalidArgument("name must be 1-%d characters", maxNameLength)
+		}
+		u.Name = name
+	}
+	if input.Avatar != nil {
+		if err := validateURL(*input.Avatar); err != nil {
+			return fmt.Errorf("avatar: %w", err)
+		}
+		u.Avatar = strings.TrimSpace(*input.Avatar)
+	}
+	if input.Bio != nil {
+		bio := strings.TrimSpace(*input.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			return domainerrors.InvalidArgument("bio must be at most %d characters", maxBioLength)
+		}
+		u.Bio = bio
+	}
+	if input.Website != nil {
+		if err := validateURL(*input.Website); err != nil {
+			return fmt.Errorf("website: %w", err)
+		}
+		u.Website = strings.TrimSpace(*input.Website)
+	}
+	if input.Twitter != nil {
+		handle, err := normalizeHandle(*input.Twitter)
+		if err != nil {
+			return fmt.Errorf("twitter: %w", err)
+		}
+		u.Twitter = handle
+	}
+	if input.Github != nil {
+		handle, err := normalizeHandle(*input.Github)
+		if err != nil {
+			return fmt.Errorf("github: %w", err)
+		}
+		u.GitHub = handle
+	}
+	return nil
+}
+
+// validateURL 空文字(削除)かhttp/httpsのURLのみ受け付ける
+func validateURL(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return domainerrors.InvalidArgument("must be an http or https URL")
+	}
+	return nil
+}
+
+func normalizeHandle(s string) (string, error) {
+	handle := strings.TrimPrefix(strings.TrimSpace(s), "@")
+	if handle == "" {
+		return "", nil
+	}
+	if !handlePattern.MatchString(handle) {
+		return "", domainerrors.InvalidArgument("invalid handle")
+	}
+	return handle, nil
+}
+
+// isUniqueViolation 一意制約違反(23505)かどうか
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,55 @@
+package resolver
+
+import (
+	"github.com/google/uuid"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRelatedArticles = 5
+	maxRelatedArticles     = 20
+)
+
+func relatedPageSize(first *int) int {
+	if first == nil || *first <= 0 {
+		return defaultRelatedArticles
+	}
+	if *first > maxRelatedArticles {
+		return maxRelatedArticles
+	}
+	return *first
+}
+
+// loadRelatedArticles 事前計算した関連記事を順位の順に返す
+// 計算後に非公開や削除になった記事は除く
+func loadRelatedArticles(db *gorm.DB, articleID uuid.UUID, limit int) ([]*domainmodel.Article, error) {
+	var articles []*domainmodel.Article
+	err := publishedArticles(db.Model(&domainmodel.Article{})).
+		Joins("JOIN related_articles ON related_articles.related_id = articles.id").
+		Where("related_articles.article_id = ?", articleID).
+		Order("related_articles.rank asc").
+		Limit(limit).
+		Preload("Author").
+		Preload("Tags").
+		Find(&articles).Error
+	return articles, err
+}
+
+// sameTagArticles 関連記事が計算前か足りない場合に、同じタグの記事を公開日時の新しい順に返す
+// exclude の記事は除く
+func sameTagArticles(db *gorm.DB, articleID uuid.UUID, exclude []uuid.UUID, limit int) ([]*domainmodel.Article, error) {
+	var articles []*domainmodel.Article
+	err := publishedArticles(db.Model(&domainmodel.Article{})).
+		Where("articles.id IN (?)", db.Table("article_tags AS source").
+			Select("other.article_id").
+			Joins("JOIN article_tags AS other ON other.tag_id = source.tag_id").
+			Where("source.article_id = ?", articleID)).
+		Where("articles.id NOT IN ?", exclude).
+		Order("articles.published_at desc").
+		Limit(limit).
+		Preload("Author").
+		Preload("Tags").
+		Find(&articles).Error
+	return articles, err
+}
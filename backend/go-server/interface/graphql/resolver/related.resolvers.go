@@ -0,0 +1,48 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+)
+
+// RelatedArticles is the resolver for the relatedArticles field.
+func (r *articleResolver) RelatedArticles(ctx context.Context, obj *gqlmodel.Article, first *int) ([]*gqlmodel.Article, error) {
+	articleID, err := uuid.Parse(obj.ID)
+	if err != nil {
+		return []*gqlmodel.Article{}, nil
+	}
+	limit := relatedPageSize(first)
+	db := r.reader(ctx)
+
+	articles, err := loadRelatedArticles(db, articleID, limit)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch related articles", zap.String("article_id", obj.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	if len(articles) < limit {
+		exclude := []uuid.UUID{articleID}
+		for _, a := range articles {
+			exclude = append(exclude, a.ID)
+		}
+		fallback, err := sameTagArticles(db, articleID, exclude, limit-len(articles))
+		if err != nil {
+			logger(ctx).Error(ctx, "failed to fetch same tag articles", zap.String("article_id", obj.ID), zap.Error(err))
+			return nil, domainerrors.ErrInternal
+		}
+		articles = append(articles, fallback...)
+	}
+
+	gqlArticles := make([]*gqlmodel.Article, 0, len(articles))
+	for _, article := range articles {
+		gqlArticles = append(gqlArticles, toGQLArticle(article))
+	}
+	return gqlArticles, nil
+}
@@ -4,9 +4,49 @@ package resolver
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
-import "gorm.io/gorm"
+import (
+	"context"
+
+	"github.com/s-blog/backend/go-server/domain/repository"
+	infragorm "github.com/s-blog/backend/go-server/infrastructure/gorm"
+	"github.com/s-blog/backend/go-server/infrastructure/media"
+	"github.com/s-blog/backend/go-server/infrastructure/newsletter"
+	"github.com/s-blog/backend/go-server/infrastructure/pubsub"
+	"github.com/s-blog/backend/go-server/infrastructure/related"
+	"github.com/s-blog/backend/go-server/infrastructure/spam"
+	"github.com/s-blog/backend/go-server/infrastructure/storage"
+	"gorm.io/gorm"
+)
 
 // Resolver はGraphQLリゾルバー
 type Resolver struct {
+	// DB プライマリ。書き込みと、書き込み直後の読み取りに使う
 	DB *gorm.DB
+	// Router 公開されている読み取り専用の問い合わせをレプリカに振り分ける
+	Router *infragorm.Router
+	// Transactor 一時的なエラーで失敗したトランザクションをやり直す
+	Transactor repository.Transactor
+	Storage    storage.Storage
+	// MediaProcessor アップロードされた画像のバリアントをバックグラウンドで生成する
+	MediaProcessor *media.Processor
+	// Newsletter 購読の受付と記事公開時のメール配信
+	Newsletter *newsletter.Service
+	// Related 記事の公開時に関連記事をバックグラウンドで計算する
+	Related *related.Recommender
+	// PubSub サブスクリプション向けにコメント追加やいいね数の変化を配信する
+	PubSub pubsub.Broker
+	// SpamChecker 保存前のコメントを判定し、疑わしいものをモデレーション待ちにする
+	SpamChecker *spam.Chain
+	// MaxUploadBytes アップロードを受け付けるファイルサイズの上限
+	MaxUploadBytes int64
+	// MaxImagePixels アップロードを受け付ける画像の画素数の上限
+	MaxImagePixels int64
+}
+
+// reader 読み取り専用の問い合わせに使う。Router がない場合はプライマリを使う
+func (r *Resolver) reader(ctx context.Context) *gorm.DB {
+	if r.Router == nil {
+		return r.DB.WithContext(ctx)
+	}
+	return r.Router.Reader(ctx)
 }
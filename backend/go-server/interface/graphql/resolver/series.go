@@ -0,0 +1,173 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxSeriesTitleLength       = 200
+	maxSeriesSlugLength        = 200
+	maxSeriesDescriptionLength = 1000
+)
+
+var (
+	seriesSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+	errSeriesArticleNotOwned = domainerrors.InvalidArgument("articles must exist and be written by the series author")
+	errSeriesArticleTaken    = domainerrors.Conflict("article already belongs to another series")
+	errSeriesSlugTaken       = domainerrors.Conflict("slug is already taken")
+)
+
+// normalizeSeriesInput 入力を検証して連載を作る
+func normalizeSeriesInput(input gqlmodel.CreateSeriesInput, authorID uuid.UUID) (*domainmodel.Series, error) {
+	title := strings.TrimSpace(input.Title)
+	if title == "" || utf8.RuneCountInString(title) > maxSeriesTitleLength {
+		return nil, domainerrors.InvalidArgument("title must be 1-%d characters", maxSeriesTitleLength)
+	}
+	slug := strings.ToLower(strings.TrimSpace(input.Slug))
+	if len(slug) > maxSeriesSlugLength || !seriesSlugPattern.MatchString(slug) {
+		return nil, domainerrors.InvalidArgument("slug must be at most %d characters of a-z, 0-9 or -", maxSeriesSlugLength)
+	}
+	description := strings.TrimSpace(stringValue(input.Description))
+	if utf8.RuneCountInString(description) > maxSeriesDescriptionLength {
+		return nil, domainerrors.InvalidArgument("description must be at most %d characters", maxSeriesDescriptionLength)
+	}
+	return domainmodel.NewSeries(uuid.New(), title, slug, description, authorID), nil
+}
+
+// parseSeriesArticleIDs 同じ記事を2回指定した場合はエラー
+func parseSeriesArticleIDs(ids []string) ([]uuid.UUID, error) {
+	parsed := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		articleID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, domainerrors.InvalidArgument("invalid article ID format")
+		}
+		if seen[articleID] {
+			return nil, domainerrors.InvalidArgument("article %s is listed more than once", id)
+		}
+		seen[articleID] = true
+		parsed = append(parsed, articleID)
+	}
+	return parsed, nil
+}
+
+// replaceSeriesArticles 連載のパートを articleIDs の順に置き換える。トランザクションの中で呼ぶ
+// 記事は連載の著者のもので、他の連載に含まれていないこと
+func replaceSeriesArticles(tx *gorm.DB, series *domainmodel.Series, articleIDs []uuid.UUID) error {
+	if len(articleIDs) > 0 {
+		var owned int64
+		err := tx.Model(&domainmodel.Article{}).
+			Where("id IN ? AND author_id = ?", articleIDs, series.AuthorID).
+			Count(&owned).Error
+		if err != nil {
+			return err
+		}
+		if int(owned) != len(articleIDs) {
+			return errSeriesArticleNotOwned
+		}
+
+		var taken int64
+		err = tx.Model(&domainmodel.SeriesArticle{}).
+			Where("article_id IN ? AND series_id <> ?", articleIDs, series.ID).
+			Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return errSeriesArticleTaken
+		}
+	}
+
+	// 位置の一意制約に掛からないよう、並べ替えは削除してから作り直す
+	if err := tx.Where("series_id = ?", series.ID).Delete(&domainmodel.SeriesArticle{}).Error; err != nil {
+		return err
+	}
+	if len(articleIDs) == 0 {
+		return nil
+	}
+	return tx.Create(domainmodel.NewSeriesArticles(series.ID, articleIDs)).Error
+}
+
+// seriesConflict 同時に更新された場合の一意制約違反を、どの制約かに応じたエラーにする
+func seriesConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		return nil
+	}
+	if strings.Contains(pgErr.ConstraintName, "slug") {
+		return errSeriesSlugTaken
+	}
+	return errSeriesArticleTaken
+}
+
+// publishedSeriesArticles 連載の公開済みのパートを順番に返す
+func publishedSeriesArticles(db *gorm.DB, seriesID uuid.UUID) ([]*domainmodel.Article, error) {
+	var articles []*domainmodel.Article
+	err := publishedArticles(db.Model(&domainmodel.Article{})).
+		Joins("JOIN series_articles ON series_articles.article_id = articles.id").
+		Where("series_articles.series_id = ?", seriesID).
+		Order("series_articles.position asc").
+		Preload("Author").
+		Preload("Tags").
+		Find(&articles).Error
+	return articles, err
+}
+
+// toGQLSeries Author はプリロード済みであること
+func toGQLSeries(s *domainmodel.Series) *gqlmodel.Series {
+	return &gqlmodel.Series{
+		ID:          s.ID.String(),
+		Title:       s.Title,
+		Slug:        s.Slug,
+		Description: optionalString(s.Description),
+		Author:      toGQLAuthor(&s.Author),
+		Articles:    []*gqlmodel.Article{}, // Resolved by seriesResolver.Articles
+	}
+}
+
+// toGQLSeriesPart articles は公開済みのパートを順番に並べたもの
+// articleID が含まれていない場合は nil を返す
+func toGQLSeriesPart(series *domainmodel.Series, articles []*domainmodel.Article, articleID uuid.UUID) *gqlmodel.SeriesPart {
+	for i, a := range articles {
+		if a.ID != articleID {
+			continue
+		}
+		part := &gqlmodel.SeriesPart{
+			Series:   toGQLSeries(series),
+			Position: i + 1,
+			Total:    len(articles),
+		}
+		if i > 0 {
+			part.Previous = toGQLArticle(articles[i-1])
+		}
+		if i < len(articles)-1 {
+			part.Next = toGQLArticle(articles[i+1])
+		}
+		return part
+	}
+	return nil
+}
+
+// loadSeries 書き込み直後に返すので、レプリカではなくプライマリから読む
+func (r *mutationResolver) loadSeries(ctx context.Context, id uuid.UUID) (*gqlmodel.Series, error) {
+	var series domainmodel.Series
+	if err := r.DB.WithContext(ctx).Preload("Author").First(&series, "id = ?", id).Error; err != nil {
+		logger(ctx).Error(ctx, "failed to fetch series", zap.String("series_id", id.String()), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return toGQLSeries(&series), nil
+}
@@ -0,0 +1,170 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/interface/graphql/generated"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Series is the resolver for the series field.
+func (r *articleResolver) Series(ctx context.Context, obj *gqlmodel.Article) (*gqlmodel.SeriesPart, error) {
+	articleID, err := uuid.Parse(obj.ID)
+	if err != nil {
+		return nil, nil
+	}
+	db := r.reader(ctx)
+
+	var seriesArticle domainmodel.SeriesArticle
+	err = db.Preload("Series.Author").First(&seriesArticle, "article_id = ?", articleID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		logger(ctx).Error(ctx, "failed to fetch series of article", zap.String("article_id", obj.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	if seriesArticle.Series.ID == uuid.Nil {
+		// 削除済みの連載
+		return nil, nil
+	}
+
+	articles, err := publishedSeriesArticles(db, seriesArticle.SeriesID)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch series articles", zap.String("series_id", seriesArticle.SeriesID.String()), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	// 下書きのパートは公開済みのパートの番号や前後の記事に含めない
+	return toGQLSeriesPart(&seriesArticle.Series, articles, articleID), nil
+}
+
+// CreateSeries is the resolver for the createSeries field.
+func (r *mutationResolver) CreateSeries(ctx context.Context, input gqlmodel.CreateSeriesInput) (*gqlmodel.Series, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	series, err := normalizeSeriesInput(input, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	articleIDs, err := parseSeriesArticleIDs(input.ArticleIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.Transactor.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(series).Error; err != nil {
+			return err
+		}
+		return replaceSeriesArticles(tx, series, articleIDs)
+	})
+	if err != nil {
+		if errors.Is(err, errSeriesArticleNotOwned) || errors.Is(err, errSeriesArticleTaken) {
+			return nil, err
+		}
+		if conflict := seriesConflict(err); conflict != nil {
+			return nil, conflict
+		}
+		logger(ctx).Error(ctx, "failed to create series", zap.String("slug", series.Slug), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	logger(ctx).Info(ctx, "created series", zap.String("series_id", series.ID.String()))
+
+	return r.loadSeries(ctx, series.ID)
+}
+
+// ReorderSeries is the resolver for the reorderSeries field.
+func (r *mutationResolver) ReorderSeries(ctx context.Context, id string, articleIds []string) (*gqlmodel.Series, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, domainerrors.InvalidArgument("invalid series ID format")
+	}
+	articleIDs, err := parseSeriesArticleIDs(articleIds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.Transactor.Transaction(ctx, func(tx *gorm.DB) error {
+		// 同じ連載を同時に並べ替えた場合に、位置の一意制約で失敗しないよう直列にする
+		var series domainmodel.Series
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&series, "id = ?", parsedID).Error
+		if err != nil {
+			return err
+		}
+		if series.AuthorID != user.UserID {
+			return errForbidden
+		}
+		return replaceSeriesArticles(tx, &series, articleIDs)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NotFound("series not found")
+		}
+		if errors.Is(err, errForbidden) || errors.Is(err, errSeriesArticleNotOwned) || errors.Is(err, errSeriesArticleTaken) {
+			return nil, err
+		}
+		if conflict := seriesConflict(err); conflict != nil {
+			return nil, conflict
+		}
+		logger(ctx).Error(ctx, "failed to reorder series", zap.String("series_id", id), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	return r.loadSeries(ctx, parsedID)
+}
+
+// Series is the resolver for the series field.
+func (r *queryResolver) Series(ctx context.Context, slug string) (*gqlmodel.Series, error) {
+	var series domainmodel.Series
+	err := r.reader(ctx).Preload("Author").First(&series, "slug = ?", slug).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NotFound("series not found")
+		}
+		logger(ctx).Error(ctx, "failed to fetch series", zap.String("slug", slug), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	return toGQLSeries(&series), nil
+}
+
+// Articles is the resolver for the articles field.
+func (r *seriesResolver) Articles(ctx context.Context, obj *gqlmodel.Series) ([]*gqlmodel.Article, error) {
+	seriesID, err := uuid.Parse(obj.ID)
+	if err != nil {
+		return []*gqlmodel.Article{}, nil
+	}
+	articles, err := publishedSeriesArticles(r.reader(ctx), seriesID)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch series articles", zap.String("series_id", obj.ID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	gqlArticles := make([]*gqlmodel.Article, 0, len(articles))
+	for _, article := range articles {
+		gqlArticles = append(gqlArticles, toGQLArticle(article))
+	}
+	return gqlArticles, nil
+}
+
+// Series returns generated.SeriesResolver implementation.
+func (r *Resolver) Series() generated.SeriesResolver { return &seriesResolver{r} }
+
+type seriesResolver struct{ *Resolver }
@@ -0,0 +1,319 @@
+package resolver
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/morikuni/failure"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+)
+
+func TestToGQLSeriesPart(t *testing.T) {
+	series := &domainmodel.Series{ID: uuid.New(), Title: "Go入門", Slug: "go"}
+	published := make([]*domainmodel.Article, 3)
+	for i := range published {
+		published[i] = &domainmodel.Article{ID: uuid.New(), Title: fmt.Sprintf("part %d", i+1)}
+	}
+	// 下書きのパートは publishedSeriesArticles の結果に含まれない
+	draft := uuid.New()
+
+	tests := []struct {
+		name         string
+		articleID    uuid.UUID
+		wantNil      bool
+		wantPosition int
+		wantPrevious string
+		wantNext     string
+	}{
+		{name: "first part", articleID: published[0].ID, wantPosition: 1, wantNext: "part 2"},
+		{name: "middle part", articleID: published[1].ID, wantPosition: 2, wantPrevious: "part 1", wantNext: "part 3"},
+		{name: "last part", articleID: published[2].ID, wantPosition: 3, wantPrevious: "part 2"},
+		{name: "draft part", articleID: draft, wantNil: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part := toGQLSeriesPart(series, published, tt.articleID)
+			if tt.wantNil {
+				if part != nil {
+					t.Fatalf("toGQLSeriesPart() = %+v, want nil", part)
+				}
+				return
+			}
+			if part == nil {
+				t.Fatal("toGQLSeriesPart() = nil")
+			}
+			if part.Position != tt.wantPosition || part.Total != len(published) {
+				t.Errorf("position = %d/%d, want %d/%d", part.Position, part.Total, tt.wantPosition, len(published))
+			}
+			if got := articleTitle(part.Previous); got != tt.wantPrevious {
+				t.Errorf("previous = %q, want %q", got, tt.wantPrevious)
+			}
+			if got := articleTitle(part.Next); got != tt.wantNext {
+				t.Errorf("next = %q, want %q", got, tt.wantNext)
+			}
+			if part.Series.Slug != series.Slug {
+				t.Errorf("series = %q, want %q", part.Series.Slug, series.Slug)
+			}
+		})
+	}
+}
+
+func articleTitle(a *gqlmodel.Article) string {
+	if a == nil {
+		return ""
+	}
+	return a.Title
+}
+
+func TestSeriesConflict(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "slug", err: &pgconn.PgError{Code: "23505", ConstraintName: "uni_series_slug"}, want: errSeriesSlugTaken},
+		{name: "article", err: &pgconn.PgError{Code: "23505", ConstraintName: "idx_series_articles_article_id"}, want: errSeriesArticleTaken},
+		{name: "position", err: &pgconn.PgError{Code: "23505", ConstraintName: "idx_series_articles_position"}, want: errSeriesArticleTaken},
+		{name: "wrapped", err: fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505", ConstraintName: "uni_series_slug"}), want: errSeriesSlugTaken},
+		{name: "other constraint violation", err: &pgconn.PgError{Code: "23503"}},
+		{name: "not a postgres error", err: errors.New("boom")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := seriesConflict(tt.err); got != tt.want {
+				t.Errorf("seriesConflict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeriesArticleIDs(t *testing.T) {
+	a, b := uuid.NewString(), uuid.NewString()
+	tests := []struct {
+		name    string
+		ids     []string
+		wantErr bool
+	}{
+		{name: "keeps the order", ids: []string{b, a}},
+		{name: "empty", ids: []string{}},
+		{name: "invalid", ids: []string{a, "x"}, wantErr: true},
+		{name: "duplicate", ids: []string{a, b, a}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSeriesArticleIDs(tt.ids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSeriesArticleIDs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !failure.Is(err, domainerrors.CodeInvalidArgument) {
+					t.Errorf("error = %v, want an invalid argument error", err)
+				}
+				return
+			}
+			for i, id := range got {
+				if id.String() != tt.ids[i] {
+					t.Errorf("ids[%d] = %s, want %s", i, id, tt.ids[i])
+				}
+			}
+		})
+	}
+}
+
+// hasCode err が code のエラーかを判定する関数を返す
+func hasCode(code failure.StringCode) func(error) bool {
+	return func(err error) bool { return failure.Is(err, code) }
+}
+
+var (
+	seriesColumns = columns("id, title, slug, author_id")
+	userColumns   = columns("id, username, name")
+	countColumns  = columns("count")
+)
+
+func TestReorderSeries(t *testing.T) {
+	authorID := uuid.New()
+	seriesID := uuid.New()
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+	order := []string{third.String(), first.String(), second.String()}
+
+	seriesRow := []driver.Value{seriesID.String(), "Go入門", "go", authorID.String()}
+
+	tests := []struct {
+		name       string
+		user       *auth.Principal
+		noSeries   bool
+		setup      func(f *fakeDB)
+		wantErr    func(error) bool
+		wantInsert bool
+	}{
+		{
+			name: "replaces the parts in the given order",
+			user: &auth.Principal{UserID: authorID},
+			setup: func(f *fakeDB) {
+				f.on(`FROM "articles"`).returns(countColumns, []driver.Value{int64(3)})
+				f.on(`FROM "series_articles"`).returns(countColumns, []driver.Value{int64(0)})
+			},
+			wantInsert: true,
+		},
+		{
+			name:    "unauthenticated",
+			wantErr: hasCode(domainerrors.CodeUnauthenticated),
+		},
+		{
+			name:     "series does not exist",
+			user:     &auth.Principal{UserID: authorID},
+			noSeries: true,
+			wantErr:  hasCode(domainerrors.CodeNotFound),
+		},
+		{
+			name:    "someone else's series",
+			user:    &auth.Principal{UserID: uuid.New()},
+			wantErr: hasCode(domainerrors.CodeForbidden),
+		},
+		{
+			name: "article of another author",
+			user: &auth.Principal{UserID: authorID},
+			setup: func(f *fakeDB) {
+				f.on(`FROM "articles"`).returns(countColumns, []driver.Value{int64(2)})
+			},
+			wantErr: hasCode(domainerrors.CodeInvalidArgument),
+		},
+		{
+			name: "article in another series",
+			user: &auth.Principal{UserID: authorID},
+			setup: func(f *fakeDB) {
+				f.on(`FROM "articles"`).returns(countColumns, []driver.Value{int64(3)})
+				f.on(`FROM "series_articles"`).returns(countColumns, []driver.Value{int64(1)})
+			},
+			wantErr: hasCode(domainerrors.CodeConflict),
+		},
+		{
+			name: "article added to another series concurrently",
+			user: &auth.Principal{UserID: authorID},
+			setup: func(f *fakeDB) {
+				f.on(`FROM "articles"`).returns(countColumns, []driver.Value{int64(3)})
+				f.on(`FROM "series_articles"`).returns(countColumns, []driver.Value{int64(0)})
+				f.on(`^INSERT INTO "series_articles"`).fails(&pgconn.PgError{Code: "23505", ConstraintName: "idx_series_articles_article_id"})
+			},
+			wantErr:    hasCode(domainerrors.CodeConflict),
+			wantInsert: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, f := newFakeResolver(t)
+			lock := f.on(`FROM "series" .*FOR UPDATE`)
+			if !tt.noSeries {
+				lock.returns(seriesColumns, seriesRow)
+			}
+			if tt.setup != nil {
+				tt.setup(f)
+			}
+			f.on(`FROM "series"`).returns(seriesColumns, seriesRow)
+			f.on(`FROM "users"`).returns(userColumns, []driver.Value{authorID.String(), "author", "Author"})
+
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = auth.WithContext(ctx, tt.user)
+			}
+			got, err := r.Mutation().ReorderSeries(ctx, seriesID.String(), order)
+
+			inserts := f.executed(`^INSERT INTO "series_articles"`)
+			if (len(inserts) > 0) != tt.wantInsert {
+				t.Errorf("inserted parts = %v, want %v", len(inserts) > 0, tt.wantInsert)
+			}
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("ReorderSeries() error = %v", err)
+				}
+				if tt.user != nil && len(f.executed("^COMMIT$")) > 0 {
+					t.Error("transaction was committed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReorderSeries() error = %v", err)
+			}
+			if got.ID != seriesID.String() || got.Author.Name != "Author" {
+				t.Errorf("ReorderSeries() = %+v", got)
+			}
+
+			// 連載の行をロックしてから、パートを削除して作り直す
+			locked := f.indexOf(`FOR UPDATE`)
+			deleted := f.indexOf(`^DELETE FROM "series_articles"`)
+			inserted := f.indexOf(`^INSERT INTO "series_articles"`)
+			committed := f.indexOf(`^COMMIT$`)
+			if locked < 0 || locked > deleted || deleted > inserted || inserted > committed {
+				t.Errorf("lock %d, delete %d, insert %d, commit %d: want them in this order", locked, deleted, inserted, committed)
+			}
+			args := inserts[0].args
+			if len(args) != 3*len(order) {
+				t.Fatalf("insert args = %v, want 3 per part", args)
+			}
+			for i, id := range order {
+				if args[3*i] != seriesID.String() || args[3*i+1] != id || args[3*i+2] != int64(i) {
+					t.Errorf("part %d = %v, want %s at position %d", i, args[3*i:3*i+3], id, i)
+				}
+			}
+		})
+	}
+}
+
+func TestArticleSeries(t *testing.T) {
+	authorID := uuid.New()
+	seriesID := uuid.New()
+	parts := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	published := time.Now().Add(-time.Hour)
+
+	r, f := newFakeResolver(t)
+	f.on(`FROM "series_articles"`).returns(columns("series_id, article_id, position"),
+		[]driver.Value{seriesID.String(), parts[1].String(), int64(2)})
+	f.on(`FROM "series"`).returns(seriesColumns, []driver.Value{seriesID.String(), "Go入門", "go", authorID.String()})
+	f.on(`FROM "users"`).returns(userColumns, []driver.Value{authorID.String(), "author", "Author"})
+	// 下書きのパート (position 1) は公開日時の条件で除かれる
+	f.on(`FROM "articles" JOIN series_articles`).returns(columns("id, title, author_id, published_at"),
+		[]driver.Value{parts[0].String(), "part 1", authorID.String(), published},
+		[]driver.Value{parts[1].String(), "part 3", authorID.String(), published},
+	)
+
+	part, err := r.Article().Series(context.Background(), &gqlmodel.Article{ID: parts[1].String()})
+	if err != nil {
+		t.Fatalf("Series() error = %v", err)
+	}
+	if part == nil {
+		t.Fatal("Series() = nil")
+	}
+	if part.Position != 2 || part.Total != 2 {
+		t.Errorf("position = %d/%d, want 2/2", part.Position, part.Total)
+	}
+	if articleTitle(part.Previous) != "part 1" || part.Next != nil {
+		t.Errorf("previous = %q, next = %q, want part 1 and none", articleTitle(part.Previous), articleTitle(part.Next))
+	}
+	if part.Series.Author.Name != "Author" {
+		t.Errorf("series author = %q, want Author", part.Series.Author.Name)
+	}
+
+	q := f.executed(`FROM "articles" JOIN series_articles`)
+	if len(q) != 1 || !strings.Contains(q[0].sql, "articles.published_at IS NOT NULL AND articles.published_at <=") {
+		t.Errorf("parts query = %v, want it to exclude drafts", q)
+	}
+
+	t.Run("not in a series", func(t *testing.T) {
+		r, _ := newFakeResolver(t)
+		part, err := r.Article().Series(context.Background(), &gqlmodel.Article{ID: uuid.NewString()})
+		if err != nil || part != nil {
+			t.Errorf("Series() = %+v, %v, want nil", part, err)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pub/subのメッセージはIDなど最小限にし、受信側でDBから読み直す
+// (NOTIFYのペイロード上限と、レプリカ間で同じ表現を保つため)
+
+type commentAddedMessage struct {
+	CommentID uuid.UUID `json:"comment_id"`
+}
+
+type likesChangedMessage struct {
+	ArticleID uuid.UUID `json:"article_id"`
+	Likes     int       `json:"likes"`
+}
+
+func commentAddedTopic(articleID uuid.UUID) string {
+	return "comment_added:" + articleID.String()
+}
+
+func likesChangedTopic(articleID uuid.UUID) string {
+	return "article_likes_changed:" + articleID.String()
+}
+
+// publish コミット後に呼ぶ。配信に失敗しても書き込み自体は成功として扱う
+func (r *Resolver) publish(ctx context.Context, topic string, msg any) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to encode message", zap.String("topic", topic), zap.Error(err))
+		return
+	}
+	if err := r.PubSub.Publish(ctx, topic, b); err != nil {
+		logger(ctx).Error(ctx, "failed to publish message", zap.String("topic", topic), zap.Error(err))
+	}
+}
+
+func countLikes(db *gorm.DB, articleID string) (int, error) {
+	var count int64
+	err := db.Model(&domainmodel.ArticleLike{}).Where("article_id = ?", articleID).Count(&count).Error
+	return int(count), err
+}
+
+// changeLike いいねの追加・取り消しを行い、変更後のいいね数を購読者に配信する
+func (r *Resolver) changeLike(ctx context.Context, articleID string, like bool) (*gqlmodel.ArticleLikes, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parsedID, err := uuid.Parse(articleID)
+	if err != nil {
+		return nil, domainerrors.InvalidArgument("invalid article ID format")
+	}
+
+	db := r.DB.WithContext(ctx)
+	if err := publishedArticles(db).Select("id").First(&domainmodel.Article{}, "id = ?", parsedID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainerrors.NotFound("article not found")
+		}
+		logger(ctx).Error(ctx, "failed to fetch article", zap.String("article_id", articleID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+
+	var res *gorm.DB
+	if like {
+		res = db.Clauses(clause.OnConflict{DoNothing: true}).Create(domainmodel.NewArticleLike(parsedID, user.UserID))
+	} else {
+		res = db.Delete(&domainmodel.ArticleLike{}, "article_id = ? AND user_id = ?", parsedID, user.UserID)
+	}
+	if res.Error != nil {
+		logger(ctx).Error(ctx, "failed to change like", zap.String("article_id", articleID), zap.Error(res.Error))
+		return nil, domainerrors.ErrInternal
+	}
+
+	likes, err := countLikes(db, parsedID.String())
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to count likes", zap.String("article_id", articleID), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	if res.RowsAffected > 0 {
+		r.publish(ctx, likesChangedTopic(parsedID), likesChangedMessage{ArticleID: parsedID, Likes: likes})
+	}
+	return &gqlmodel.ArticleLikes{ArticleID: parsedID.String(), Likes: likes}, nil
+}
+
+// subscribe トピックのメッセージをdecodeで変換して流す。ctxが終わると購読を解除してチャネルを閉じる
+func subscribe[T any](ctx context.Context, r *Resolver, topic string, decode func(context.Context, []byte) (T, bool)) (<-chan T, error) {
+	msgs, err := r.PubSub.Subscribe(ctx, topic)
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to subscribe", zap.String("topic", topic), zap.Error(err))
+		return nil, domainerrors.ErrInternal
+	}
+	out := make(chan T, 1)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case b, ok := <-msgs:
+				if !ok {
+					return
+				}
+				v, ok := decode(ctx, b)
+				if !ok {
+					continue
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
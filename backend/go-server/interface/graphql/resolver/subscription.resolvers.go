@@ -0,0 +1,86 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/s-blog/backend/go-server/domain/errors"
+	domainmodel "github.com/s-blog/backend/go-server/domain/model"
+	"github.com/s-blog/backend/go-server/interface/graphql/generated"
+	gqlmodel "github.com/s-blog/backend/go-server/interface/graphql/model"
+	"go.uber.org/zap"
+)
+
+// LikedByMe is the resolver for the likedByMe field.
+func (r *articleResolver) LikedByMe(ctx context.Context, obj *gqlmodel.Article) (bool, error) {
+	user, err := currentUser(ctx)
+	if err != nil {
+		return false, nil
+	}
+	var count int64
+	err = r.DB.WithContext(ctx).Model(&domainmodel.ArticleLike{}).
+		Where("article_id = ? AND user_id = ?", obj.ID, user.UserID).
+		Count(&count).Error
+	if err != nil {
+		logger(ctx).Error(ctx, "failed to fetch like", zap.String("article_id", obj.ID), zap.Error(err))
+		return false, domainerrors.ErrInternal
+	}
+	return count > 0, nil
+}
+
+// LikeArticle is the resolver for the likeArticle field.
+func (r *mutationResolver) LikeArticle(ctx context.Context, articleID string) (*gqlmodel.ArticleLikes, error) {
+	return r.changeLike(ctx, articleID, true)
+}
+
+// UnlikeArticle is the resolver for the unlikeArticle field.
+func (r *mutationResolver) UnlikeArticle(ctx context.Context, articleID string) (*gqlmodel.ArticleLikes, error) {
+	return r.changeLike(ctx, articleID, false)
+}
+
+// CommentAdded is the resolver for the commentAdded field.
+func (r *subscriptionResolver) CommentAdded(ctx context.Context, articleID string) (<-chan *gqlmodel.Comment, error) {
+	parsedID, err := uuid.Parse(articleID)
+	if err != nil {
+		return nil, domainerrors.InvalidArgument("invalid article ID format")
+	}
+	return subscribe(ctx, r.Resolver, commentAddedTopic(parsedID), func(ctx context.Context, b []byte) (*gqlmodel.Comment, bool) {
+		var msg commentAddedMessage
+		if err := json.Unmarshal(b, &msg); err != nil {
+			logger(ctx).Error(ctx, "failed to decode commentAdded message", zap.Error(err))
+			return nil, false
+		}
+		var comment domainmodel.Comment
+		if err := r.DB.WithContext(ctx).Preload("User").First(&comment, "id = ?", msg.CommentID).Error; err != nil {
+			logger(ctx).Error(ctx, "failed to fetch comment", zap.Stringer("comment_id", msg.CommentID), zap.Error(err))
+			return nil, false
+		}
+		return toGQLComment(&comment), true
+	})
+}
+
+// ArticleLikesChanged is the resolver for the articleLikesChanged field.
+func (r *subscriptionResolver) ArticleLikesChanged(ctx context.Context, articleID string) (<-chan *gqlmodel.ArticleLikes, error) {
+	parsedID, err := uuid.Parse(articleID)
+	if err != nil {
+		return nil, domainerrors.InvalidArgument("invalid article ID format")
+	}
+	return subscribe(ctx, r.Resolver, likesChangedTopic(parsedID), func(_ context.Context, b []byte) (*gqlmodel.ArticleLikes, bool) {
+		var msg likesChangedMessage
+		if err := json.Unmarshal(b, &msg); err != nil {
+			logger(ctx).Error(ctx, "failed to decode articleLikesChanged message", zap.Error(err))
+			return nil, false
+		}
+		return &gqlmodel.ArticleLikes{ArticleID: msg.ArticleID.String(), Likes: msg.Likes}, true
+	})
+}
+
+// Subscription returns generated.SubscriptionResolver implementation.
+func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
+
+type subscriptionResolver struct{ *Resolver }
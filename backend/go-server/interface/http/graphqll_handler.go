@@ -1,41 +1,93 @@
 package http
 
 import (
-	"log"
+	"context"
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/99designs/gqlgen/graphql/handler"
-	"github.com/99designs/gqlgen/graphql/playground"
-	"github.com/s-blog/backend/go-server/interface/graphql/generated"
-	"github.com/s-blog/backend/go-server/interface/graphql/resolver"
-	"gorm.io/gorm"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+
+	"go.uber.org/zap"
 )
 
 type GraphQLHandler struct {
-	db *gorm.DB
+	srv http.Handler
 }
 
-func NewGraphQLHandler(db *gorm.DB) *GraphQLHandler {
-	return &GraphQLHandler{db: db}
+// NewGraphQLHandler srv は起動時に一度だけ作ったGraphQLサーバー
+func NewGraphQLHandler(srv http.Handler) *GraphQLHandler {
+	return &GraphQLHandler{srv: srv}
 }
 
 func (h *GraphQLHandler) GraphQL(w http.ResponseWriter, r *http.Request) {
-	// Log incoming request method
-	log.Printf("GraphQL handler received request: Method=%s, URL=%s", r.Method, r.URL.Path)
-
-	// GraphQLサーバーとPlaygroundを設定
-	resolvers := &resolver.Resolver{DB: h.db}
-	srv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolvers}))
-
-	// POSTリクエスト時はGraphQLクエリを処理
-	if r.Method == "POST" {
-		log.Println("GraphQL handler: Processing POST request...") // Log before serving POST
-		srv.ServeHTTP(w, r)
-		log.Println("GraphQL handler: Finished processing POST request.") // Log after serving POST (might not be reached if panic occurs)
-		return
+	ctx := r.Context()
+	logger := log.MustFromContext(ctx)
+	logger.Debug(ctx, "graphql request received", zap.String("method", r.Method))
+	h.srv.ServeHTTP(w, r)
+	logger.Debug(ctx, "graphql request finished") // might not be reached if panic occurs
+}
+
+// NewWebsocketTransport サブスクリプション用のトランスポート
+// ブラウザのWebSocketはCORSの対象外なので、Originをここで検査する
+func NewWebsocketTransport(verifier *auth.Verifier, allowedOrigins []string, conns *WebsocketConnections) transport.Websocket {
+	return transport.Websocket{
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				// ブラウザ以外のクライアントはOriginを送らない
+				return origin == "" || slices.Contains(allowedOrigins, origin)
+			},
+		},
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              websocketInit(verifier, conns),
 	}
+}
+
+// websocketInit ブラウザのWebSocketはヘッダーを付けられないため、
+// connection_initのペイロードで渡されたアクセストークンを検証する
+func websocketInit(verifier *auth.Verifier, conns *WebsocketConnections) transport.WebsocketInitFunc {
+	return func(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		ctx = conns.track(ctx)
+		token, ok := strings.CutPrefix(initPayload.Authorization(), "Bearer ")
+		if !verifier.Enabled() || !ok || token == "" {
+			return ctx, nil, nil
+		}
+		principal, err := verifier.Verify(token)
+		if err != nil {
+			return ctx, nil, errors.New("invalid access token")
+		}
+		return auth.WithContext(ctx, principal), nil, nil
+	}
+}
+
+// WebsocketConnections シャットダウン時に開いているWebSocket接続をまとめて閉じる
+// http.Server.Shutdown はハイジャックされた接続を待たないため、別に閉じる必要がある
+type WebsocketConnections struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewWebsocketConnections() *WebsocketConnections {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebsocketConnections{ctx: ctx, cancel: cancel}
+}
+
+// Close 開いている接続をすべて閉じる。以降に開かれた接続もすぐに閉じる
+func (c *WebsocketConnections) Close() {
+	c.cancel()
+}
 
-	// GETリクエスト時はPlaygroundを表示
-	log.Println("GraphQL handler: Serving Playground...") // Log before serving Playground
-	playground.Handler("GraphQL Playground", "/graphql").ServeHTTP(w, r)
+// track 接続が終わるか Close が呼ばれると終わる ctx を返す
+// gqlgen は InitFunc が返した ctx が終わると接続を閉じる
+func (c *WebsocketConnections) track(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.ctx, cancel)
+	context.AfterFunc(ctx, func() { stop() })
+	return ctx
 }
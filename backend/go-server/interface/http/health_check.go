@@ -1,36 +1,55 @@
 package http
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
+	"github.com/s-blog/backend/go-server/infrastructure/health"
 	"github.com/s-blog/backend/go-server/infrastructure/log"
 
-	"gorm.io/gorm"
-
 	"go.uber.org/zap"
 )
 
 type HealthCheckHandler struct {
-	db *gorm.DB
+	checks *health.Registry
 }
 
-func NewHealthCheckHandler(db *gorm.DB) *HealthCheckHandler {
+func NewHealthCheckHandler(checks *health.Registry) *HealthCheckHandler {
 	return &HealthCheckHandler{
-		db: db,
+		checks: checks,
 	}
 }
 
-func (h *HealthCheckHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	// GORMで簡単なクエリを実行してDBの接続状態を確認
-	var result int
-	err := h.db.Raw("SELECT 1").Scan(&result).Error
-	if err != nil {
-		log.MustFromContext(r.Context()).Error(r.Context(), "failed to check database", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
+// Healthz プロセスが応答できるかだけを返す。依存先は確認しない
+func (h *HealthCheckHandler) Healthz(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = fmt.Fprint(w, "OK")
 }
+
+// Readyz 依存先のチェックをすべて実行し、それぞれの結果をJSONで返す
+// 1つでも失敗しているか、シャットダウン中の場合は503を返す
+// 失敗の理由はログにだけ出力し、レスポンスには含めない
+func (h *HealthCheckHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	report := h.checks.Run(ctx)
+
+	code := http.StatusOK
+	if !report.OK() {
+		code = http.StatusServiceUnavailable
+		logger := log.MustFromContext(ctx)
+		for _, res := range report.Checks {
+			if res.Error != "" {
+				logger.Warn(ctx, "readiness check failed",
+					zap.String("check", res.Name),
+					zap.Float64("latency_ms", res.LatencyMS),
+					zap.String("error", res.Error),
+				)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
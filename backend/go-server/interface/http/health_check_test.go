@@ -0,0 +1,59 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/s-blog/backend/go-server/infrastructure/health"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+)
+
+func TestReadyzHidesErrors(t *testing.T) {
+	const detail = "dial tcp 10.0.3.7:5432: connection refused"
+	checks := health.NewRegistry(time.Second)
+	checks.Register("database", func(context.Context) error { return errors.New(detail) })
+	checks.Register("storage", func(context.Context) error { return nil })
+
+	var logs bytes.Buffer
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	req = req.WithContext(log.WithContext(req.Context(), log.New(&logs)))
+	w := httptest.NewRecorder()
+	NewHealthCheckHandler(checks).Readyz(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if strings.Contains(w.Body.String(), "10.0.3.7") {
+		t.Errorf("response exposes the check error: %s", w.Body.String())
+	}
+	if !strings.Contains(logs.String(), detail) {
+		t.Errorf("log does not contain the check error: %s", logs.String())
+	}
+
+	var report struct {
+		Status string `json:"status"`
+		Checks []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"checks"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if report.Status != health.StatusFailing {
+		t.Errorf("status = %q, want %q", report.Status, health.StatusFailing)
+	}
+	want := map[string]string{"database": health.StatusFailing, "storage": health.StatusOK}
+	for _, c := range report.Checks {
+		if want[c.Name] != c.Status {
+			t.Errorf("check %q status = %q, want %q", c.Name, c.Status, want[c.Name])
+		}
+	}
+}
@@ -1,13 +1,30 @@
 package http
 
 import (
+	"context"
 	"net/http"
+	"strings"
 
+	"github.com/google/uuid"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/clientip"
+	infragorm "github.com/s-blog/backend/go-server/infrastructure/gorm"
 	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
+	"github.com/s-blog/backend/go-server/infrastructure/tracing"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+const (
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength クライアントから受け取るリクエストIDの長さの上限
+	maxRequestIDLength = 128
+)
+
 func WithLogger(next http.HandlerFunc, logger *log.Logger) http.HandlerFunc {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		// Add following fields into logger on request
@@ -18,3 +35,108 @@ func WithLogger(next http.HandlerFunc, logger *log.Logger) http.HandlerFunc {
 	}
 	return fn
 }
+
+// WithRequestID リクエストごとのIDをログに出力し、レスポンスヘッダーで返す
+// 上流のロードバランサーなどが付けた X-Request-ID があればそれを使う
+func WithRequestID(next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := log.WithFields(r.Context(), zap.String("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return fn
+}
+
+// WithDBSession リクエストの中で書き込みがあれば、以降の読み取りをプライマリに固定する
+func WithDBSession(next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(infragorm.WithSession(r.Context())))
+	}
+	return fn
+}
+
+// WithTracing リクエストごとにサーバースパンを作る
+// traceparent ヘッダーがあれば上流のトレースに繋げ、トレースIDをログに出力する
+func WithTracing(next http.HandlerFunc, tp trace.TracerProvider) http.HandlerFunc {
+	inner := func(w http.ResponseWriter, r *http.Request) {
+		sc := trace.SpanContextFromContext(r.Context())
+		if !sc.IsValid() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := log.WithFields(r.Context(), zap.Stringer("trace_id", sc.TraceID()))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+	h := otelhttp.NewHandler(http.HandlerFunc(inner), "http.server",
+		otelhttp.WithTracerProvider(tp),
+		otelhttp.WithPropagators(tracing.Propagator()),
+	)
+	return h.ServeHTTP
+}
+
+type routeKey struct{}
+
+// WithMetrics リクエストの件数と処理時間を記録する
+// パスごとに数えると系列が増えすぎるので、route が返すルーティングのパターンで分ける
+func WithMetrics(next http.HandlerFunc, m *metrics.HTTP, route func(*http.Request) string) http.HandlerFunc {
+	handlerLabel := promhttp.WithLabelFromCtx("handler", func(ctx context.Context) string {
+		r, _ := ctx.Value(routeKey{}).(string)
+		return r
+	})
+	h := promhttp.InstrumentHandlerDuration(m.Duration,
+		promhttp.InstrumentHandlerCounter(m.Requests, next, handlerLabel),
+		handlerLabel,
+	)
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), routeKey{}, route(r))
+		h.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return fn
+}
+
+func WithClientIP(next http.HandlerFunc, resolver *clientip.Resolver) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		ctx := clientip.WithContext(r.Context(), resolver.FromRequest(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return fn
+}
+
+func WithAuth(next http.HandlerFunc, verifier *auth.Verifier) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !verifier.Enabled() || !ok || token == "" {
+			// 未ログインのリクエストはそのまま通す
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := verifier.Verify(token)
+		if err != nil {
+			writeWarning(r.Context(), w, "invalid access token", err)
+			return
+		}
+		ctx := auth.WithContext(r.Context(), principal)
+		ctx = log.WithFields(ctx, zap.String("user_id", principal.UserID.String()))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return fn
+}
+
+// validRequestID ログを汚さないよう、表示可能なASCII文字だけからなるIDのみ受け付ける
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
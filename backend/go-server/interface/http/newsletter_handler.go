@@ -0,0 +1,110 @@
+package http
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/newsletter"
+
+	"go.uber.org/zap"
+)
+
+// newsletterPage メールのリンクを開いたときに表示する確認ページ
+// メールのスキャナーやプリフェッチがGETでリンクを開いても状態が変わらないよう、変更は同じURLへのPOSTで行う
+var newsletterPage = template.Must(template.New("newsletter").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>s-blog ニュースレター</title></head>
+<body style="font-family: sans-serif; line-height: 1.6; color: #111;">
+  <p>{{.Message}}</p>
+  <form method="post">
+    <input type="hidden" name="token" value="{{.Token}}">
+    <button type="submit">{{.Button}}</button>
+  </form>
+</body>
+</html>
+`))
+
+type newsletterPageData struct {
+	Message string
+	Token   string
+	Button  string
+}
+
+type NewsletterHandler struct {
+	svc *newsletter.Service
+}
+
+func NewNewsletterHandler(svc *newsletter.Service) *NewsletterHandler {
+	return &NewsletterHandler{svc: svc}
+}
+
+// Confirm GETでは確認ページを返し、POSTで購読を有効にする
+func (h *NewsletterHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		writeNewsletterPage(w, r, newsletterPageData{
+			Message: "s-blog のニュースレターへの登録を完了します。",
+			Token:   r.URL.Query().Get("token"),
+			Button:  "登録を完了する",
+		})
+		return
+	case http.MethodPost:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := h.svc.Confirm(ctx, r.FormValue("token"))
+	if errors.Is(err, newsletter.ErrInvalidToken) {
+		writeError(ctx, w, http.StatusBadRequest, "リンクが無効か、有効期限が切れています。もう一度登録してください。", err)
+		return
+	}
+	if err != nil {
+		writeError(ctx, w, http.StatusInternalServerError, "登録の確認に失敗しました", err)
+		return
+	}
+	writeSuccess(ctx, w, "ニュースレターの登録が完了しました")
+}
+
+// Unsubscribe GETでは確認ページを返し、POSTで購読を解除する
+// メールクライアントのワンクリック配信停止(RFC 8058)はクエリにトークンを付けたままPOSTする
+func (h *NewsletterHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		writeNewsletterPage(w, r, newsletterPageData{
+			Message: "s-blog のニュースレターの配信を停止します。",
+			Token:   r.URL.Query().Get("token"),
+			Button:  "配信を停止する",
+		})
+		return
+	case http.MethodPost:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := h.svc.Unsubscribe(ctx, r.FormValue("token"))
+	if errors.Is(err, newsletter.ErrInvalidToken) {
+		writeError(ctx, w, http.StatusBadRequest, "リンクが無効です", err)
+		return
+	}
+	if err != nil {
+		writeError(ctx, w, http.StatusInternalServerError, "配信停止に失敗しました", err)
+		return
+	}
+	writeSuccess(ctx, w, "ニュースレターの配信を停止しました")
+}
+
+func writeNewsletterPage(w http.ResponseWriter, r *http.Request, data newsletterPageData) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	if err := newsletterPage.Execute(w, data); err != nil {
+		log.MustFromContext(ctx).Error(ctx, "failed to render newsletter page", zap.Error(err))
+	}
+}
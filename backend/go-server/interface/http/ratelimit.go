@@ -0,0 +1,66 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/s-blog/backend/go-server/infrastructure/ratelimit"
+)
+
+// WithRateLimit GraphQLの操作ごとの回数制限(gqlratelimit)の結果をレスポンスヘッダーで返し、
+// 拒否された場合はステータスを 429 にする
+// WebSocket は接続後の操作ごとに制限するので、アップグレードのリクエストには何もしない
+func WithRateLimit(next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, report := ratelimit.WithReport(r.Context())
+		next.ServeHTTP(&rateLimitWriter{ResponseWriter: w, report: report}, r.WithContext(ctx))
+	}
+	return fn
+}
+
+// rateLimitWriter ステータスを書く時点で、記録された結果をヘッダーに反映する
+type rateLimitWriter struct {
+	http.ResponseWriter
+	report      *ratelimit.Report
+	wroteHeader bool
+}
+
+func (w *rateLimitWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if res, ok := w.report.Result(); ok {
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				code = http.StatusTooManyRequests
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *rateLimitWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap http.ResponseController が元の ResponseWriter を使えるようにする
+func (w *rateLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/s-blog/backend/go-server/infrastructure/ratelimit"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     *ratelimit.Result
+		upgrade    bool
+		wantStatus int
+		wantHeader map[string]string
+	}{
+		{
+			name:       "nothing recorded",
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{
+				"RateLimit-Limit": "",
+				"Retry-After":     "",
+			},
+		},
+		{
+			name:       "allowed",
+			result:     &ratelimit.Result{Allowed: true, Limit: 10, Remaining: 7, Reset: 2500 * time.Millisecond},
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "7",
+				"RateLimit-Reset":     "3",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:       "denied",
+			result:     &ratelimit.Result{Allowed: false, Limit: 10, Remaining: 0, RetryAfter: 1200 * time.Millisecond, Reset: 58 * time.Second},
+			wantStatus: http.StatusTooManyRequests,
+			wantHeader: map[string]string{
+				"RateLimit-Limit":     "10",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "58",
+				"Retry-After":         "2",
+			},
+		},
+		{
+			name:       "websocket upgrades are passed through",
+			result:     &ratelimit.Result{Allowed: false, Limit: 10},
+			upgrade:    true,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{
+				"RateLimit-Limit": "",
+				"Retry-After":     "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := WithRateLimit(func(w http.ResponseWriter, r *http.Request) {
+				if tt.result != nil {
+					ratelimit.Record(r.Context(), *tt.result)
+				}
+				_, _ = w.Write([]byte("{}"))
+			})
+			req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+			if tt.upgrade {
+				req.Header.Set("Upgrade", "websocket")
+			}
+			w := httptest.NewRecorder()
+			h(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			for name, want := range tt.wantHeader {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitWriterUnwrap(t *testing.T) {
+	h := WithRateLimit(func(w http.ResponseWriter, r *http.Request) {
+		// http.ResponseController は Unwrap を辿って Flush を呼ぶ
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush() error = %v", err)
+		}
+	})
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/graphql", nil))
+}
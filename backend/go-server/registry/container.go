@@ -3,6 +3,7 @@ package registry
 import (
 	"github.com/s-blog/backend/go-server/domain/config"
 
+	"go.opentelemetry.io/otel"
 	"gorm.io/gorm"
 )
 
@@ -11,14 +12,18 @@ type Container struct {
 	DB     *gorm.DB
 }
 
-func NewContainer(cfg *config.Vars) (*Container, error) {
-	db, err := gormDBProvider(cfg.Database)
+func NewContainer(cfg *config.Vars) (*Container, func(), error) {
+	creds, err := NewCredentialProvider(cfg.Database)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	db, cleanup, err := gormDBProvider(cfg.Database, creds, otel.GetTracerProvider())
+	if err != nil {
+		return nil, nil, err
 	}
 
 	return &Container{
 		Config: cfg,
 		DB:     db,
-	}, nil
+	}, cleanup, nil
 }
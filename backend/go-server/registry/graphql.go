@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
+	"github.com/s-blog/backend/go-server/infrastructure/ratelimit"
+	"github.com/s-blog/backend/go-server/interface/graphql/allowlist"
+	"github.com/s-blog/backend/go-server/interface/graphql/generated"
+	"github.com/s-blog/backend/go-server/interface/graphql/gqlerrors"
+	"github.com/s-blog/backend/go-server/interface/graphql/gqlmetrics"
+	"github.com/s-blog/backend/go-server/interface/graphql/gqlratelimit"
+	"github.com/s-blog/backend/go-server/interface/graphql/gqltrace"
+	"github.com/s-blog/backend/go-server/interface/graphql/querylimit"
+	"github.com/s-blog/backend/go-server/interface/graphql/resolver"
+	"github.com/s-blog/backend/go-server/interface/http"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// graphqlServerProvider 起動時に一度だけGraphQLサーバーを作る
+// APQとパース済みクエリのキャッシュはリクエスト間で共有される
+func graphqlServerProvider(
+	cfg *config.GraphQL,
+	cors *config.CORS,
+	resolvers *resolver.Resolver,
+	verifier *auth.Verifier,
+	ops *allowlist.Allowlist,
+	tp trace.TracerProvider,
+	m *metrics.Metrics,
+	known gqlmetrics.KnownOperations,
+	conns *http.WebsocketConnections,
+	rl *config.RateLimit,
+	rlStore ratelimit.Store,
+	logger *log.Logger,
+) *handler.Server {
+	srv := handler.New(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  resolvers,
+		Complexity: resolver.Complexity(),
+	}))
+
+	srv.SetErrorPresenter(gqlerrors.Presenter(logger))
+	srv.SetRecoverFunc(gqlerrors.Recover(logger))
+
+	srv.AddTransport(http.NewWebsocketTransport(verifier, cors.AllowedOrigins, conns))
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	// リゾルバーのログに操作名を出力する
+	srv.AroundOperations(func(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+		opCtx := graphql.GetOperationContext(ctx)
+		name := opCtx.OperationName
+		if name == "" && opCtx.Operation != nil {
+			name = opCtx.Operation.Name
+		}
+		ctx = log.WithFields(ctx, zap.String("graphql_operation", name))
+		return next(ctx)
+	})
+
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](cfg.QueryCacheSize))
+
+	// 厳格モードでは許可リストにない操作と一緒にイントロスペクションも拒否する
+	if ops == nil {
+		srv.Use(extension.Introspection{})
+	} else {
+		srv.Use(allowlist.Extension{Allowlist: ops})
+	}
+	srv.Use(&gqlratelimit.Extension{
+		Store: rlStore,
+		Limits: gqlratelimit.Limits{
+			Query:    ratelimit.PerMinute(rl.QueriesPerMinute, rl.QueryBurst),
+			Mutation: ratelimit.PerMinute(rl.MutationsPerMinute, rl.MutationBurst),
+		},
+	})
+	srv.Use(&gqltrace.Extension{TracerProvider: tp})
+	srv.Use(&gqlmetrics.Extension{Metrics: m.GraphQL, Known: known})
+	srv.Use(&querylimit.Extension{Limits: queryLimits(cfg)})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](cfg.APQCacheSize),
+	})
+
+	return srv
+}
+
+// websocketConnectionsProvider HTTPサーバーの停止後に、残っているサブスクリプションの接続を閉じる
+func websocketConnectionsProvider() (*http.WebsocketConnections, func()) {
+	conns := http.NewWebsocketConnections()
+	return conns, conns.Close
+}
+
+func queryLimits(cfg *config.GraphQL) func(ctx context.Context) querylimit.Limits {
+	limits := func(maxComplexity int) querylimit.Limits {
+		return querylimit.Limits{MaxDepth: cfg.MaxDepth, MaxComplexity: maxComplexity}
+	}
+	return querylimit.ByRole(limits(cfg.MaxComplexityAnonymous), map[string]querylimit.Limits{
+		auth.RoleReader: limits(cfg.MaxComplexityReader),
+		auth.RoleEditor: limits(cfg.MaxComplexityEditor),
+		auth.RoleAdmin:  limits(cfg.MaxComplexityEditor),
+	})
+}
@@ -0,0 +1,68 @@
+package registry
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
+	"github.com/s-blog/backend/go-server/infrastructure/ratelimit"
+	"github.com/s-blog/backend/go-server/interface/graphql/generated"
+	"github.com/s-blog/backend/go-server/interface/graphql/resolver"
+	ihttp "github.com/s-blog/backend/go-server/interface/http"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// benchmarkQuery リゾルバーを呼ばずにパースとバリデーションを通るクエリ
+const benchmarkQuery = `{"query":"query Bench { __typename a: __typename b: __typename }"}`
+
+// BenchmarkGraphQLServer リクエストごとにサーバーを作る場合と、起動時に一度だけ作る場合の比較
+func BenchmarkGraphQLServer(b *testing.B) {
+	resolvers := &resolver.Resolver{}
+	logger := log.New(io.Discard)
+
+	b.Run("per-request", func(b *testing.B) {
+		serve(b, logger, func() http.Handler {
+			return handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolvers}))
+		})
+	})
+
+	b.Run("shared", func(b *testing.B) {
+		srv := graphqlServerProvider(
+			&config.GraphQL{MaxDepth: 10, MaxComplexityAnonymous: 5000, QueryCacheSize: 1000, APQCacheSize: 1000},
+			&config.CORS{},
+			resolvers,
+			auth.NewVerifier(""),
+			nil,
+			noop.NewTracerProvider(),
+			metrics.New(),
+			nil,
+			ihttp.NewWebsocketConnections(),
+			&config.RateLimit{QueriesPerMinute: math.MaxInt32, QueryBurst: math.MaxInt32, MutationsPerMinute: 20, MutationBurst: 10},
+			ratelimit.NewMemory(),
+			logger,
+		)
+		serve(b, logger, func() http.Handler { return srv })
+	})
+}
+
+func serve(b *testing.B, logger *log.Logger, server func() http.Handler) {
+	b.ReportAllocs()
+	for b.Loop() {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(benchmarkQuery))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(log.WithContext(req.Context(), logger))
+		w := httptest.NewRecorder()
+		server().ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+		}
+	}
+}
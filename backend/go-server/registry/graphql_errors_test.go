@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
+	"github.com/s-blog/backend/go-server/infrastructure/ratelimit"
+	"github.com/s-blog/backend/go-server/interface/graphql/resolver"
+	ihttp "github.com/s-blog/backend/go-server/interface/http"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TestGraphQLErrors クライアントが頼る extensions.code と、内部のエラーを隠すことを確認する
+// DB を設定しないので、DB を使うリゾルバーはパニックする
+func TestGraphQLErrors(t *testing.T) {
+	var logs bytes.Buffer
+	logger := log.New(&logs)
+	srv := graphqlServerProvider(
+		&config.GraphQL{MaxDepth: 10, MaxComplexityAnonymous: 5000, QueryCacheSize: 100, APQCacheSize: 100},
+		&config.CORS{},
+		&resolver.Resolver{},
+		auth.NewVerifier(""),
+		nil,
+		noop.NewTracerProvider(),
+		metrics.New(),
+		nil,
+		ihttp.NewWebsocketConnections(),
+		&config.RateLimit{QueriesPerMinute: 600, QueryBurst: 600, MutationsPerMinute: 600, MutationBurst: 600},
+		ratelimit.NewMemory(),
+		logger,
+	)
+	ts := httptest.NewServer(ihttp.WithLogger(ihttp.NewGraphQLHandler(srv).GraphQL, logger))
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		name        string
+		query       string
+		wantCode    string
+		wantMessage string
+		// wantLog パニックのログに含まれるべき文字列
+		wantLog []string
+	}{
+		{
+			name:        "domain error",
+			query:       `{ article(id: "not-a-uuid") { id } }`,
+			wantCode:    "INVALID_ARGUMENT",
+			wantMessage: "invalid article ID format",
+		},
+		{
+			name:        "authentication required",
+			query:       `mutation { follow(target: {tag: "go"}) }`,
+			wantCode:    "UNAUTHENTICATED",
+			wantMessage: "authentication required",
+		},
+		{
+			name:        "parse error",
+			query:       `{ articles { id `,
+			wantCode:    "GRAPHQL_PARSE_FAILED",
+			wantMessage: "Expected Name, found <EOF>",
+		},
+		{
+			name:        "validation error",
+			query:       `{ nope }`,
+			wantCode:    "GRAPHQL_VALIDATION_FAILED",
+			wantMessage: `Cannot query field "nope" on type "Query".`,
+		},
+		{
+			name:        "panic in a resolver",
+			query:       `{ articles { id } }`,
+			wantCode:    "INTERNAL",
+			wantMessage: "internal system error",
+			wantLog:     []string{"recovered from panic in graphql resolver", "nil pointer dereference", `"stack":"`, `"path":"articles"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			_, resp := postGraphQL(t, ts, map[string]any{"query": tt.query})
+			if len(resp.Errors) != 1 {
+				t.Fatalf("errors = %+v, want one", resp.Errors)
+			}
+			if got := resp.code(); got != tt.wantCode {
+				t.Errorf("extensions.code = %q, want %q", got, tt.wantCode)
+			}
+			if got := resp.Errors[0].Message; got != tt.wantMessage {
+				t.Errorf("message = %q, want %q", got, tt.wantMessage)
+			}
+			for _, want := range tt.wantLog {
+				if !strings.Contains(logs.String(), want) {
+					t.Errorf("log does not contain %q: %s", want, logs.String())
+				}
+			}
+		})
+	}
+}
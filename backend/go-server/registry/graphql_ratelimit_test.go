@@ -0,0 +1,255 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/clientip"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
+	"github.com/s-blog/backend/go-server/infrastructure/ratelimit"
+	"github.com/s-blog/backend/go-server/interface/graphql/resolver"
+	ihttp "github.com/s-blog/backend/go-server/interface/http"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const (
+	rateLimitQuery    = `query Q { __typename }`
+	rateLimitMutation = `mutation M { __typename }`
+)
+
+// newRateLimitServer ミューテーションを1回だけ許可するサーバー
+// ミドルウェアは registry/mux.go と同じ順に重ねる
+func newRateLimitServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	logger := log.New(io.Discard)
+	srv := graphqlServerProvider(
+		&config.GraphQL{MaxDepth: 10, MaxComplexityAnonymous: 5000, QueryCacheSize: 100, APQCacheSize: 100},
+		&config.CORS{},
+		&resolver.Resolver{},
+		auth.NewVerifier(""),
+		nil,
+		noop.NewTracerProvider(),
+		metrics.New(),
+		nil,
+		ihttp.NewWebsocketConnections(),
+		&config.RateLimit{QueriesPerMinute: math.MaxInt32, QueryBurst: math.MaxInt32, MutationsPerMinute: 1, MutationBurst: 1},
+		ratelimit.NewMemory(),
+		logger,
+	)
+	resolverIP, err := clientip.NewResolver(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := ihttp.WithRateLimit(ihttp.NewGraphQLHandler(srv).GraphQL)
+	h = ihttp.WithClientIP(h, resolverIP)
+	h = ihttp.WithLogger(h, logger)
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+type graphqlResponse struct {
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func (r graphqlResponse) code() string {
+	if len(r.Errors) == 0 {
+		return ""
+	}
+	code, _ := r.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func postGraphQL(t *testing.T, ts *httptest.Server, body any) (*http.Response, graphqlResponse) {
+	t.Helper()
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post(ts.URL, "application/json", strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out graphqlResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp, out
+}
+
+func persistedQuery(query string, withQuery bool) map[string]any {
+	sum := sha256.Sum256([]byte(query))
+	body := map[string]any{
+		"extensions": map[string]any{
+			"persistedQuery": map[string]any{"version": 1, "sha256Hash": hex.EncodeToString(sum[:])},
+		},
+	}
+	if withQuery {
+		body["query"] = query
+	}
+	return body
+}
+
+// TestRateLimitClassification 操作の種類はパース後に判定するので、本文の形に関係なくミューテーションとして数える
+func TestRateLimitClassification(t *testing.T) {
+	// 1MB を超える本文でも先頭だけを見て判定しない
+	padding := strings.Repeat(" ", 2<<20)
+
+	tests := []struct {
+		name     string
+		requests []any
+		// wantLimited 最後のリクエストが制限されるか
+		wantLimited bool
+	}{
+		{
+			name:     "queries use the query limit",
+			requests: []any{map[string]any{"query": rateLimitQuery}, map[string]any{"query": rateLimitQuery}},
+		},
+		{
+			name:        "mutations use the mutation limit",
+			requests:    []any{map[string]any{"query": rateLimitMutation}, map[string]any{"query": rateLimitMutation}},
+			wantLimited: true,
+		},
+		{
+			name:     "a mutation does not spend the query limit",
+			requests: []any{map[string]any{"query": rateLimitMutation}, map[string]any{"query": rateLimitQuery}},
+		},
+		{
+			name: "mutation selected by operationName",
+			requests: []any{
+				map[string]any{"query": rateLimitQuery + " " + rateLimitMutation, "operationName": "M"},
+				map[string]any{"query": rateLimitQuery + " " + rateLimitMutation, "operationName": "M"},
+			},
+			wantLimited: true,
+		},
+		{
+			name: "persisted mutation sent by hash only",
+			requests: []any{
+				persistedQuery(rateLimitMutation, true),
+				persistedQuery(rateLimitMutation, false),
+			},
+			wantLimited: true,
+		},
+		{
+			name: "oversized body",
+			requests: []any{
+				map[string]any{"query": padding + rateLimitMutation},
+				map[string]any{"query": padding + rateLimitMutation},
+			},
+			wantLimited: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newRateLimitServer(t)
+			var (
+				resp *http.Response
+				out  graphqlResponse
+			)
+			for i, req := range tt.requests {
+				resp, out = postGraphQL(t, ts, req)
+				if last := i == len(tt.requests)-1; !last && (resp.StatusCode != http.StatusOK || len(out.Errors) > 0) {
+					t.Fatalf("request %d: status %d, errors %+v", i, resp.StatusCode, out.Errors)
+				}
+			}
+
+			if tt.wantLimited {
+				if resp.StatusCode != http.StatusTooManyRequests || out.code() != "RATE_LIMITED" {
+					t.Errorf("status %d, code %q, want 429 RATE_LIMITED", resp.StatusCode, out.code())
+				}
+				if resp.Header.Get("Retry-After") == "" {
+					t.Error("Retry-After is not set")
+				}
+			} else if resp.StatusCode != http.StatusOK || len(out.Errors) > 0 {
+				t.Errorf("status %d, errors %+v, want 200 without errors", resp.StatusCode, out.Errors)
+			}
+			if resp.Header.Get("RateLimit-Limit") == "" {
+				t.Error("RateLimit-Limit is not set")
+			}
+		})
+	}
+}
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// TestRateLimitWebsocket WebSocket で送ったミューテーションも HTTP と同じバケットで数える
+func TestRateLimitWebsocket(t *testing.T) {
+	ts := newRateLimitServer(t)
+
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	if resp.Header.Get("RateLimit-Limit") != "" {
+		t.Error("the upgrade response should not carry rate limit headers")
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteJSON(wsMessage{Type: "connection_init"}); err != nil {
+		t.Fatal(err)
+	}
+	var ack wsMessage
+	if err := conn.ReadJSON(&ack); err != nil || ack.Type != "connection_ack" {
+		t.Fatalf("connection_init: got %+v, %v", ack, err)
+	}
+
+	subscribe := func(id string) graphqlResponse {
+		t.Helper()
+		payload, _ := json.Marshal(map[string]any{"query": rateLimitMutation})
+		if err := conn.WriteJSON(wsMessage{ID: id, Type: "subscribe", Payload: payload}); err != nil {
+			t.Fatal(err)
+		}
+		for {
+			var msg wsMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if msg.ID != id || msg.Type == "ping" || msg.Type == "complete" {
+				continue
+			}
+			var out graphqlResponse
+			if msg.Type == "error" {
+				// error のペイロードはエラーの配列
+				_ = json.Unmarshal(msg.Payload, &out.Errors)
+			} else {
+				_ = json.Unmarshal(msg.Payload, &out)
+			}
+			return out
+		}
+	}
+
+	if out := subscribe("1"); len(out.Errors) > 0 {
+		t.Fatalf("first mutation: errors %+v", out.Errors)
+	}
+	if out := subscribe("2"); out.code() != "RATE_LIMITED" {
+		t.Errorf("second mutation: code %q, want RATE_LIMITED", out.code())
+	}
+
+	// 同じクライアントからの HTTP のミューテーションも制限される
+	httpResp, out := postGraphQL(t, ts, map[string]any{"query": rateLimitMutation})
+	if httpResp.StatusCode != http.StatusTooManyRequests || out.code() != "RATE_LIMITED" {
+		t.Errorf("http mutation after websocket: status %d, code %q, want 429 RATE_LIMITED", httpResp.StatusCode, out.code())
+	}
+}
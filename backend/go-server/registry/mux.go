@@ -3,18 +3,41 @@ package registry
 import (
 	stdhttp "net/http"
 
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/infrastructure/health"
+	"github.com/s-blog/backend/go-server/infrastructure/newsletter"
+	"github.com/s-blog/backend/go-server/infrastructure/storage"
 	"github.com/s-blog/backend/go-server/interface/http"
-	"gorm.io/gorm"
 )
 
 func newMux(
 	cfg *config.Vars,
-	db *gorm.DB,
+	st storage.Storage,
+	nl *newsletter.Service,
+	gqlServer *handler.Server,
+	gql *config.GraphQL,
+	checks *health.Registry,
 ) *stdhttp.ServeMux {
 	mux := stdhttp.NewServeMux()
-	mux.HandleFunc("/health", http.NewHealthCheckHandler(db).HealthCheck)
-	mux.HandleFunc("/graphql", http.NewGraphQLHandler(db).GraphQL)
+	healthHandler := http.NewHealthCheckHandler(checks)
+	mux.HandleFunc("/healthz", healthHandler.Healthz)
+	mux.HandleFunc("/readyz", healthHandler.Readyz)
+	mux.HandleFunc("/graphql", http.WithRateLimit(http.NewGraphQLHandler(gqlServer).GraphQL))
+
+	if gql.PlaygroundEnabled {
+		mux.Handle(gql.PlaygroundPath, playground.Handler("GraphQL Playground", "/graphql"))
+	}
+
+	newsletterHandler := http.NewNewsletterHandler(nl)
+	mux.HandleFunc("/newsletter/confirm", newsletterHandler.Confirm)
+	mux.HandleFunc("/newsletter/unsubscribe", newsletterHandler.Unsubscribe)
+
+	// ローカルストレージの場合はアップロードされたファイルをこのサーバーで配信する
+	if local, ok := st.(*storage.Local); ok {
+		mux.Handle("/media/", stdhttp.StripPrefix("/media/", stdhttp.FileServer(stdhttp.Dir(local.Dir()))))
+	}
 
 	return mux
 }
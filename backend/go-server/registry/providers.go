@@ -1,25 +1,394 @@
 package registry
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/domain/repository"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	infragorm "github.com/s-blog/backend/go-server/infrastructure/gorm"
+	"github.com/s-blog/backend/go-server/infrastructure/health"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/mail"
+	"github.com/s-blog/backend/go-server/infrastructure/media"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
+	"github.com/s-blog/backend/go-server/infrastructure/newsletter"
+	"github.com/s-blog/backend/go-server/infrastructure/pubsub"
+	"github.com/s-blog/backend/go-server/infrastructure/ratelimit"
+	"github.com/s-blog/backend/go-server/infrastructure/related"
+	"github.com/s-blog/backend/go-server/infrastructure/spam"
+	"github.com/s-blog/backend/go-server/infrastructure/storage"
+	"github.com/s-blog/backend/go-server/infrastructure/tracing"
+	"github.com/s-blog/backend/go-server/infrastructure/worker"
+	"github.com/s-blog/backend/go-server/interface/graphql/allowlist"
+	"github.com/s-blog/backend/go-server/interface/graphql/gqlmetrics"
+	"github.com/s-blog/backend/go-server/interface/graphql/resolver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func gormDBProvider(db *config.Database) (*gorm.DB, error) {
-	// 直接GORMのPostgres接続を使用
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=5435 sslmode=disable",
-		db.Host, db.User, db.Password, db.Name,
+func gormDBProvider(db *config.Database, creds infragorm.CredentialProvider, tp trace.TracerProvider) (*gorm.DB, func(), error) {
+	return openGormDB(db, db.DataSourceName(), creds, tp)
+}
+
+// NewCredentialProvider 接続のたびにDBのパスワードを取得する方法。マイグレーションでも使う
+func NewCredentialProvider(cfg *config.Database) (infragorm.CredentialProvider, error) {
+	switch cfg.CredentialProvider {
+	case "static":
+		return infragorm.StaticCredential(cfg.Password), nil
+	case "file":
+		return &infragorm.FileCredential{Path: cfg.PasswordFile}, nil
+	case "command":
+		return &infragorm.CommandCredential{Command: strings.Fields(cfg.PasswordCommand), TTL: cfg.PasswordCommandTTL}, nil
+	default:
+		return nil, fmt.Errorf("unknown database credential provider: %s", cfg.CredentialProvider)
+	}
+}
+
+// dbRouterProvider レプリカに接続し、読み取りの振り分け先を作る
+func dbRouterProvider(cfg *config.Database, primary *gorm.DB, creds infragorm.CredentialProvider, tp trace.TracerProvider) (*infragorm.Router, func(), error) {
+	var replicas []*gorm.DB
+	var cleanups []func()
+	cleanup := func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
+	for _, dsn := range cfg.ReplicaDataSourceNames() {
+		replica, c, err := openGormDB(cfg, dsn, creds, tp)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		replicas = append(replicas, replica)
+		cleanups = append(cleanups, c)
+	}
+
+	router, err := infragorm.NewRouter(primary, replicas...)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return router, cleanup, nil
+}
+
+// transactorProvider トランザクションは常にプライマリで実行する
+func transactorProvider(cfg *config.Database, db *gorm.DB, logger *log.Logger) repository.Transactor {
+	return infragorm.NewTransactor(db, logger, infragorm.TransactorOptions{
+		MaxAttempts: cfg.TxMaxAttempts,
+		BaseDelay:   cfg.TxRetryBaseDelay,
+		MaxDelay:    cfg.TxRetryMaxDelay,
+	})
+}
+
+// openGormDB 接続プールの設定はプライマリとレプリカで共通にする
+func openGormDB(cfg *config.Database, dsn string, creds infragorm.CredentialProvider, tp trace.TracerProvider) (*gorm.DB, func(), error) {
+	sqlDB, err := infragorm.OpenSQL(dsn, creds)
+	if err != nil {
+		return nil, nil, err
+	}
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	cleanup := func() {
+		_ = sqlDB.Close()
+	}
+	if err := gormDB.Use(tracing.NewGormPlugin(tp)); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	return gormDB, cleanup, nil
+}
+
+// tracerProviderProvider エクスポーターが none の場合はスパンを記録しない
+func tracerProviderProvider(ctx context.Context, cfg *config.Tracing, logger *log.Logger) (trace.TracerProvider, func(), error) {
+	otel.SetTextMapPropagator(tracing.Propagator())
+
+	exporter, err := tracing.NewExporter(ctx, cfg.Exporter, os.Stdout)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exporter == nil {
+		return noop.NewTracerProvider(), func() {}, nil
+	}
+
+	tp := tracing.NewProvider(exporter, cfg.ServiceName, cfg.SampleRatio)
+	otel.SetTracerProvider(tp)
+	return tp, func() {
+		// 送信前のスパンを書き出してから終了する
+		if err := tp.Shutdown(ctx); err != nil {
+			logger.Error(ctx, "failed to shutdown tracer provider", zap.Error(err))
+		}
+	}, nil
+}
+
+// metricsProvider DBの接続プールと業務指標もスクレイプで集計する
+func metricsProvider(db *gorm.DB, router *infragorm.Router) (*metrics.Metrics, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	m := metrics.New()
+	m.Registry.MustRegister(
+		collectors.NewDBStatsCollector(sqlDB, "postgres"),
+		metrics.NewContentCollector(db),
 	)
+	for i, replica := range router.Replicas() {
+		sqlDB, err := replica.DB()
+		if err != nil {
+			return nil, err
+		}
+		m.Registry.MustRegister(collectors.NewDBStatsCollector(sqlDB, fmt.Sprintf("postgres_replica_%d", i)))
+	}
+	return m, nil
+}
+
+// healthCheckKey 存在しないキーを問い合わせて、ストレージに接続できるかを確認する
+const healthCheckKey = ".healthz"
+
+// healthRegistryProvider /readyz で確認する依存先
+func healthRegistryProvider(cfg *config.Server, db *gorm.DB, st storage.Storage, mailer mail.Mailer) *health.Registry {
+	checks := health.NewRegistry(cfg.HealthCheckTimeout)
+	checks.Register("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	checks.Register("migrations", func(ctx context.Context) error {
+		version, err := infragorm.AppliedSchemaVersion(ctx, db)
+		if err != nil {
+			return err
+		}
+		if version < infragorm.SchemaVersion {
+			return fmt.Errorf("schema version %d is behind %d", version, infragorm.SchemaVersion)
+		}
+		return nil
+	})
+	checks.Register("storage", func(ctx context.Context) error {
+		_, err := st.Exists(ctx, healthCheckKey)
+		return err
+	})
+	checks.Register("mail", func(ctx context.Context) error {
+		if p, ok := mailer.(mail.Pinger); ok {
+			return p.Ping(ctx)
+		}
+		return nil
+	})
+	return checks
+}
 
-	// postgres.Openを使用して直接接続
-	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+func storageProvider(cfg *config.Storage) (storage.Storage, error) {
+	switch cfg.Backend {
+	case "local":
+		return storage.NewLocal(cfg.LocalDir, cfg.PublicBaseURL)
+	case "s3":
+		return storage.NewS3(storage.S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+			BaseURL:   cfg.PublicBaseURL,
+		})
+	case "memory":
+		return storage.NewMemory(cfg.PublicBaseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Backend)
+	}
+}
+
+func workerPoolProvider(ctx context.Context, cfg *config.Worker, logger *log.Logger) (*worker.Pool, func()) {
+	pool := worker.NewPool(logger, cfg.Concurrency, cfg.QueueSize)
+	pool.Start(ctx)
+	return pool, func() {
+		// 実行中のタスクを待ち、時間内に終わらなければキャンセルする
+		ctx, cancel := context.WithTimeout(ctx, cfg.StopTimeout)
+		defer cancel()
+		if err := pool.Stop(ctx); err != nil {
+			logger.Error(ctx, "failed to stop worker pool", zap.Error(err))
+		}
+	}
+}
+
+func mediaProcessorProvider(ctx context.Context, cfg *config.Storage, db *gorm.DB, st storage.Storage, pool *worker.Pool, logger *log.Logger) *media.Processor {
+	p := media.NewProcessor(db, st, pool, cfg.MaxImagePixels)
+	if err := p.ResumePending(ctx); err != nil {
+		// 起動は止めずに、次回の起動時に再度処理する
+		logger.Warn(ctx, "failed to resume pending media", zap.Error(err))
+	}
+	return p
+}
+
+func recommenderProvider(ctx context.Context, cfg *config.Related, db *gorm.DB, pool *worker.Pool, logger *log.Logger) (*related.Recommender, func()) {
+	rec := related.NewRecommender(db, pool, related.Options{
+		MaxResults: cfg.MaxResults,
+		Candidates: cfg.Candidates,
+		TagWeight:  cfg.TagWeight,
+		MinScore:   cfg.MinScore,
+	})
+	if err := rec.ResumeStale(ctx); err != nil {
+		// 起動は止めずに、次の Refresh で再度探す
+		logger.Warn(ctx, "failed to resume stale related articles", zap.Error(err))
+	}
+	ctx, cancel := context.WithCancel(log.WithContext(ctx, logger))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rec.Refresh(ctx, cfg.RefreshInterval)
+	}()
+	// 実行中の再計算が終わるのを待ってから DB を閉じさせる
+	return rec, func() {
+		cancel()
+		<-done
+	}
+}
+
+func mailerProvider(cfg *config.Mail, logger *log.Logger) (mail.Mailer, error) {
+	switch cfg.Backend {
+	case "smtp":
+		return mail.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		return mail.NewFile(cfg.FileDir, cfg.From)
+	case "log":
+		return mail.NewLog(logger, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend: %s", cfg.Backend)
+	}
+}
+
+func newsletterProvider(ctx context.Context, cfg *config.Newsletter, db *gorm.DB, mailer mail.Mailer, pool *worker.Pool, logger *log.Logger) *newsletter.Service {
+	svc := newsletter.NewService(db, mailer, pool, newsletter.Options{
+		SiteURL:        cfg.SiteURL,
+		APIURL:         cfg.APIURL,
+		ConfirmTTL:     cfg.ConfirmTTL,
+		ResendInterval: cfg.ResendInterval,
+		MaxAttempts:    cfg.MaxAttempts,
+		BatchSize:      cfg.BatchSize,
+		RetryDelay:     cfg.RetryDelay,
+	})
+	if err := svc.ResumePending(ctx); err != nil {
+		logger.Warn(ctx, "failed to resume pending newsletter deliveries", zap.Error(err))
+	}
+	return svc
+}
+
+func authVerifierProvider(cfg *config.Auth) *auth.Verifier {
+	return auth.NewVerifier(cfg.JWTSecret)
+}
+
+func pubsubProvider(ctx context.Context, cfg *config.PubSub, db *gorm.DB, logger *log.Logger) (pubsub.Broker, func(), error) {
+	switch cfg.Backend {
+	case "memory":
+		b := pubsub.NewMemory()
+		return b, b.Close, nil
+	case "postgres":
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, nil, err
+		}
+		b := pubsub.NewPostgres(ctx, sqlDB, logger)
+		return b, b.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown pubsub backend: %s", cfg.Backend)
+	}
+}
+
+func rateLimitStoreProvider(ctx context.Context, cfg *config.RateLimit, db *gorm.DB, logger *log.Logger) (ratelimit.Store, func(), error) {
+	switch cfg.Backend {
+	case "memory":
+		return ratelimit.NewMemory(), func() {}, nil
+	case "postgres":
+		store := ratelimit.NewPostgres(db, logger)
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			store.Prune(ctx, 10*time.Minute, time.Hour)
+		}()
+		return store, func() {
+			cancel()
+			<-done
+		}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown rate limit backend: %s", cfg.Backend)
+	}
+}
+
+// allowlistProvider 厳格モードでない場合は nil を返し、どの操作も許可する
+func allowlistProvider(ctx context.Context, cfg *config.GraphQL, logger *log.Logger) (*allowlist.Allowlist, error) {
+	if !cfg.StrictAllowlist {
+		return nil, nil
+	}
+	ops, err := allowlist.Load(cfg.AllowlistFile)
 	if err != nil {
 		return nil, err
 	}
+	logger.Info(ctx, fmt.Sprintf("graphql operation allowlist enabled with %d operations", ops.Len()))
+	return ops, nil
+}
 
-	return gormDB, nil
+// knownOperationsProvider 厳格モードでなくてもマニフェストの操作名をメトリクスのラベルに使う
+func knownOperationsProvider(cfg *config.GraphQL) (gqlmetrics.KnownOperations, error) {
+	m, err := allowlist.LoadManifest(cfg.AllowlistFile)
+	if err != nil {
+		return nil, err
+	}
+	return m.Names(), nil
+}
+
+func spamCheckerProvider(cfg *config.Spam) *spam.Chain {
+	// 安価な判定から順に実行する
+	return spam.NewChain(
+		spam.Honeypot{},
+		spam.LinkLimit{Max: cfg.MaxLinks},
+		spam.BannedWords{},
+		spam.Duplicate{Window: cfg.DuplicateWindow},
+		spam.Velocity{Window: cfg.VelocityWindow, MaxPerUser: cfg.MaxPerUser, MaxPerIP: cfg.MaxPerIP},
+	)
+}
+
+func resolverProvider(
+	cfg *config.Storage,
+	db *gorm.DB,
+	router *infragorm.Router,
+	tx repository.Transactor,
+	st storage.Storage,
+	mp *media.Processor,
+	nl *newsletter.Service,
+	rec *related.Recommender,
+	ps pubsub.Broker,
+	sc *spam.Chain,
+) *resolver.Resolver {
+	return &resolver.Resolver{
+		DB:             db,
+		Router:         router,
+		Transactor:     tx,
+		Storage:        st,
+		MediaProcessor: mp,
+		Newsletter:     nl,
+		Related:        rec,
+		PubSub:         ps,
+		SpamChecker:    sc,
+		MaxUploadBytes: cfg.MaxUploadBytes,
+		MaxImagePixels: cfg.MaxImagePixels,
+	}
 }
@@ -0,0 +1,258 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
+	"github.com/s-blog/backend/go-server/infrastructure/ratelimit"
+	"github.com/s-blog/backend/go-server/infrastructure/tracing"
+	"github.com/s-blog/backend/go-server/interface/graphql/resolver"
+	ihttp "github.com/s-blog/backend/go-server/interface/http"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const (
+	upstreamTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	upstreamSpanID  = "00f067aa0ba902b7"
+)
+
+// newTracedHandler HTTP、GraphQL、GORM のすべてで tp を使うハンドラー
+// DB はどの問い合わせにも0行を返す
+func newTracedHandler(t *testing.T, tp trace.TracerProvider) http.HandlerFunc {
+	t.Helper()
+	logger := log.New(io.Discard)
+
+	sqlDB := sql.OpenDB(emptyConnector{})
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:               gormlogger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+	if err := db.Use(tracing.NewGormPlugin(tp)); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := graphqlServerProvider(
+		&config.GraphQL{MaxDepth: 10, MaxComplexityAnonymous: 5000, QueryCacheSize: 100, APQCacheSize: 100},
+		&config.CORS{},
+		&resolver.Resolver{DB: db},
+		auth.NewVerifier(""),
+		nil,
+		tp,
+		metrics.New(),
+		nil,
+		ihttp.NewWebsocketConnections(),
+		&config.RateLimit{QueriesPerMinute: 60, QueryBurst: 60, MutationsPerMinute: 60, MutationBurst: 60},
+		ratelimit.NewMemory(),
+		logger,
+	)
+	// cmd/server と同じく、ログの後にトレースを始める
+	return ihttp.WithLogger(ihttp.WithTracing(ihttp.NewGraphQLHandler(srv).GraphQL, tp), logger)
+}
+
+func TestTracing(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		// wantRemoteParent HTTPのスパンが上流のスパンの子になるか
+		wantRemoteParent bool
+		wantSpans        bool
+	}{
+		{name: "new trace", wantSpans: true},
+		{
+			name:             "continues a sampled upstream trace",
+			traceparent:      "00-" + upstreamTraceID + "-" + upstreamSpanID + "-01",
+			wantRemoteParent: true,
+			wantSpans:        true,
+		},
+		{
+			name:        "respects an unsampled upstream trace",
+			traceparent: "00-" + upstreamTraceID + "-" + upstreamSpanID + "-00",
+		},
+		{
+			name:        "ignores a malformed traceparent",
+			traceparent: "00-not-a-trace-01",
+			wantSpans:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sr := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(
+				sdktrace.WithSpanProcessor(sr),
+				sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+			)
+			h := newTracedHandler(t, tp)
+
+			req := httptest.NewRequest(http.MethodPost, "/graphql",
+				strings.NewReader(`{"query":"query Articles { articles { id } }"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			w := httptest.NewRecorder()
+			h(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+
+			spans := sr.Ended()
+			if !tt.wantSpans {
+				if len(spans) != 0 {
+					t.Errorf("recorded %d spans, want none", len(spans))
+				}
+				return
+			}
+
+			httpSpan := findSpan(t, spans, "http.server")
+			opSpan := findSpan(t, spans, "query Articles")
+			fieldSpan := findSpan(t, spans, "Query.articles")
+			dbSpan := findSpan(t, spans, "SELECT articles")
+
+			if tt.wantRemoteParent {
+				if got := httpSpan.SpanContext().TraceID().String(); got != upstreamTraceID {
+					t.Errorf("trace id = %s, want %s", got, upstreamTraceID)
+				}
+				if got := httpSpan.Parent().SpanID().String(); got != upstreamSpanID || !httpSpan.Parent().IsRemote() {
+					t.Errorf("http span parent = %s (remote %v), want remote %s", got, httpSpan.Parent().IsRemote(), upstreamSpanID)
+				}
+			} else if httpSpan.Parent().IsValid() {
+				t.Errorf("http span has parent %s, want a root span", httpSpan.Parent().SpanID())
+			}
+			if httpSpan.SpanKind() != trace.SpanKindServer {
+				t.Errorf("http span kind = %s, want server", httpSpan.SpanKind())
+			}
+
+			assertChild(t, httpSpan, opSpan)
+			assertChild(t, opSpan, fieldSpan)
+			assertChild(t, fieldSpan, dbSpan)
+
+			assertAttribute(t, opSpan, "graphql.operation.type", "query")
+			assertAttribute(t, opSpan, "graphql.operation.name", "Articles")
+			assertAttribute(t, fieldSpan, "graphql.field.path", "articles")
+			assertAttribute(t, dbSpan, "db.system.name", "postgresql")
+			assertAttribute(t, dbSpan, "db.operation.name", "SELECT")
+			assertAttribute(t, dbSpan, "db.collection.name", "articles")
+			if dbSpan.SpanKind() != trace.SpanKindClient {
+				t.Errorf("db span kind = %s, want client", dbSpan.SpanKind())
+			}
+			if q := attributeValue(dbSpan, "db.query.text"); !strings.Contains(q, `"articles"`) {
+				t.Errorf("db.query.text = %q, want the SQL for articles", q)
+			}
+
+			for _, s := range spans {
+				if s.SpanContext().TraceID() != httpSpan.SpanContext().TraceID() {
+					t.Errorf("span %q is in trace %s, want %s", s.Name(), s.SpanContext().TraceID(), httpSpan.SpanContext().TraceID())
+				}
+			}
+		})
+	}
+}
+
+func findSpan(t *testing.T, spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name() == name {
+			return s
+		}
+	}
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	t.Fatalf("span %q not found in %q", name, names)
+	return nil
+}
+
+func assertChild(t *testing.T, parent, child sdktrace.ReadOnlySpan) {
+	t.Helper()
+	if child.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("span %q has parent %s, want %q (%s)",
+			child.Name(), child.Parent().SpanID(), parent.Name(), parent.SpanContext().SpanID())
+	}
+}
+
+func attributeValue(s sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range s.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func assertAttribute(t *testing.T, s sdktrace.ReadOnlySpan, key attribute.Key, want string) {
+	t.Helper()
+	if got := attributeValue(s, key); got != want {
+		t.Errorf("span %q attribute %s = %q, want %q", s.Name(), key, got, want)
+	}
+}
+
+// emptyConnector どの問い合わせにも0行を返すドライバー
+type emptyConnector struct{}
+
+func (emptyConnector) Connect(context.Context) (driver.Conn, error) {
+	return emptyConn{}, nil
+}
+
+func (emptyConnector) Driver() driver.Driver {
+	return emptyDriver{}
+}
+
+type emptyDriver struct{}
+
+func (emptyDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("use the connector")
+}
+
+type emptyConn struct{}
+
+func (emptyConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (emptyConn) Close() error {
+	return nil
+}
+
+func (emptyConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+func (emptyConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (emptyRows) Close() error {
+	return nil
+}
+
+func (emptyRows) Next([]driver.Value) error {
+	return io.EOF
+}
@@ -9,19 +9,49 @@ import (
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/health"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
 
 	"github.com/google/wire"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type MuxServer struct {
-	Mux *http.ServeMux
+	Mux            *http.ServeMux
+	Verifier       *auth.Verifier
+	TracerProvider trace.TracerProvider
+	Metrics        *metrics.Metrics
+	Health         *health.Registry
 }
 
-func InitMuxServer(ctx context.Context, cfg *config.Vars) (*MuxServer, func(), error) {
+func InitMuxServer(ctx context.Context, cfg *config.Vars, logger *log.Logger) (*MuxServer, func(), error) {
 	panic(wire.Build(
-		wire.FieldsOf(new(*config.Vars), "Database"),
+		wire.FieldsOf(new(*config.Vars), "Database", "Storage", "Worker", "Related", "Mail", "Newsletter", "Auth", "PubSub", "CORS", "Spam", "RateLimit", "GraphQL", "Tracing", "Server"),
+		tracerProviderProvider,
+		NewCredentialProvider,
 		gormDBProvider,
+		dbRouterProvider,
+		transactorProvider,
+		metricsProvider,
+		healthRegistryProvider,
+		storageProvider,
+		workerPoolProvider,
+		mediaProcessorProvider,
+		mailerProvider,
+		newsletterProvider,
+		recommenderProvider,
+		authVerifierProvider,
+		pubsubProvider,
+		spamCheckerProvider,
+		rateLimitStoreProvider,
+		allowlistProvider,
+		knownOperationsProvider,
+		websocketConnectionsProvider,
+		graphqlServerProvider,
+		resolverProvider,
 		newMux,
-		wire.Struct(new(MuxServer), "Mux"),
+		wire.Struct(new(MuxServer), "Mux", "Verifier", "TracerProvider", "Metrics", "Health"),
 	))
 }
@@ -9,6 +9,11 @@ package registry
 import (
 	"context"
 	"github.com/s-blog/backend/go-server/domain/config"
+	"github.com/s-blog/backend/go-server/infrastructure/auth"
+	"github.com/s-blog/backend/go-server/infrastructure/health"
+	"github.com/s-blog/backend/go-server/infrastructure/log"
+	"github.com/s-blog/backend/go-server/infrastructure/metrics"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
 )
 
@@ -18,22 +23,138 @@ import (
 
 // Injectors from wire.go:
 
-func InitMuxServer(ctx context.Context, cfg *config.Vars) (*MuxServer, func(), error) {
+func InitMuxServer(ctx context.Context, cfg *config.Vars, logger *log.Logger) (*MuxServer, func(), error) {
+	storage := cfg.Storage
+	storageStorage, err := storageProvider(storage)
+	if err != nil {
+		return nil, nil, err
+	}
+	newsletter := cfg.Newsletter
 	database := cfg.Database
-	db, err := gormDBProvider(database)
+	credentialProvider, err := NewCredentialProvider(database)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracing := cfg.Tracing
+	tracerProvider, cleanup, err := tracerProviderProvider(ctx, tracing, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, cleanup2, err := gormDBProvider(database, credentialProvider, tracerProvider)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	mail := cfg.Mail
+	mailer, err := mailerProvider(mail, logger)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	worker := cfg.Worker
+	pool, cleanup3 := workerPoolProvider(ctx, worker, logger)
+	service := newsletterProvider(ctx, newsletter, db, mailer, pool, logger)
+	graphQL := cfg.GraphQL
+	cors := cfg.CORS
+	router, cleanup4, err := dbRouterProvider(database, db, credentialProvider, tracerProvider)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	transactor := transactorProvider(database, db, logger)
+	processor := mediaProcessorProvider(ctx, storage, db, storageStorage, pool, logger)
+	related := cfg.Related
+	recommender, cleanup5 := recommenderProvider(ctx, related, db, pool, logger)
+	pubSub := cfg.PubSub
+	broker, cleanup6, err := pubsubProvider(ctx, pubSub, db, logger)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	spam := cfg.Spam
+	chain := spamCheckerProvider(spam)
+	resolver := resolverProvider(storage, db, router, transactor, storageStorage, processor, service, recommender, broker, chain)
+	auth := cfg.Auth
+	verifier := authVerifierProvider(auth)
+	allowlist, err := allowlistProvider(ctx, graphQL, logger)
+	if err != nil {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	metrics, err := metricsProvider(db, router)
+	if err != nil {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	knownOperations, err := knownOperationsProvider(graphQL)
+	if err != nil {
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	websocketConnections, cleanup7 := websocketConnectionsProvider()
+	rateLimit := cfg.RateLimit
+	store, cleanup8, err := rateLimitStoreProvider(ctx, rateLimit, db, logger)
 	if err != nil {
+		cleanup7()
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
 		return nil, nil, err
 	}
-	serveMux := newMux(cfg, db)
+	server := graphqlServerProvider(graphQL, cors, resolver, verifier, allowlist, tracerProvider, metrics, knownOperations, websocketConnections, rateLimit, store, logger)
+	configServer := cfg.Server
+	registry := healthRegistryProvider(configServer, db, storageStorage, mailer)
+	serveMux := newMux(cfg, storageStorage, service, server, graphQL, registry)
 	muxServer := &MuxServer{
-		Mux: serveMux,
+		Mux:            serveMux,
+		Verifier:       verifier,
+		TracerProvider: tracerProvider,
+		Metrics:        metrics,
+		Health:         registry,
 	}
 	return muxServer, func() {
+		cleanup8()
+		cleanup7()
+		cleanup6()
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
 	}, nil
 }
 
 // wire.go:
 
 type MuxServer struct {
-	Mux *http.ServeMux
+	Mux            *http.ServeMux
+	Verifier       *auth.Verifier
+	TracerProvider trace.TracerProvider
+	Metrics        *metrics.Metrics
+	Health         *health.Registry
 }